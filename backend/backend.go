@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"path"
 	"strings"
 
@@ -21,6 +23,8 @@ import (
 const (
 	archivePrefix = "archive"
 	livePrefix    = "live"
+
+	signatureMetadataKey = "Signature"
 )
 
 var defaultEntropySource = rand.Reader
@@ -31,19 +35,48 @@ type Backend struct {
 
 	s3         s3iface.S3API
 	bucketName *string
+	signer     secretservice.Signer
+	hashKey    []byte
+}
+
+// Option customizes the Backend.
+type Option func(*Backend)
+
+// WithSigner makes the Backend sign every new Release, and verify signatures
+// of retrieved ones.
+func WithSigner(signer secretservice.Signer) Option {
+	return func(b *Backend) {
+		b.signer = signer
+	}
+}
+
+// WithContentHashKey sets the key of content hashes of Releases and
+// workspaces. Without it, content hashes are keyed with an empty key.
+func WithContentHashKey(key []byte) Option {
+	return func(b *Backend) {
+		b.hashKey = key
+	}
 }
 
 // New returns an implementation of Secret Service backend.
-func New(ssm ssmvars.ReadWriter, s3 s3iface.S3API, bucketName string) *Backend {
-	return &Backend{
+func New(ssm ssmvars.ReadWriter, s3 s3iface.S3API, bucketName string, opts ...Option) *Backend {
+	ret := &Backend{
 		ReadWriter: ssm,
 		s3:         s3,
 		bucketName: aws.String(bucketName),
 	}
+
+	for _, opt := range opts {
+		opt(ret)
+	}
+
+	return ret
 }
 
-// CreateRelease creates a release with a given set of variables.
-func (b *Backend) CreateRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable) (*secretservice.Release, error) {
+// CreateRelease creates a release with a given set of variables. If the
+// release was derived from another one, its ID should be passed as
+// baseReleaseID.
+func (b *Backend) CreateRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable, baseReleaseID string) (*secretservice.Release, error) {
 	ulid, err := ulid.New(ulid.MaxTime()-ulid.Now(), defaultEntropySource)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not generate an ID")
@@ -55,10 +88,13 @@ func (b *Backend) CreateRelease(ctx context.Context, scopeName string, variables
 	}
 
 	release := &secretservice.Release{
-		ID:        ulid.String(),
-		ScopeName: scopeName,
-		Live:      true,
-		Variables: variables,
+		ID:               ulid.String(),
+		ScopeName:        scopeName,
+		Live:             true,
+		BaseReleaseID:    baseReleaseID,
+		ContentHash:      b.ContentHash(variables),
+		ContentHashKeyID: secretservice.ContentHashKeyID(b.hashKey),
+		Variables:        variables,
 	}
 
 	body, err := json.Marshal(release)
@@ -66,17 +102,30 @@ func (b *Backend) CreateRelease(ctx context.Context, scopeName string, variables
 		return nil, errors.Wrap(err, "could not marshal the release")
 	}
 
+	var metadata map[string]*string
+	if b.signer != nil {
+		signature, err := b.signer.Sign(ctx, signedPayload(scopeName, release.ID, body))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not sign the release")
+		}
+		metadata = map[string]*string{
+			signatureMetadataKey: aws.String(base64.StdEncoding.EncodeToString(signature)),
+		}
+		release.Verified = true
+	}
+
 	kmsKeyID := aws.String(scope.KMSKeyID)
 	archiveKey := b.objectKey(release.ScopeName, archivePrefix, release.ID)
 	_, err = b.s3.PutObjectWithContext(ctx, &s3.PutObjectInput{
 		Body:                 bytes.NewReader(body),
 		Bucket:               b.bucketName,
 		Key:                  archiveKey,
+		Metadata:             metadata,
 		SSEKMSKeyId:          kmsKeyID,
 		ServerSideEncryption: aws.String("aws:kms"),
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "could not put archive object to S3")
+		return nil, errors.Wrap(classify(err), "could not put archive object to S3")
 	}
 
 	_, err = b.s3.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
@@ -87,30 +136,47 @@ func (b *Backend) CreateRelease(ctx context.Context, scopeName string, variables
 		ServerSideEncryption: aws.String("aws:kms"),
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "could not copy live version on S3")
+		return nil, errors.Wrap(classify(err), "could not copy live version on S3")
 	}
 
 	return release, nil
 }
 
-// GetRelease retrieves a release given its ID.
+// GetRelease retrieves a release given its ID. If the Backend has a signer,
+// the signature of the release is verified, and releases without a valid
+// signature are rejected.
 func (b *Backend) GetRelease(ctx context.Context, scopeName, releaseID string) (*secretservice.Release, error) {
 	output, err := b.s3.GetObjectWithContext(ctx, &s3.GetObjectInput{
 		Bucket: b.bucketName,
 		Key:    b.objectKey(scopeName, archivePrefix, releaseID),
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "could not retrieve object from S3")
+		return nil, errors.Wrap(classify(err), "could not retrieve object from S3")
 	}
 	defer output.Body.Close()
 
+	body, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read object from S3")
+	}
+
+	verified, err := b.verify(ctx, signedPayload(scopeName, releaseID, body), output.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
 	release := new(secretservice.Release)
-	if err := json.NewDecoder(output.Body).Decode(&release); err != nil {
+	if err := json.Unmarshal(body, &release); err != nil {
 		return nil, errors.Wrap(err, "could not unmarshal release")
 	}
 
 	release.ID = releaseID
 	release.ScopeName = scopeName
+	release.Verified = verified
+
+	if err := b.checkContentHash(release); err != nil {
+		return nil, err
+	}
 
 	release.Live, err = b.isLive(ctx, scopeName, releaseID)
 	if err != nil {
@@ -120,6 +186,31 @@ func (b *Backend) GetRelease(ctx context.Context, scopeName, releaseID string) (
 	return release, nil
 }
 
+// checkContentHash verifies the content hash stored with a Release if it was
+// computed with the current key. Hashes computed with a previous key are kept
+// as they are, so that they do not change when the key is rotated. Releases
+// created before hashes were stored get one computed with the current key.
+func (b *Backend) checkContentHash(release *secretservice.Release) error {
+	hash := b.ContentHash(release.Variables)
+
+	if release.ContentHash == "" {
+		release.ContentHash = hash
+		return nil
+	}
+
+	if release.ContentHashKeyID == secretservice.ContentHashKeyID(b.hashKey) && release.ContentHash != hash {
+		return errors.Errorf("content hash of release %q does not match its variables", release.ID)
+	}
+
+	return nil
+}
+
+// ContentHash returns the content hash of a set of Variables, keyed with the
+// key of the Backend.
+func (b *Backend) ContentHash(variables []*ssmvars.Variable) string {
+	return secretservice.ContentHash(b.hashKey, variables)
+}
+
 // ArchiveRelease archives a release.
 func (b *Backend) ArchiveRelease(ctx context.Context, scopeName, releaseID string) error {
 	_, err := b.s3.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
@@ -127,7 +218,7 @@ func (b *Backend) ArchiveRelease(ctx context.Context, scopeName, releaseID strin
 		Key:    b.objectKey(scopeName, "live", releaseID),
 	})
 
-	return errors.Wrap(err, "could not remove live object from S3")
+	return errors.Wrap(classify(err), "could not remove live object from S3")
 }
 
 // ListReleases return a list of release IDs. If `before` argument is not nil,
@@ -143,7 +234,7 @@ func (b *Backend) ListReleases(ctx context.Context, scopeName string, before *st
 	})
 
 	if err != nil {
-		return nil, errors.Wrap(err, "could not list objects with a prefix")
+		return nil, errors.Wrap(classify(err), "could not list objects with a prefix")
 	}
 
 	var ret []string
@@ -162,9 +253,40 @@ func (b *Backend) ListReleases(ctx context.Context, scopeName string, before *st
 func (b *Backend) Scope(ctx context.Context, scopeName string) (*secretservice.Scope, error) {
 	scopeVar, err := b.ShowVariable(ctx, "scopes", scopeName)
 	if err != nil {
-		return nil, errors.Wrapf(err, "could not find scope %q", scopeName)
+		return nil, errors.Wrapf(classify(err), "could not find scope %q", scopeName)
 	}
-	return &secretservice.Scope{Name: scopeName, KMSKeyID: scopeVar.Value}, nil
+	return secretservice.ParseScope(scopeName, scopeVar.Value)
+}
+
+func (b *Backend) verify(ctx context.Context, payload []byte, metadata map[string]*string) (bool, error) {
+	if b.signer == nil {
+		return false, nil
+	}
+
+	encoded, signed := metadata[signatureMetadataKey]
+	if !signed {
+		return false, errors.New("could not verify release signature: release is not signed")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(aws.StringValue(encoded))
+	if err != nil {
+		return false, errors.Wrap(err, "could not decode release signature")
+	}
+
+	if err := b.signer.Verify(ctx, payload, signature); err != nil {
+		return false, errors.Wrap(err, "could not verify release signature")
+	}
+
+	return true, nil
+}
+
+// signedPayload is what the signature of a Release covers. The scope name
+// and the release ID are not part of the stored body, so they are prepended
+// to it as netstrings to prevent a signed body from being replayed under
+// another Release.
+func signedPayload(scopeName, releaseID string, body []byte) []byte {
+	header := fmt.Sprintf("%d:%s,%d:%s,", len(scopeName), scopeName, len(releaseID), releaseID)
+	return append([]byte(header), body...)
 }
 
 func (b *Backend) isLive(ctx context.Context, scopeName, releaseID string) (bool, error) {
@@ -173,7 +295,7 @@ func (b *Backend) isLive(ctx context.Context, scopeName, releaseID string) (bool
 		Prefix: b.objectKey(scopeName, livePrefix, releaseID),
 	})
 	if err != nil {
-		return false, errors.Wrap(err, "could not check for live version presence")
+		return false, errors.Wrap(classify(err), "could not check for live version presence")
 	}
 
 	return len(objects.Contents) > 0, nil
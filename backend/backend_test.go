@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/marcinwyszynski/secretservice"
 	"github.com/marcinwyszynski/secretservice/backend"
 	"github.com/marcinwyszynski/ssmvars"
 	"github.com/stretchr/testify/mock"
@@ -19,6 +20,7 @@ import (
 
 const (
 	bucketName = "bucketName"
+	hashKey    = "hashKey"
 	kmsKeyID   = "kmsKeyID"
 	releaseID  = "releaseID"
 	scopeName  = "scopeName"
@@ -43,7 +45,7 @@ func (b *backendTestSuite) SetupTest() {
 	b.ctx = context.Background()
 	b.ssmvars = new(mockSSMVars)
 	b.s3 = new(mockS3)
-	b.sut = backend.New(b.ssmvars, b.s3, bucketName)
+	b.sut = backend.New(b.ssmvars, b.s3, bucketName, backend.WithContentHashKey([]byte(hashKey)))
 }
 
 func (b *backendTestSuite) TestCreateRelease_OK() {
@@ -51,7 +53,7 @@ func (b *backendTestSuite) TestCreateRelease_OK() {
 	b.withPutObject(nil)
 	b.withCopyObject(nil)
 
-	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables)
+	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables, "")
 
 	b.NoError(err)
 	b.NotEmpty(release.ID)
@@ -62,12 +64,36 @@ func (b *backendTestSuite) TestCreateRelease_OK() {
 
 	b.True(release.Live)
 	b.Equal(scopeName, release.ScopeName)
+	b.Equal(secretservice.ContentHash([]byte(hashKey), variables), release.ContentHash)
+	b.Equal(secretservice.ContentHashKeyID([]byte(hashKey)), release.ContentHashKeyID)
+	b.Empty(release.BaseReleaseID)
+}
+
+func (b *backendTestSuite) TestCreateRelease_BaseRelease() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.withPutObject(nil)
+	b.withCopyObject(nil)
+
+	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables, "baseID")
+
+	b.NoError(err)
+	b.Equal("baseID", release.BaseReleaseID)
+}
+
+func (b *backendTestSuite) TestGetRelease_BaseRelease() {
+	b.withGetObject(`{"baseRelease":"baseID","variables":[]}`, nil)
+	b.withLiveObjects(nil)
+
+	release, err := b.sut.GetRelease(b.ctx, scopeName, releaseID)
+
+	b.NoError(err)
+	b.Equal("baseID", release.BaseReleaseID)
 }
 
 func (b *backendTestSuite) TestCreateRelease_FailScope() {
 	b.withShowVariable(nil, errors.New("bacon"))
 
-	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables)
+	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables, "")
 
 	b.Nil(release)
 	b.EqualError(err, `could not find scope "scopeName": bacon`)
@@ -77,7 +103,7 @@ func (b *backendTestSuite) TestCreateRelease_FailPut() {
 	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
 	b.withPutObject(errors.New("bacon"))
 
-	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables)
+	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables, "")
 
 	b.Nil(release)
 	b.EqualError(err, "could not put archive object to S3: bacon")
@@ -88,7 +114,7 @@ func (b *backendTestSuite) TestCreateRelease_FailCopy() {
 	b.withPutObject(nil)
 	b.withCopyObject(errors.New("bacon"))
 
-	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables)
+	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables, "")
 
 	b.Nil(release)
 	b.EqualError(err, "could not copy live version on S3: bacon")
@@ -110,6 +136,45 @@ func (b *backendTestSuite) TestGetRelease_OK() {
 	b.Equal("bacon", variable.Name)
 	b.Equal("tasty", variable.Value)
 	b.True(variable.WriteOnly)
+
+	b.Equal(secretservice.ContentHash([]byte(hashKey), release.Variables), release.ContentHash)
+}
+
+func (b *backendTestSuite) TestGetRelease_StoredContentHash() {
+	hash := secretservice.ContentHash([]byte(hashKey), nil)
+	b.withGetObject(`{"contentHash":"`+hash+`","contentHashKeyId":"`+secretservice.ContentHashKeyID([]byte(hashKey))+`","variables":[]}`, nil)
+	b.withLiveObjects(nil)
+
+	release, err := b.sut.GetRelease(b.ctx, scopeName, releaseID)
+
+	b.NoError(err)
+	b.Equal(hash, release.ContentHash)
+}
+
+func (b *backendTestSuite) TestGetRelease_ContentHashWithPreviousKey() {
+	b.withGetObject(`{"contentHash":"hash","contentHashKeyId":"previous","variables":[]}`, nil)
+	b.withLiveObjects(nil)
+
+	release, err := b.sut.GetRelease(b.ctx, scopeName, releaseID)
+
+	b.NoError(err)
+	b.Equal("hash", release.ContentHash)
+}
+
+func (b *backendTestSuite) TestGetRelease_ContentHashMismatch() {
+	b.withGetObject(`{"contentHash":"hash","contentHashKeyId":"`+secretservice.ContentHashKeyID([]byte(hashKey))+`","variables":[]}`, nil)
+
+	release, err := b.sut.GetRelease(b.ctx, scopeName, releaseID)
+
+	b.Nil(release)
+	b.EqualError(err, `content hash of release "releaseID" does not match its variables`)
+}
+
+func (b *backendTestSuite) TestContentHash() {
+	other := backend.New(b.ssmvars, b.s3, bucketName, backend.WithContentHashKey([]byte("bacon")))
+
+	b.Equal(b.sut.ContentHash(variables), b.sut.ContentHash(variables))
+	b.NotEqual(b.sut.ContentHash(variables), other.ContentHash(variables))
 }
 
 func (b *backendTestSuite) TestGetRelease_NotLive() {
@@ -201,6 +266,45 @@ func (b *backendTestSuite) TestScope_OK() {
 
 	b.Equal(scopeName, ret.Name)
 	b.Equal(kmsKeyID, ret.KMSKeyID)
+	b.Zero(ret.RequiredApprovals)
+	b.Equal(kmsKeyID, ret.Encode())
+}
+
+func (b *backendTestSuite) TestScope_Settings() {
+	b.withShowVariable(&ssmvars.Variable{
+		Name:  scopeName,
+		Value: `{"kmsKeyId":"kmsKeyID","requiredApprovals":2}`,
+	}, nil)
+
+	ret, err := b.sut.Scope(b.ctx, scopeName)
+	b.NoError(err)
+
+	b.Equal(scopeName, ret.Name)
+	b.Equal(kmsKeyID, ret.KMSKeyID)
+	b.Equal(2, ret.RequiredApprovals)
+	b.Equal(`{"kmsKeyId":"kmsKeyID","requiredApprovals":2}`, ret.Encode())
+}
+
+func (b *backendTestSuite) TestScope_Protected() {
+	b.withShowVariable(&ssmvars.Variable{
+		Name:  scopeName,
+		Value: `{"kmsKeyId":"kmsKeyID","protected":true}`,
+	}, nil)
+
+	ret, err := b.sut.Scope(b.ctx, scopeName)
+	b.NoError(err)
+
+	b.True(ret.Protected)
+	b.Zero(ret.RequiredApprovals)
+	b.Equal(`{"kmsKeyId":"kmsKeyID","protected":true}`, ret.Encode())
+}
+
+func (b *backendTestSuite) TestScope_InvalidSettings() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: "{bacon"}, nil)
+
+	ret, err := b.sut.Scope(b.ctx, scopeName)
+	b.Nil(ret)
+	b.Contains(err.Error(), "could not unmarshal scope settings")
 }
 
 func (b *backendTestSuite) TestScope_FailShowVariable() {
@@ -327,6 +431,7 @@ func (b *backendTestSuite) withPutObject(err error) {
 			data, err := ioutil.ReadAll(input.Body)
 			b.NoError(err)
 			b.Contains(string(data), "bacon")
+			b.Contains(string(data), `"contentHash":"`+secretservice.ContentHash([]byte(hashKey), variables)+`"`)
 
 			b.Equal(bucketName, *input.Bucket)
 			b.Contains(*input.Key, "scopeName/archive/")
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+)
+
+// Error codes S3 returns for writes which fail their preconditions, or race
+// with another conditional write of the same object.
+const (
+	errCodeConditionalRequestConflict = "ConditionalRequestConflict"
+	errCodePreconditionFailed         = "PreconditionFailed"
+)
+
+// classify replaces AWS errors which API clients can act upon with typed
+// errors. The AWS error text is dropped, as it may reveal internal details
+// like bucket names and ARNs. Other errors are returned unchanged - the SDK
+// considers every error it does not know retryable, so only AWS errors are
+// checked for being retryable.
+func classify(err error) error {
+	awsErr, ok := errors.Cause(err).(awserr.Error)
+	if !ok {
+		return err
+	}
+
+	if request.IsErrorThrottle(awsErr) || request.IsErrorRetryable(awsErr) {
+		return secretservice.Unavailable("the service is temporarily unavailable, please retry")
+	}
+
+	switch awsErr.Code() {
+	case s3.ErrCodeNoSuchKey, ssm.ErrCodeParameterNotFound:
+		return secretservice.NotFound("the resource does not exist")
+	case errCodeConditionalRequestConflict, errCodePreconditionFailed:
+		return secretservice.Conflict("the resource has been modified concurrently, please retry")
+	}
+
+	return err
+}
@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type errorsTestSuite struct {
+	suite.Suite
+}
+
+func (e *errorsTestSuite) TestClassify_NotFound() {
+	for _, code := range []string{s3.ErrCodeNoSuchKey, ssm.ErrCodeParameterNotFound} {
+		err := classify(errors.Wrap(awserr.New(code, "bucket-name", nil), "bacon"))
+
+		e.Equal(secretservice.CodeNotFound, secretservice.ErrorCode(err))
+		e.NotContains(err.Error(), "bucket-name")
+	}
+}
+
+func (e *errorsTestSuite) TestClassify_Conflict() {
+	for _, code := range []string{"ConditionalRequestConflict", "PreconditionFailed"} {
+		err := classify(awserr.New(code, "bucket-name", nil))
+
+		e.Equal(secretservice.CodeConflict, secretservice.ErrorCode(err))
+		e.NotContains(err.Error(), "bucket-name")
+	}
+}
+
+func (e *errorsTestSuite) TestClassify_Unavailable() {
+	err := classify(awserr.New("ThrottlingException", "slow down", nil))
+
+	e.Equal(secretservice.CodeUnavailable, secretservice.ErrorCode(err))
+}
+
+func (e *errorsTestSuite) TestClassify_NotAWSError() {
+	for _, original := range []error{
+		errors.New("bacon"),
+		errors.Wrap(errors.New("bacon"), "could not unmarshal release"),
+		context.DeadlineExceeded,
+	} {
+		e.Equal(original, classify(original))
+	}
+}
+
+func (e *errorsTestSuite) TestClassify_Other() {
+	original := awserr.New("AccessDenied", "bacon", nil)
+
+	e.Equal(original, classify(original))
+	e.Nil(classify(nil))
+}
+
+func TestErrors(t *testing.T) {
+	suite.Run(t, new(errorsTestSuite))
+}
@@ -0,0 +1,24 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+// Ready checks that the Backend can read the "scopes" namespace and list
+// objects in the bucket.
+func (b *Backend) Ready(ctx context.Context) error {
+	if _, err := b.ListVariables(ctx, "scopes"); err != nil {
+		return errors.Wrap(classify(err), "could not read scopes")
+	}
+
+	_, err := b.s3.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:  b.bucketName,
+		MaxKeys: aws.Int64(1),
+	})
+
+	return errors.Wrap(classify(err), "could not list objects in the bucket")
+}
@@ -0,0 +1,46 @@
+package backend_test
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/mock"
+)
+
+func (b *backendTestSuite) TestReady_OK() {
+	b.withListScopes(nil)
+	b.withListBucket(nil)
+
+	b.NoError(b.sut.Ready(b.ctx))
+}
+
+func (b *backendTestSuite) TestReady_ScopesUnreadable() {
+	b.withListScopes(errors.New("bacon"))
+
+	b.EqualError(b.sut.Ready(b.ctx), "could not read scopes: bacon")
+	b.s3.AssertNotCalled(b.T(), "ListObjectsV2WithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (b *backendTestSuite) TestReady_BucketUnreadable() {
+	b.withListScopes(nil)
+	b.withListBucket(errors.New("bacon"))
+
+	b.EqualError(b.sut.Ready(b.ctx), "could not list objects in the bucket: bacon")
+}
+
+func (b *backendTestSuite) withListScopes(err error) {
+	b.ssmvars.On("ListVariables", b.ctx, "scopes").Return([]*ssmvars.Variable(nil), err)
+}
+
+func (b *backendTestSuite) withListBucket(err error) {
+	b.s3.On(
+		"ListObjectsV2WithContext",
+		b.ctx,
+		mock.MatchedBy(func(input *s3.ListObjectsV2Input) bool {
+			return *input.Bucket == bucketName && *input.MaxKeys == 1 && input.Prefix == nil
+		}),
+		[]request.Option(nil),
+	).Return(new(s3.ListObjectsV2Output), err)
+}
@@ -0,0 +1,60 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/pkg/errors"
+)
+
+const metadataNamespace = "metadata"
+
+// ListMetadata returns metadata for all Variables in the workspace of a given
+// Scope, keyed by Variable name.
+func (b *Backend) ListMetadata(ctx context.Context, scopeName string) (map[string]*secretservice.Metadata, error) {
+	variables, err := b.ListVariables(ctx, path.Join(metadataNamespace, scopeName))
+	if err != nil {
+		return nil, errors.Wrap(classify(err), "could not list metadata")
+	}
+
+	ret := make(map[string]*secretservice.Metadata, len(variables))
+	for _, variable := range variables {
+		metadata := new(secretservice.Metadata)
+		if err := json.Unmarshal([]byte(variable.Value), metadata); err != nil {
+			return nil, errors.Wrapf(err, "could not unmarshal metadata for %q", variable.Name)
+		}
+		ret[variable.Name] = metadata
+	}
+
+	return ret, nil
+}
+
+// SetMetadata stores metadata for a single Variable in the workspace of a
+// given Scope.
+func (b *Backend) SetMetadata(ctx context.Context, scopeName, variableName string, metadata *secretservice.Metadata) error {
+	value, err := json.Marshal(metadata)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal metadata")
+	}
+
+	_, err = b.CreateVariable(ctx, path.Join(metadataNamespace, scopeName), &ssmvars.Variable{
+		Name:  variableName,
+		Value: string(value),
+	})
+
+	return errors.Wrap(classify(err), "could not store metadata")
+}
+
+// DeleteMetadata removes metadata of a single Variable from the workspace of
+// a given Scope. Variables without metadata are not an error.
+func (b *Backend) DeleteMetadata(ctx context.Context, scopeName, variableName string) error {
+	_, err := b.DeleteVariable(ctx, path.Join(metadataNamespace, scopeName), variableName)
+	if err = classify(err); secretservice.ErrorCode(err) == secretservice.CodeNotFound {
+		return nil
+	}
+
+	return errors.Wrap(err, "could not delete metadata")
+}
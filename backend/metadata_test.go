@@ -0,0 +1,106 @@
+package backend_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/mock"
+)
+
+func (b *backendTestSuite) TestListMetadata_OK() {
+	b.withListMetadata(nil, &ssmvars.Variable{
+		Name:  "bacon",
+		Value: `{"expiresAt":"2018-11-10T23:00:00Z","updatedAt":"2018-10-10T23:00:00Z"}`,
+	})
+
+	ret, err := b.sut.ListMetadata(b.ctx, scopeName)
+
+	b.NoError(err)
+	b.Len(ret, 1)
+	b.Equal(time.Date(2018, 11, 10, 23, 0, 0, 0, time.UTC), *ret["bacon"].ExpiresAt)
+	b.Nil(ret["bacon"].RotateEvery)
+}
+
+func (b *backendTestSuite) TestListMetadata_FailList() {
+	b.withListMetadata(errors.New("bacon"))
+
+	ret, err := b.sut.ListMetadata(b.ctx, scopeName)
+
+	b.Nil(ret)
+	b.EqualError(err, "could not list metadata: bacon")
+}
+
+func (b *backendTestSuite) TestListMetadata_FailDecode() {
+	b.withListMetadata(nil, &ssmvars.Variable{Name: "bacon", Value: "invalid"})
+
+	ret, err := b.sut.ListMetadata(b.ctx, scopeName)
+
+	b.Nil(ret)
+	b.EqualError(err, `could not unmarshal metadata for "bacon": invalid character 'i' looking for beginning of value`)
+}
+
+func (b *backendTestSuite) TestSetMetadata_OK() {
+	b.withSetMetadata(nil)
+
+	rotateEvery := time.Hour
+	b.NoError(b.sut.SetMetadata(b.ctx, scopeName, "bacon", &secretservice.Metadata{
+		RotateEvery: &rotateEvery,
+	}))
+}
+
+func (b *backendTestSuite) TestSetMetadata_Failure() {
+	b.withSetMetadata(errors.New("bacon"))
+
+	b.EqualError(
+		b.sut.SetMetadata(b.ctx, scopeName, "bacon", new(secretservice.Metadata)),
+		"could not store metadata: bacon",
+	)
+}
+
+func (b *backendTestSuite) TestDeleteMetadata_OK() {
+	b.withDeleteMetadata(nil)
+
+	b.NoError(b.sut.DeleteMetadata(b.ctx, scopeName, "bacon"))
+}
+
+func (b *backendTestSuite) TestDeleteMetadata_Missing() {
+	b.withDeleteMetadata(awserr.New(ssm.ErrCodeParameterNotFound, "bacon", nil))
+
+	b.NoError(b.sut.DeleteMetadata(b.ctx, scopeName, "bacon"))
+}
+
+func (b *backendTestSuite) TestDeleteMetadata_Failure() {
+	b.withDeleteMetadata(errors.New("bacon"))
+
+	b.EqualError(b.sut.DeleteMetadata(b.ctx, scopeName, "bacon"), "could not delete metadata: bacon")
+}
+
+func (b *backendTestSuite) withDeleteMetadata(err error) {
+	b.ssmvars.On("DeleteVariable", b.ctx, "metadata/scopeName", "bacon").Return((*ssmvars.Variable)(nil), err)
+}
+
+func (b *backendTestSuite) withListMetadata(err error, variables ...*ssmvars.Variable) {
+	b.ssmvars.On("ListVariables", b.ctx, "metadata/scopeName").Return(variables, err)
+}
+
+func (b *backendTestSuite) withSetMetadata(err error) {
+	b.ssmvars.On(
+		"CreateVariable",
+		b.ctx,
+		"metadata/scopeName",
+		mock.MatchedBy(func(arg interface{}) bool {
+			input, ok := arg.(*ssmvars.Variable)
+			b.True(ok)
+
+			b.Equal("bacon", input.Name)
+			b.Contains(input.Value, `"updatedAt"`)
+			b.False(input.WriteOnly)
+
+			return true
+		}),
+	).Return((*ssmvars.Variable)(nil), err)
+}
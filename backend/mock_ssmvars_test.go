@@ -16,3 +16,18 @@ func (m *mockSSMVars) ShowVariable(ctx context.Context, namespace, name string)
 	args := m.Called(ctx, namespace, name)
 	return args.Get(0).(*ssmvars.Variable), args.Error(1)
 }
+
+func (m *mockSSMVars) ListVariables(ctx context.Context, namespace string) ([]*ssmvars.Variable, error) {
+	args := m.Called(ctx, namespace)
+	return args.Get(0).([]*ssmvars.Variable), args.Error(1)
+}
+
+func (m *mockSSMVars) CreateVariable(ctx context.Context, namespace string, variable *ssmvars.Variable) (*ssmvars.Variable, error) {
+	args := m.Called(ctx, namespace, variable)
+	return args.Get(0).(*ssmvars.Variable), args.Error(1)
+}
+
+func (m *mockSSMVars) DeleteVariable(ctx context.Context, namespace, name string) (*ssmvars.Variable, error) {
+	args := m.Called(ctx, namespace, name)
+	return args.Get(0).(*ssmvars.Variable), args.Error(1)
+}
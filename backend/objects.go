@@ -0,0 +1,108 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+// getJSON retrieves an S3 object and decodes it as JSON into the value. It
+// returns the ETag of the object, which allows updating it conditionally.
+func (b *Backend) getJSON(ctx context.Context, key *string, value interface{}) (string, error) {
+	output, err := b.s3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: b.bucketName,
+		Key:    key,
+	})
+	if err != nil {
+		return "", errors.Wrap(classify(err), "could not retrieve object from S3")
+	}
+	defer output.Body.Close()
+
+	if err := json.NewDecoder(output.Body).Decode(value); err != nil {
+		return "", errors.Wrap(err, "could not unmarshal object")
+	}
+
+	return aws.StringValue(output.ETag), nil
+}
+
+// putJSON stores the value as a JSON-encoded S3 object, and returns the ETag
+// of the new version. The object is encrypted with the given KMS key, or the
+// default one if it is nil.
+func (b *Backend) putJSON(ctx context.Context, key, kmsKeyID *string, value interface{}, opts ...request.Option) (string, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal object")
+	}
+
+	output, err := b.s3.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Body:                 bytes.NewReader(body),
+		Bucket:               b.bucketName,
+		Key:                  key,
+		SSEKMSKeyId:          kmsKeyID,
+		ServerSideEncryption: aws.String("aws:kms"),
+	}, opts...)
+	if err != nil {
+		return "", errors.Wrap(classify(err), "could not put object to S3")
+	}
+
+	return aws.StringValue(output.ETag), nil
+}
+
+// ifMatch makes a write succeed only if the object still has the given ETag,
+// or if it does not exist yet when the ETag is empty. Writes failing the
+// condition are rejected by S3, and classified as conflicts.
+func ifMatch(etag string) request.Option {
+	return func(r *request.Request) {
+		if etag == "" {
+			r.HTTPRequest.Header.Set("If-None-Match", "*")
+		} else {
+			r.HTTPRequest.Header.Set("If-Match", etag)
+		}
+	}
+}
+
+// deleteKey removes an S3 object. Removing an object which does not exist is
+// not an error.
+func (b *Backend) deleteKey(ctx context.Context, key *string) error {
+	_, err := b.s3.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: b.bucketName,
+		Key:    key,
+	})
+
+	return errors.Wrap(classify(err), "could not delete object from S3")
+}
+
+// listKeys returns names of all objects with a given prefix, with the prefix
+// itself stripped.
+func (b *Backend) listKeys(ctx context.Context, prefix string) ([]string, error) {
+	var ret []string
+	var token *string
+
+	for {
+		list, err := b.s3.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
+			Bucket:            b.bucketName,
+			ContinuationToken: token,
+			Prefix:            aws.String(prefix),
+		})
+		if err != nil {
+			return nil, errors.Wrap(classify(err), "could not list objects with a prefix")
+		}
+
+		for _, object := range list.Contents {
+			if object.Key != nil {
+				ret = append(ret, strings.TrimPrefix(*object.Key, prefix))
+			}
+		}
+
+		if !aws.BoolValue(list.IsTruncated) {
+			return ret, nil
+		}
+		token = list.NextContinuationToken
+	}
+}
@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+)
+
+const proposalsPrefix = "proposals"
+
+// CreateProposal stores a new pending Proposal. Proposal IDs are sorted by
+// creation time.
+func (b *Backend) CreateProposal(ctx context.Context, proposal *secretservice.Proposal) (*secretservice.Proposal, error) {
+	id, err := ulid.New(ulid.Now(), defaultEntropySource)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate an ID")
+	}
+
+	ret := *proposal
+	ret.ID = id.String()
+	ret.Status = secretservice.ProposalPending
+	ret.ETag = ""
+
+	if err := b.UpdateProposal(ctx, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// GetProposal retrieves a Proposal given its ID.
+func (b *Backend) GetProposal(ctx context.Context, scopeName, proposalID string) (*secretservice.Proposal, error) {
+	ret := new(secretservice.Proposal)
+
+	etag, err := b.getJSON(ctx, b.objectKey(scopeName, proposalsPrefix, proposalID), ret)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get proposal")
+	}
+
+	ret.ID = proposalID
+	ret.ScopeName = scopeName
+	ret.ETag = etag
+
+	return ret, nil
+}
+
+// ListProposals returns all Proposals for a given Scope, including the ones
+// which have already been approved or rejected.
+func (b *Backend) ListProposals(ctx context.Context, scopeName string) ([]*secretservice.Proposal, error) {
+	ids, err := b.listKeys(ctx, fmt.Sprintf("%s/%s/", scopeName, proposalsPrefix))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list proposals")
+	}
+
+	ret := make([]*secretservice.Proposal, 0, len(ids))
+	for _, id := range ids {
+		proposal, err := b.GetProposal(ctx, scopeName, id)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, proposal)
+	}
+
+	return ret, nil
+}
+
+// UpdateProposal stores a Proposal. Since Proposals contain Variable values,
+// they are encrypted with the KMS key of their Scope. The write only succeeds
+// if the stored Proposal still has the ETag of the Proposal, or does not exist
+// yet if the ETag is empty, and fails with a CONFLICT error otherwise. On
+// success the ETag is updated.
+func (b *Backend) UpdateProposal(ctx context.Context, proposal *secretservice.Proposal) error {
+	scope, err := b.Scope(ctx, proposal.ScopeName)
+	if err != nil {
+		return err
+	}
+
+	key := b.objectKey(proposal.ScopeName, proposalsPrefix, proposal.ID)
+
+	etag, err := b.putJSON(ctx, key, aws.String(scope.KMSKeyID), proposal, ifMatch(proposal.ETag))
+	if err != nil {
+		return errors.Wrap(err, "could not store proposal")
+	}
+	proposal.ETag = etag
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package backend_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/mock"
+)
+
+const proposalBody = `{"message":"bacon","requestedBy":"alice","variables":[{"Name":"bacon","Value":"tasty"}],"approvals":[],"status":"PENDING"}`
+
+func (b *backendTestSuite) TestCreateProposal_OK() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+
+	var stored secretservice.Proposal
+	b.s3.On(
+		"PutObjectWithContext",
+		b.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			input, ok := arg.(*s3.PutObjectInput)
+			b.True(ok)
+
+			b.True(strings.HasPrefix(*input.Key, "scopeName/proposals/"))
+			b.Equal(kmsKeyID, *input.SSEKMSKeyId)
+			b.NoError(json.NewDecoder(input.Body).Decode(&stored))
+
+			return true
+		}),
+		mock.MatchedBy(func(opts []request.Option) bool {
+			return conditionHeaders(opts).Get("If-None-Match") == "*"
+		}),
+	).Return(&s3.PutObjectOutput{ETag: aws.String("etag")}, nil)
+
+	ret, err := b.sut.CreateProposal(b.ctx, &secretservice.Proposal{
+		ScopeName:   scopeName,
+		RequestedBy: "alice",
+		Variables:   variables,
+	})
+
+	b.NoError(err)
+	b.NotEmpty(ret.ID)
+	b.Equal("etag", ret.ETag)
+	b.Equal(secretservice.ProposalPending, ret.Status)
+	b.Equal(secretservice.ProposalPending, stored.Status)
+	b.Equal("alice", stored.RequestedBy)
+	b.Equal(variables, stored.Variables)
+}
+
+func (b *backendTestSuite) TestCreateProposal_FailScope() {
+	b.withShowVariable(nil, errors.New("bacon"))
+
+	ret, err := b.sut.CreateProposal(b.ctx, &secretservice.Proposal{ScopeName: scopeName})
+
+	b.Nil(ret)
+	b.EqualError(err, `could not find scope "scopeName": bacon`)
+}
+
+func (b *backendTestSuite) TestCreateProposal_FailPut() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.s3.
+		On("PutObjectWithContext", b.ctx, mock.Anything, mock.Anything).
+		Return((*s3.PutObjectOutput)(nil), errors.New("bacon"))
+
+	ret, err := b.sut.CreateProposal(b.ctx, &secretservice.Proposal{ScopeName: scopeName})
+
+	b.Nil(ret)
+	b.EqualError(err, "could not store proposal: could not put object to S3: bacon")
+}
+
+func (b *backendTestSuite) TestGetProposal_OK() {
+	b.withGetProposalObject("proposalID", proposalBody, nil)
+
+	ret, err := b.sut.GetProposal(b.ctx, scopeName, "proposalID")
+
+	b.NoError(err)
+	b.Equal("proposalID", ret.ID)
+	b.Equal(scopeName, ret.ScopeName)
+	b.Equal("etag-proposalID", ret.ETag)
+	b.Equal("alice", ret.RequestedBy)
+	b.Equal(secretservice.ProposalPending, ret.Status)
+	b.Len(ret.Variables, 1)
+}
+
+func (b *backendTestSuite) TestGetProposal_FailGet() {
+	b.withGetProposalObject("proposalID", "", errors.New("bacon"))
+
+	ret, err := b.sut.GetProposal(b.ctx, scopeName, "proposalID")
+
+	b.Nil(ret)
+	b.EqualError(err, "could not get proposal: could not retrieve object from S3: bacon")
+}
+
+func (b *backendTestSuite) TestListProposals_OK() {
+	b.s3.On(
+		"ListObjectsV2WithContext",
+		b.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			input, ok := arg.(*s3.ListObjectsV2Input)
+			return ok && *input.Prefix == "scopeName/proposals/"
+		}),
+		[]request.Option(nil),
+	).Return(&s3.ListObjectsV2Output{
+		Contents: []*s3.Object{{Key: aws.String("scopeName/proposals/proposalID")}},
+	}, nil)
+	b.withGetProposalObject("proposalID", proposalBody, nil)
+
+	ret, err := b.sut.ListProposals(b.ctx, scopeName)
+
+	b.NoError(err)
+	b.Len(ret, 1)
+	b.Equal("proposalID", ret[0].ID)
+}
+
+func (b *backendTestSuite) TestListProposals_FailList() {
+	b.s3.
+		On("ListObjectsV2WithContext", b.ctx, mock.Anything, []request.Option(nil)).
+		Return((*s3.ListObjectsV2Output)(nil), errors.New("bacon"))
+
+	ret, err := b.sut.ListProposals(b.ctx, scopeName)
+
+	b.Nil(ret)
+	b.EqualError(err, "could not list proposals: could not list objects with a prefix: bacon")
+}
+
+func (b *backendTestSuite) TestUpdateProposal_OK() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.s3.On(
+		"PutObjectWithContext",
+		b.ctx,
+		mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+			return *input.Key == "scopeName/proposals/proposalID" && *input.SSEKMSKeyId == kmsKeyID
+		}),
+		mock.MatchedBy(func(opts []request.Option) bool {
+			return conditionHeaders(opts).Get("If-Match") == "old"
+		}),
+	).Return(&s3.PutObjectOutput{ETag: aws.String("new")}, nil)
+
+	proposal := &secretservice.Proposal{ID: "proposalID", ScopeName: scopeName, ETag: "old"}
+
+	b.NoError(b.sut.UpdateProposal(b.ctx, proposal))
+	b.Equal("new", proposal.ETag)
+}
+
+func (b *backendTestSuite) TestUpdateProposal_Conflict() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.s3.
+		On("PutObjectWithContext", b.ctx, mock.Anything, mock.Anything).
+		Return((*s3.PutObjectOutput)(nil), awserr.New("PreconditionFailed", "bacon", nil))
+
+	proposal := &secretservice.Proposal{ID: "proposalID", ScopeName: scopeName, ETag: "old"}
+
+	err := b.sut.UpdateProposal(b.ctx, proposal)
+
+	b.Equal(secretservice.CodeConflict, secretservice.ErrorCode(err))
+	b.Equal("old", proposal.ETag)
+}
+
+func (b *backendTestSuite) withGetProposalObject(proposalID, body string, err error) {
+	var output *s3.GetObjectOutput
+	if err == nil {
+		output = &s3.GetObjectOutput{
+			Body: ioutil.NopCloser(strings.NewReader(body)),
+			ETag: aws.String("etag-" + proposalID),
+		}
+	}
+
+	b.s3.On(
+		"GetObjectWithContext",
+		b.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			input, ok := arg.(*s3.GetObjectInput)
+			return ok && *input.Key == "scopeName/proposals/"+proposalID
+		}),
+		[]request.Option(nil),
+	).Return(output, err)
+}
@@ -0,0 +1,148 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+)
+
+const (
+	scheduledPrefix = "scheduled"
+
+	// pendingPrefix holds an empty object for every pending operation, so
+	// that finding pending operations does not require reading every
+	// operation ever scheduled.
+	pendingPrefix = "pending"
+)
+
+// ScheduleOperation stores a new pending operation. Operation IDs are sorted
+// by creation time.
+func (b *Backend) ScheduleOperation(ctx context.Context, operation *secretservice.ScheduledOperation) (*secretservice.ScheduledOperation, error) {
+	id, err := ulid.New(ulid.Now(), defaultEntropySource)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate an ID")
+	}
+
+	ret := *operation
+	ret.ID = id.String()
+	ret.Status = secretservice.OperationPending
+	ret.ETag = ""
+
+	// The operation is indexed before it is stored, so that a failure can not
+	// leave behind a pending operation which is never executed.
+	if _, err := b.putJSON(ctx, b.objectKey(ret.ScopeName, pendingPrefix, ret.ID), nil, struct{}{}); err != nil {
+		return nil, errors.Wrap(err, "could not index scheduled operation")
+	}
+
+	if err := b.UpdateScheduledOperation(ctx, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// GetScheduledOperation retrieves a scheduled operation given its ID.
+func (b *Backend) GetScheduledOperation(ctx context.Context, scopeName, operationID string) (*secretservice.ScheduledOperation, error) {
+	ret := new(secretservice.ScheduledOperation)
+
+	etag, err := b.getJSON(ctx, b.objectKey(scopeName, scheduledPrefix, operationID), ret)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get scheduled operation")
+	}
+
+	ret.ID = operationID
+	ret.ScopeName = scopeName
+	ret.ETag = etag
+
+	return ret, nil
+}
+
+// ListScheduledOperations returns all operations scheduled for a given Scope,
+// including the ones which have already been executed or cancelled.
+func (b *Backend) ListScheduledOperations(ctx context.Context, scopeName string) ([]*secretservice.ScheduledOperation, error) {
+	ids, err := b.listKeys(ctx, fmt.Sprintf("%s/%s/", scopeName, scheduledPrefix))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list scheduled operations")
+	}
+
+	ret := make([]*secretservice.ScheduledOperation, 0, len(ids))
+	for _, id := range ids {
+		operation, err := b.GetScheduledOperation(ctx, scopeName, id)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, operation)
+	}
+
+	return ret, nil
+}
+
+// ListPendingOperations returns the operations scheduled for a given Scope
+// which are still pending. Index entries of operations which are no longer
+// pending are removed along the way.
+func (b *Backend) ListPendingOperations(ctx context.Context, scopeName string) ([]*secretservice.ScheduledOperation, error) {
+	ids, err := b.listKeys(ctx, fmt.Sprintf("%s/%s/", scopeName, pendingPrefix))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list pending operations")
+	}
+
+	var ret []*secretservice.ScheduledOperation
+	for _, id := range ids {
+		operation, err := b.GetScheduledOperation(ctx, scopeName, id)
+		if secretservice.ErrorCode(err) == secretservice.CodeNotFound {
+			// The operation is either being scheduled right now, or storing
+			// it has failed.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if operation.Status != secretservice.OperationPending {
+			if err := b.deleteKey(ctx, b.objectKey(scopeName, pendingPrefix, id)); err != nil {
+				return nil, errors.Wrap(err, "could not unindex scheduled operation")
+			}
+			continue
+		}
+
+		ret = append(ret, operation)
+	}
+
+	return ret, nil
+}
+
+// UpdateScheduledOperation stores a scheduled operation, encrypted with the
+// KMS key of its Scope. The write only succeeds if the stored operation still
+// has the ETag of the operation, or does not exist yet if the ETag is empty,
+// and fails with a CONFLICT error otherwise. On success the ETag is updated,
+// and operations which are no longer pending are removed from the index.
+// Failing to do the latter is only logged, as ListPendingOperations removes
+// stale index entries anyway.
+func (b *Backend) UpdateScheduledOperation(ctx context.Context, operation *secretservice.ScheduledOperation) error {
+	scope, err := b.Scope(ctx, operation.ScopeName)
+	if err != nil {
+		return err
+	}
+
+	key := b.objectKey(operation.ScopeName, scheduledPrefix, operation.ID)
+
+	etag, err := b.putJSON(ctx, key, aws.String(scope.KMSKeyID), operation, ifMatch(operation.ETag))
+	if err != nil {
+		return errors.Wrap(err, "could not store scheduled operation")
+	}
+	operation.ETag = etag
+
+	if operation.Status == secretservice.OperationPending {
+		return nil
+	}
+
+	if err := b.deleteKey(ctx, b.objectKey(operation.ScopeName, pendingPrefix, operation.ID)); err != nil {
+		log.WithField("operation", operation.ID).Warnf("Could not unindex scheduled operation: %v", err)
+	}
+
+	return nil
+}
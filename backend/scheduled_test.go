@@ -0,0 +1,322 @@
+package backend_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/mock"
+)
+
+const scheduledBody = `{"kind":"ARCHIVE_RELEASE","releaseId":"releaseID","runAt":"2018-11-10T23:00:00Z","status":"PENDING"}`
+
+func (b *backendTestSuite) TestScheduleOperation_OK() {
+	runAt := time.Date(2018, 11, 10, 23, 0, 0, 0, time.UTC)
+
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.withPutIndex(nil)
+
+	var stored secretservice.ScheduledOperation
+	b.s3.On(
+		"PutObjectWithContext",
+		b.ctx,
+		mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+			if !strings.HasPrefix(*input.Key, "scopeName/scheduled/") {
+				return false
+			}
+
+			b.Equal(bucketName, *input.Bucket)
+			b.Equal(kmsKeyID, *input.SSEKMSKeyId)
+			b.NoError(json.NewDecoder(input.Body).Decode(&stored))
+
+			return true
+		}),
+		mock.MatchedBy(func(opts []request.Option) bool {
+			return conditionHeaders(opts).Get("If-None-Match") == "*"
+		}),
+	).Return(&s3.PutObjectOutput{ETag: aws.String("etag")}, nil)
+
+	ret, err := b.sut.ScheduleOperation(b.ctx, &secretservice.ScheduledOperation{
+		ScopeName: scopeName,
+		Kind:      secretservice.OperationCreateRelease,
+		RunAt:     runAt,
+	})
+
+	b.NoError(err)
+	b.NotEmpty(ret.ID)
+	b.Equal("etag", ret.ETag)
+	b.Equal(secretservice.OperationPending, ret.Status)
+	b.Equal(secretservice.OperationCreateRelease, stored.Kind)
+	b.Equal(secretservice.OperationPending, stored.Status)
+	b.True(runAt.Equal(stored.RunAt))
+	b.s3.AssertNotCalled(b.T(), "DeleteObjectWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (b *backendTestSuite) TestScheduleOperation_FailIndex() {
+	b.withPutIndex(errors.New("bacon"))
+
+	ret, err := b.sut.ScheduleOperation(b.ctx, &secretservice.ScheduledOperation{ScopeName: scopeName})
+
+	b.Nil(ret)
+	b.EqualError(err, "could not index scheduled operation: could not put object to S3: bacon")
+}
+
+func (b *backendTestSuite) TestScheduleOperation_FailPut() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.withPutIndex(nil)
+	b.withPutScheduled(errors.New("bacon"))
+
+	ret, err := b.sut.ScheduleOperation(b.ctx, &secretservice.ScheduledOperation{ScopeName: scopeName})
+
+	b.Nil(ret)
+	b.EqualError(err, "could not store scheduled operation: could not put object to S3: bacon")
+}
+
+func (b *backendTestSuite) TestGetScheduledOperation_OK() {
+	b.withGetScheduledObject("operationID", scheduledBody, nil)
+
+	ret, err := b.sut.GetScheduledOperation(b.ctx, scopeName, "operationID")
+
+	b.NoError(err)
+	b.Equal("operationID", ret.ID)
+	b.Equal(scopeName, ret.ScopeName)
+	b.Equal("etag-operationID", ret.ETag)
+	b.Equal(secretservice.OperationArchiveRelease, ret.Kind)
+	b.Equal(releaseID, ret.ReleaseID)
+	b.Equal(secretservice.OperationPending, ret.Status)
+}
+
+func (b *backendTestSuite) TestGetScheduledOperation_FailGet() {
+	b.withGetScheduledObject("operationID", "", errors.New("bacon"))
+
+	ret, err := b.sut.GetScheduledOperation(b.ctx, scopeName, "operationID")
+
+	b.Nil(ret)
+	b.EqualError(err, "could not get scheduled operation: could not retrieve object from S3: bacon")
+}
+
+func (b *backendTestSuite) TestGetScheduledOperation_FailDecode() {
+	b.withGetScheduledObject("operationID", "bacon", nil)
+
+	ret, err := b.sut.GetScheduledOperation(b.ctx, scopeName, "operationID")
+
+	b.Nil(ret)
+	b.Contains(err.Error(), "could not get scheduled operation: could not unmarshal object")
+}
+
+func (b *backendTestSuite) TestListScheduledOperations_OK() {
+	b.withListScheduled("scheduled", nil, &s3.ListObjectsV2Output{
+		Contents:              []*s3.Object{{Key: aws.String("scopeName/scheduled/first")}},
+		IsTruncated:           aws.Bool(true),
+		NextContinuationToken: aws.String("token"),
+	}, nil)
+	b.withListScheduled("scheduled", aws.String("token"), &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{{Key: aws.String("scopeName/scheduled/second")}},
+	}, nil)
+	b.withGetScheduledObject("first", scheduledBody, nil)
+	b.withGetScheduledObject("second", scheduledBody, nil)
+
+	ret, err := b.sut.ListScheduledOperations(b.ctx, scopeName)
+
+	b.NoError(err)
+	b.Len(ret, 2)
+	b.Equal("first", ret[0].ID)
+	b.Equal("second", ret[1].ID)
+}
+
+func (b *backendTestSuite) TestListScheduledOperations_FailList() {
+	b.withListScheduled("scheduled", nil, (*s3.ListObjectsV2Output)(nil), errors.New("bacon"))
+
+	ret, err := b.sut.ListScheduledOperations(b.ctx, scopeName)
+
+	b.Nil(ret)
+	b.EqualError(err, "could not list scheduled operations: could not list objects with a prefix: bacon")
+}
+
+func (b *backendTestSuite) TestListPendingOperations_OK() {
+	b.withListScheduled("pending", nil, &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{Key: aws.String("scopeName/pending/pending")},
+			{Key: aws.String("scopeName/pending/done")},
+			{Key: aws.String("scopeName/pending/missing")},
+		},
+	}, nil)
+	b.withGetScheduledObject("pending", scheduledBody, nil)
+	b.withGetScheduledObject("done", strings.Replace(scheduledBody, "PENDING", "DONE", 1), nil)
+	b.withGetScheduledObject("missing", "", awserr.New(s3.ErrCodeNoSuchKey, "bacon", nil))
+	b.withDeleteIndex("done", nil)
+
+	ret, err := b.sut.ListPendingOperations(b.ctx, scopeName)
+
+	b.NoError(err)
+	b.Len(ret, 1)
+	b.Equal("pending", ret[0].ID)
+	b.s3.AssertExpectations(b.T())
+}
+
+func (b *backendTestSuite) TestListPendingOperations_FailList() {
+	b.withListScheduled("pending", nil, (*s3.ListObjectsV2Output)(nil), errors.New("bacon"))
+
+	ret, err := b.sut.ListPendingOperations(b.ctx, scopeName)
+
+	b.Nil(ret)
+	b.EqualError(err, "could not list pending operations: could not list objects with a prefix: bacon")
+}
+
+func (b *backendTestSuite) TestListPendingOperations_FailGet() {
+	b.withListScheduled("pending", nil, &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{{Key: aws.String("scopeName/pending/first")}},
+	}, nil)
+	b.withGetScheduledObject("first", "", errors.New("bacon"))
+
+	ret, err := b.sut.ListPendingOperations(b.ctx, scopeName)
+
+	b.Nil(ret)
+	b.EqualError(err, "could not get scheduled operation: could not retrieve object from S3: bacon")
+}
+
+func (b *backendTestSuite) TestUpdateScheduledOperation_OK() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.s3.On(
+		"PutObjectWithContext",
+		b.ctx,
+		mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+			return *input.Key == "scopeName/scheduled/operationID" && *input.SSEKMSKeyId == kmsKeyID
+		}),
+		mock.MatchedBy(func(opts []request.Option) bool {
+			return conditionHeaders(opts).Get("If-Match") == "old"
+		}),
+	).Return(&s3.PutObjectOutput{ETag: aws.String("new")}, nil)
+	b.withDeleteIndex("operationID", nil)
+
+	operation := &secretservice.ScheduledOperation{
+		ID:        "operationID",
+		ScopeName: scopeName,
+		Status:    secretservice.OperationCancelled,
+		ETag:      "old",
+	}
+
+	b.NoError(b.sut.UpdateScheduledOperation(b.ctx, operation))
+	b.Equal("new", operation.ETag)
+	b.s3.AssertExpectations(b.T())
+}
+
+func (b *backendTestSuite) TestUpdateScheduledOperation_Conflict() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.withPutScheduled(awserr.New("PreconditionFailed", "bacon", nil))
+
+	err := b.sut.UpdateScheduledOperation(b.ctx, &secretservice.ScheduledOperation{
+		ID:        "operationID",
+		ScopeName: scopeName,
+		Status:    secretservice.OperationCancelled,
+		ETag:      "old",
+	})
+
+	b.Equal(secretservice.CodeConflict, secretservice.ErrorCode(err))
+	b.s3.AssertNotCalled(b.T(), "DeleteObjectWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (b *backendTestSuite) TestUpdateScheduledOperation_FailUnindex() {
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.withPutScheduled(nil)
+	b.withDeleteIndex("operationID", errors.New("bacon"))
+
+	b.NoError(b.sut.UpdateScheduledOperation(b.ctx, &secretservice.ScheduledOperation{
+		ID:        "operationID",
+		ScopeName: scopeName,
+		Status:    secretservice.OperationDone,
+	}))
+}
+
+func (b *backendTestSuite) TestUpdateScheduledOperation_FailScope() {
+	b.withShowVariable(nil, errors.New("bacon"))
+
+	err := b.sut.UpdateScheduledOperation(b.ctx, &secretservice.ScheduledOperation{ScopeName: scopeName})
+
+	b.EqualError(err, `could not find scope "scopeName": bacon`)
+}
+
+func (b *backendTestSuite) withPutIndex(err error) {
+	b.s3.On(
+		"PutObjectWithContext",
+		b.ctx,
+		mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+			return strings.HasPrefix(*input.Key, "scopeName/pending/") && input.SSEKMSKeyId == nil
+		}),
+		[]request.Option(nil),
+	).Return(new(s3.PutObjectOutput), err)
+}
+
+func (b *backendTestSuite) withPutScheduled(err error) {
+	b.s3.On(
+		"PutObjectWithContext",
+		b.ctx,
+		mock.MatchedBy(func(input *s3.PutObjectInput) bool {
+			return strings.HasPrefix(*input.Key, "scopeName/scheduled/")
+		}),
+		mock.Anything,
+	).Return(new(s3.PutObjectOutput), err)
+}
+
+func (b *backendTestSuite) withDeleteIndex(operationID string, err error) {
+	b.s3.On(
+		"DeleteObjectWithContext",
+		b.ctx,
+		mock.MatchedBy(func(input *s3.DeleteObjectInput) bool {
+			return *input.Key == "scopeName/pending/"+operationID
+		}),
+		[]request.Option(nil),
+	).Return(new(s3.DeleteObjectOutput), err)
+}
+
+func (b *backendTestSuite) withGetScheduledObject(operationID, body string, err error) {
+	var output *s3.GetObjectOutput
+	if err == nil {
+		output = &s3.GetObjectOutput{
+			Body: ioutil.NopCloser(strings.NewReader(body)),
+			ETag: aws.String("etag-" + operationID),
+		}
+	}
+
+	b.s3.On(
+		"GetObjectWithContext",
+		b.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			input, ok := arg.(*s3.GetObjectInput)
+			return ok && *input.Key == "scopeName/scheduled/"+operationID
+		}),
+		[]request.Option(nil),
+	).Return(output, err)
+}
+
+func (b *backendTestSuite) withListScheduled(prefix string, token *string, output *s3.ListObjectsV2Output, err error) {
+	b.s3.On(
+		"ListObjectsV2WithContext",
+		b.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			input, ok := arg.(*s3.ListObjectsV2Input)
+			return ok &&
+				*input.Prefix == "scopeName/"+prefix+"/" &&
+				aws.StringValue(input.ContinuationToken) == aws.StringValue(token)
+		}),
+		[]request.Option(nil),
+	).Return(output, err)
+}
+
+// conditionHeaders returns the headers request options set on a request.
+func conditionHeaders(opts []request.Option) http.Header {
+	req := &request.Request{HTTPRequest: &http.Request{Header: make(http.Header)}}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req.HTTPRequest.Header
+}
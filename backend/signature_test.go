@@ -0,0 +1,127 @@
+package backend_test
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/marcinwyszynski/secretservice/backend"
+	"github.com/marcinwyszynski/secretservice/signer"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/mock"
+)
+
+const signedBody = `{"variables":[{"Name":"bacon","Value":"tasty","WriteOnly":true}]}`
+
+// signedPayload is signedBody along with the scope name and the release ID.
+const signedPayload = "9:scopeName,9:releaseID," + signedBody
+
+func (b *backendTestSuite) TestCreateRelease_Signed() {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	b.Require().NoError(err)
+	b.sut = backend.New(b.ssmvars, b.s3, bucketName, backend.WithSigner(signer.NewEd25519(privateKey, nil)))
+
+	b.withShowVariable(&ssmvars.Variable{Name: scopeName, Value: kmsKeyID}, nil)
+	b.withCopyObject(nil)
+
+	var input *s3.PutObjectInput
+	b.s3.
+		On("PutObjectWithContext", b.ctx, mock.Anything, []request.Option(nil)).
+		Run(func(args mock.Arguments) { input = args.Get(1).(*s3.PutObjectInput) }).
+		Return((*s3.PutObjectOutput)(nil), nil)
+
+	release, err := b.sut.CreateRelease(b.ctx, scopeName, variables, "")
+
+	b.NoError(err)
+	b.True(release.Verified)
+
+	body, err := ioutil.ReadAll(input.Body)
+	b.NoError(err)
+
+	signature, err := base64.StdEncoding.DecodeString(*input.Metadata["Signature"])
+	b.NoError(err)
+	payload := fmt.Sprintf("9:scopeName,%d:%s,%s", len(release.ID), release.ID, body)
+	b.True(ed25519.Verify(privateKey.Public().(ed25519.PublicKey), []byte(payload), signature))
+	b.False(ed25519.Verify(privateKey.Public().(ed25519.PublicKey), body, signature))
+}
+
+func (b *backendTestSuite) TestGetRelease_Verified() {
+	privateKey := b.withSigner()
+	b.withSignedObject(ed25519.Sign(privateKey, []byte(signedPayload)))
+	b.withLiveObjects(nil)
+
+	release, err := b.sut.GetRelease(b.ctx, scopeName, releaseID)
+
+	b.NoError(err)
+	b.True(release.Verified)
+}
+
+func (b *backendTestSuite) TestGetRelease_Unsigned() {
+	b.withSigner()
+	b.withGetObject(signedBody, nil)
+
+	release, err := b.sut.GetRelease(b.ctx, scopeName, releaseID)
+
+	b.Nil(release)
+	b.EqualError(err, "could not verify release signature: release is not signed")
+}
+
+func (b *backendTestSuite) TestGetRelease_SignedForAnotherRelease() {
+	privateKey := b.withSigner()
+
+	for _, payload := range []string{
+		signedBody,
+		"9:scopeName,5:other," + signedBody,
+		"5:other,9:releaseID," + signedBody,
+	} {
+		b.s3.ExpectedCalls = nil
+		b.withSignedObject(ed25519.Sign(privateKey, []byte(payload)))
+
+		release, err := b.sut.GetRelease(b.ctx, scopeName, releaseID)
+
+		b.Nil(release)
+		b.EqualError(err, "could not verify release signature: invalid signature")
+	}
+}
+
+func (b *backendTestSuite) TestGetRelease_NoSigner() {
+	b.withSignedObject([]byte("signature"))
+	b.withLiveObjects(nil)
+
+	release, err := b.sut.GetRelease(b.ctx, scopeName, releaseID)
+
+	b.NoError(err)
+	b.False(release.Verified)
+}
+
+func (b *backendTestSuite) TestGetRelease_InvalidSignature() {
+	b.withSigner()
+	b.withSignedObject([]byte("bacon"))
+
+	release, err := b.sut.GetRelease(b.ctx, scopeName, releaseID)
+
+	b.Nil(release)
+	b.EqualError(err, "could not verify release signature: invalid signature")
+}
+
+func (b *backendTestSuite) withSigner() ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	b.Require().NoError(err)
+
+	b.sut = backend.New(b.ssmvars, b.s3, bucketName, backend.WithSigner(signer.NewEd25519(privateKey, nil)))
+	return privateKey
+}
+
+func (b *backendTestSuite) withSignedObject(signature []byte) {
+	b.s3.On("GetObjectWithContext", b.ctx, mock.Anything, []request.Option(nil)).Return(&s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(signedBody)),
+		Metadata: map[string]*string{
+			"Signature": aws.String(base64.StdEncoding.EncodeToString(signature)),
+		},
+	}, nil)
+}
@@ -1,27 +1,90 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/ssm"
-	"github.com/aws/aws-xray-sdk-go/xray"
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/marcinwyszynski/secretservice"
 	"github.com/marcinwyszynski/secretservice/backend"
+	"github.com/marcinwyszynski/secretservice/expiry"
 	"github.com/marcinwyszynski/secretservice/handler"
+	"github.com/marcinwyszynski/secretservice/health"
+	"github.com/marcinwyszynski/secretservice/idempotency"
+	"github.com/marcinwyszynski/secretservice/loader"
+	"github.com/marcinwyszynski/secretservice/metrics"
+	"github.com/marcinwyszynski/secretservice/ratelimit"
 	"github.com/marcinwyszynski/secretservice/resolver"
+	"github.com/marcinwyszynski/secretservice/scheduler"
+	"github.com/marcinwyszynski/secretservice/signer"
+	"github.com/marcinwyszynski/secretservice/tracing"
 	"github.com/marcinwyszynski/ssmvars"
 	"github.com/pkg/errors"
 )
 
+const (
+	modeGraphQL     = "graphql"
+	modeExpiryCheck = "expiry-check"
+	modeHTTP        = "http"
+	modeScheduler   = "scheduler"
+
+	commandVerify = "verify"
+)
+
+// version and commit are set at build time, e.g. with -ldflags
+// "-X main.version=1.2.0 -X main.commit=$(git rev-parse HEAD)".
+var (
+	version = "dev"
+	commit  = "unknown"
+)
+
 type config struct {
-	BucketName string `envconfig:"S3_BUCKET_NAME" required:"true"`
-	KMSKeyID   string `envconfig:"KMS_KEY_ID"`
-	LogLevel   string `envconfig:"LOG_LEVEL" default:"INFO"`
-	SSMPrefix  string `envconfig:"SSM_PREFIX" required:"true"`
+	ALBIdentityHeader string        `envconfig:"ALB_IDENTITY_HEADER"`
+	BucketName        string        `envconfig:"S3_BUCKET_NAME" required:"true"`
+	ContentHashKey    string        `envconfig:"CONTENT_HASH_KEY"`
+	ExpiryWindow      time.Duration `envconfig:"EXPIRY_WINDOW" default:"168h"`
+	HTTPAddr          string        `envconfig:"HTTP_ADDR" default:":8080"`
+	KMSKeyID          string        `envconfig:"KMS_KEY_ID"`
+	LogLevel          string        `envconfig:"LOG_LEVEL" default:"INFO"`
+	MetricsNamespace  string        `envconfig:"METRICS_NAMESPACE" default:"SecretService"`
+	Mode              string        `envconfig:"MODE" default:"graphql"`
+	ScheduleInterval  time.Duration `envconfig:"SCHEDULE_INTERVAL" default:"1m"`
+	SNSTopicARN       string        `envconfig:"SNS_TOPIC_ARN"`
+	SSMPrefix         string        `envconfig:"SSM_PREFIX" required:"true"`
+
+	TracingExporter    string `envconfig:"TRACING_EXPORTER"`
+	TracingServiceName string `envconfig:"TRACING_SERVICE_NAME" default:"secretservice"`
+
+	SigningAlgorithm string `envconfig:"SIGNING_ALGORITHM" default:"ECDSA_SHA_256"`
+	SigningKeyFile   string `envconfig:"SIGNING_KEY_FILE"`
+	SigningKMSKeyID  string `envconfig:"SIGNING_KMS_KEY_ID"`
+
+	PersistedQueriesFile string `envconfig:"PERSISTED_QUERIES_FILE"`
+	RejectAdHocQueries   bool   `envconfig:"REJECT_AD_HOC_QUERIES"`
+
+	IdempotencyTable  string        `envconfig:"IDEMPOTENCY_TABLE"`
+	IdempotencyWindow time.Duration `envconfig:"IDEMPOTENCY_WINDOW" default:"24h"`
+
+	MaxQueryCost  int64 `envconfig:"MAX_QUERY_COST" default:"1000"`
+	MaxQueryDepth int   `envconfig:"MAX_QUERY_DEPTH" default:"15"`
+
+	RateLimitMutations ratelimit.Limit `envconfig:"RATE_LIMIT_MUTATIONS"`
+	RateLimitQueries   ratelimit.Limit `envconfig:"RATE_LIMIT_QUERIES"`
+	RateLimitReleases  ratelimit.Limit `envconfig:"RATE_LIMIT_RELEASES"`
+	RateLimitTable     string          `envconfig:"RATE_LIMIT_TABLE"`
 }
 
 func main() {
@@ -36,39 +99,323 @@ func main() {
 	}
 	log.SetLevel(level)
 
+	log.Debug("Setting up tracing")
+	if err := tracing.Setup(context.Background(), cfg.TracingExporter, cfg.TracingServiceName); err != nil {
+		log.Fatalf("Could not set up tracing: %v", err)
+	}
+
 	log.Debug("Starting AWS session")
 	session := session.Must(session.NewSession())
 
-	log.Debug("Building handler")
-	handler, err := buildHandler(session, &cfg)
-	if err != nil {
-		log.Fatalf("Could not build GraphQL schema: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == commandVerify {
+		if err := verify(session, &cfg, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	log.Info("Starting Lambda server")
-	lambda.Start(handler.Handle)
+	switch cfg.Mode {
+	case modeGraphQL:
+		log.Debug("Building handler")
+		handler, err := buildHandler(session, &cfg, metrics.NewEMF(os.Stdout, cfg.MetricsNamespace))
+		if err != nil {
+			log.Fatalf("Could not build GraphQL handler: %v", err)
+		}
+
+		log.Info("Starting Lambda server")
+		lambda.Start(func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+			defer tracing.Flush(ctx)
+			return handler.Handle(ctx, payload)
+		})
+	case modeExpiryCheck:
+		log.Debug("Building expiry checker")
+		checker, err := buildChecker(session, &cfg)
+		if err != nil {
+			log.Fatalf("Could not build expiry checker: %v", err)
+		}
+
+		log.Info("Starting Lambda server for expiry checks")
+		lambda.Start(checker.Handle)
+	case modeScheduler:
+		log.Debug("Building scheduled operations runner")
+		runner, err := buildRunner(session, &cfg)
+		if err != nil {
+			log.Fatalf("Could not build scheduled operations runner: %v", err)
+		}
+
+		log.Info("Starting Lambda server for scheduled operations")
+		lambda.Start(runner.Handle)
+	case modeHTTP:
+		registry := metrics.NewRegistry()
+
+		log.Debug("Building handler")
+		handler, err := buildHandler(session, &cfg, registry)
+		if err != nil {
+			log.Fatalf("Could not build GraphQL handler: %v", err)
+		}
+
+		log.Debug("Building scheduled operations runner")
+		runner, err := buildRunner(session, &cfg)
+		if err != nil {
+			log.Fatalf("Could not build scheduled operations runner: %v", err)
+		}
+		go runSchedule(runner, cfg.ScheduleInterval)
+
+		log.Debug("Building health checks")
+		mux, err := buildMux(session, &cfg, handler, registry)
+		if err != nil {
+			log.Fatalf("Could not build health checks: %v", err)
+		}
+
+		log.Infof("Starting HTTP server on %s", cfg.HTTPAddr)
+		log.Fatal(http.ListenAndServe(cfg.HTTPAddr, mux))
+	default:
+		log.Fatalf("Unsupported mode %q", cfg.Mode)
+	}
 }
 
-func buildHandler(session *session.Session, cfg *config) (*handler.Handler, error) {
+func buildBackend(session *session.Session, cfg *config) (*backend.Backend, error) {
 	log.Debug("Creating SSM API client")
 	ssmAPI := ssm.New(session)
-	xray.AWS(ssmAPI.Client)
+	tracing.AWS(ssmAPI.Client)
 
 	log.Debug("Creating S3 API client")
 	s3API := s3.New(session)
-	xray.AWS(s3API.Client)
+	tracing.AWS(s3API.Client)
 
 	log.Debug("Setting up SSM variables handler")
 	ssmvars := ssmvars.New(ssmAPI, cfg.SSMPrefix, cfg.KMSKeyID)
 
+	// Without a key, content hashes are keyed with an empty one, so they can
+	// reveal guessable values of write-only Variables.
+	if cfg.ContentHashKey == "" {
+		log.Warn("CONTENT_HASH_KEY is not set, content hashes are not keyed")
+	}
+	opts := []backend.Option{backend.WithContentHashKey([]byte(cfg.ContentHashKey))}
+
+	signer, err := buildSigner(session, cfg)
+	if err != nil {
+		return nil, err
+	} else if signer != nil {
+		opts = append(opts, backend.WithSigner(signer))
+	}
+
 	log.Debug("Setting up backend")
-	backend := backend.New(ssmvars, s3API, cfg.BucketName)
+	return backend.New(ssmvars, s3API, cfg.BucketName, opts...), nil
+}
+
+func buildSigner(session *session.Session, cfg *config) (secretservice.Signer, error) {
+	switch {
+	case cfg.SigningKeyFile != "" && cfg.SigningKMSKeyID != "":
+		return nil, errors.New("only one of SIGNING_KEY_FILE and SIGNING_KMS_KEY_ID can be set")
+	case cfg.SigningKeyFile != "":
+		log.Debug("Loading ed25519 signing key")
+		ret, err := signer.LoadEd25519(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load signing key")
+		}
+		return ret, nil
+	case cfg.SigningKMSKeyID != "":
+		log.Debug("Creating KMS API client")
+		kmsAPI := kms.New(session)
+		tracing.AWS(kmsAPI.Client)
+
+		return signer.NewKMS(kmsAPI, cfg.SigningKMSKeyID, cfg.SigningAlgorithm), nil
+	default:
+		return nil, nil
+	}
+}
+
+// buildHandler builds the GraphQL handler. If the recorder is not nil, both
+// operations and Backend calls are recorded with it.
+func buildHandler(session *session.Session, cfg *config, recorder metrics.Recorder) (*handler.Handler, error) {
+	var backend secretservice.Backend
+	backend, err := buildBackend(session, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []handler.Option
+	if recorder != nil {
+		backend = metrics.NewBackend(backend, recorder)
+		opts = append(opts, handler.WithMetrics(recorder))
+	}
 
 	log.Debug("Setting up GraphQL schema")
-	schema, err := graphql.ParseSchema(secretservice.Schema, resolver.New(backend))
+	schema, err := graphql.ParseSchema(
+		secretservice.Schema,
+		resolver.New(loader.New(backend)),
+		graphql.Tracer(tracing.GraphQLTracer{}),
+		graphql.MaxDepth(cfg.MaxQueryDepth),
+	)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create a GraphQL schema")
 	}
 
-	return handler.New(schema), nil
+	opts = append(opts, handler.WithMaxCost(cfg.MaxQueryCost))
+	if cfg.ALBIdentityHeader != "" {
+		opts = append(opts, handler.WithIdentityHeader(cfg.ALBIdentityHeader))
+	}
+	if store := buildIdempotencyStore(session, cfg); store != nil {
+		opts = append(opts, handler.WithIdempotency(store, cfg.IdempotencyWindow))
+	}
+	if limiter := buildLimiter(session, cfg); limiter != nil {
+		opts = append(opts, handler.WithRateLimits(limiter, handler.RateLimits{
+			Queries:   cfg.RateLimitQueries,
+			Mutations: cfg.RateLimitMutations,
+			Releases:  cfg.RateLimitReleases,
+		}))
+	}
+
+	switch {
+	case cfg.PersistedQueriesFile != "":
+		log.Debug("Loading persisted queries")
+		queries, err := handler.LoadPersistedQueries(cfg.PersistedQueriesFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, handler.WithPersistedQueries(queries, cfg.RejectAdHocQueries))
+	case cfg.RejectAdHocQueries:
+		return nil, errors.New("REJECT_AD_HOC_QUERIES requires PERSISTED_QUERIES_FILE to be set")
+	}
+
+	return handler.New(schema, opts...), nil
+}
+
+// buildLimiter returns a limiter shared by all instances through DynamoDB if
+// RATE_LIMIT_TABLE is set, or one local to the process otherwise. It returns
+// nil if no rate limits are set.
+func buildLimiter(session *session.Session, cfg *config) ratelimit.Limiter {
+	if cfg.RateLimitQueries.Unlimited() && cfg.RateLimitMutations.Unlimited() && cfg.RateLimitReleases.Unlimited() {
+		return nil
+	}
+
+	if cfg.RateLimitTable == "" {
+		return ratelimit.NewMemoryLimiter()
+	}
+
+	log.Debug("Creating DynamoDB API client")
+	dynamoDBAPI := dynamodb.New(session)
+	tracing.AWS(dynamoDBAPI.Client)
+
+	return ratelimit.NewDynamoDBLimiter(dynamoDBAPI, cfg.RateLimitTable)
+}
+
+// buildIdempotencyStore returns a store shared by all instances through
+// DynamoDB if IDEMPOTENCY_TABLE is set. Otherwise responses are only kept in
+// memory in the HTTP mode, as Lambda instances share nothing and come and go.
+// It returns nil if idempotency is disabled.
+func buildIdempotencyStore(session *session.Session, cfg *config) idempotency.Store {
+	if cfg.IdempotencyWindow <= 0 {
+		return nil
+	}
+
+	if cfg.IdempotencyTable == "" {
+		if cfg.Mode != modeHTTP {
+			log.Warn("Idempotency is disabled, IDEMPOTENCY_TABLE is required outside of the HTTP mode")
+			return nil
+		}
+		return idempotency.NewMemoryStore()
+	}
+
+	log.Debug("Creating DynamoDB API client")
+	dynamoDBAPI := dynamodb.New(session)
+	tracing.AWS(dynamoDBAPI.Client)
+
+	return idempotency.NewDynamoDBStore(dynamoDBAPI, cfg.IdempotencyTable)
+}
+
+// buildMux serves the GraphQL handler alongside the "/healthz", "/readyz",
+// "/version" and "/metrics" endpoints.
+func buildMux(session *session.Session, cfg *config, handler http.Handler, registry *metrics.Registry) (*http.ServeMux, error) {
+	backend, err := buildBackend(session, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := http.NewServeMux()
+	health.New(backend, health.NewBuildInfo(version, commit)).Register(ret)
+	ret.Handle("/metrics", registry)
+	ret.Handle("/", handler)
+
+	return ret, nil
+}
+
+func buildChecker(session *session.Session, cfg *config) (*expiry.Checker, error) {
+	backend, err := buildBackend(session, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifier secretservice.Notifier = logNotifier{}
+	if cfg.SNSTopicARN != "" {
+		log.Debug("Creating SNS API client")
+		snsAPI := sns.New(session)
+		tracing.AWS(snsAPI.Client)
+
+		notifier = expiry.NewSNSNotifier(snsAPI, cfg.SNSTopicARN)
+	}
+
+	return expiry.New(backend, notifier, cfg.ExpiryWindow), nil
+}
+
+func buildRunner(session *session.Session, cfg *config) (*scheduler.Runner, error) {
+	backend, err := buildBackend(session, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduler.New(backend), nil
+}
+
+// runSchedule periodically executes due scheduled operations, serving as a
+// replacement for a CloudWatch schedule when running outside of Lambda.
+func runSchedule(runner *scheduler.Runner, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if err := runner.Run(context.Background(), now); err != nil {
+			log.Errorf("Could not run scheduled operations: %v", err)
+		}
+	}
+}
+
+func verify(session *session.Session, cfg *config, args []string) error {
+	if len(args) != 2 {
+		return errors.Errorf("usage: %s %s <scope> <release>", os.Args[0], commandVerify)
+	}
+
+	if cfg.SigningKeyFile == "" && cfg.SigningKMSKeyID == "" {
+		return errors.New("verification requires SIGNING_KEY_FILE or SIGNING_KMS_KEY_ID")
+	}
+
+	backend, err := buildBackend(session, cfg)
+	if err != nil {
+		return err
+	}
+
+	release, err := backend.GetRelease(context.Background(), args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	if !release.Verified {
+		return errors.Errorf("release %s in scope %s is not signed", release.ID, release.ScopeName)
+	}
+
+	fmt.Printf("release %s in scope %s has a valid signature\n", release.ID, release.ScopeName)
+	return nil
+}
+
+type logNotifier struct{}
+
+func (logNotifier) Notify(_ context.Context, notification *secretservice.Notification) error {
+	log.WithFields(log.Fields{
+		"deadline": notification.Deadline,
+		"expired":  notification.Expired,
+		"scope":    notification.ScopeName,
+		"variable": notification.VariableName,
+	}).Warn("Variable needs attention")
+	return nil
 }
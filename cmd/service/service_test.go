@@ -1,10 +1,17 @@
 package main
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/marcinwyszynski/secretservice/idempotency"
+	"github.com/marcinwyszynski/secretservice/metrics"
+	"github.com/marcinwyszynski/secretservice/ratelimit"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,8 +20,141 @@ func TestBuildHandler(t *testing.T) {
 	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
 	session := session.Must(session.NewSession())
 
-	handler, err := buildHandler(session, new(config))
+	handler, err := buildHandler(session, new(config), nil)
 
 	assert.NotNil(t, handler)
 	assert.NoError(t, err)
 }
+
+func TestBuildHandler_MaxQueryDepth(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	handler, err := buildHandler(session, &config{MaxQueryDepth: 1}, nil)
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(
+		http.MethodPost,
+		"/",
+		strings.NewReader(`{"query":"{ scope(scopeId: \"bacon\") { kmsKeyId } }"}`),
+	))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `Field \"kmsKeyId\" has depth 2 that exceeds max depth 1`)
+}
+
+func TestBuildHandler_Metrics(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	handler, err := buildHandler(session, new(config), metrics.NewRegistry())
+
+	assert.NotNil(t, handler)
+	assert.NoError(t, err)
+}
+
+func TestBuildChecker(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	checker, err := buildChecker(session, &config{SNSTopicARN: "topic"})
+
+	assert.NotNil(t, checker)
+	assert.NoError(t, err)
+}
+
+func TestBuildHandler_KMSSigner(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	handler, err := buildHandler(session, &config{SigningKMSKeyID: "key"}, nil)
+
+	assert.NotNil(t, handler)
+	assert.NoError(t, err)
+}
+
+func TestBuildHandler_ConflictingSigners(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	handler, err := buildHandler(session, &config{SigningKMSKeyID: "key", SigningKeyFile: "key.pem"}, nil)
+
+	assert.Nil(t, handler)
+	assert.EqualError(t, err, "only one of SIGNING_KEY_FILE and SIGNING_KMS_KEY_ID can be set")
+}
+
+func TestBuildHandler_MissingKeyFile(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	handler, err := buildHandler(session, &config{SigningKeyFile: "/nonexistent/key.pem"}, nil)
+
+	assert.Nil(t, handler)
+	assert.Error(t, err)
+}
+
+func TestBuildHandler_RejectAdHocWithoutPersistedQueries(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	handler, err := buildHandler(session, &config{RejectAdHocQueries: true}, nil)
+
+	assert.Nil(t, handler)
+	assert.EqualError(t, err, "REJECT_AD_HOC_QUERIES requires PERSISTED_QUERIES_FILE to be set")
+}
+
+func TestBuildRunner(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	runner, err := buildRunner(session, new(config))
+
+	assert.NotNil(t, runner)
+	assert.NoError(t, err)
+}
+
+func TestBuildLimiter(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+	limit := ratelimit.Limit{Count: 60, Period: time.Minute}
+
+	assert.Nil(t, buildLimiter(session, new(config)))
+	assert.IsType(t, new(ratelimit.MemoryLimiter), buildLimiter(session, &config{RateLimitQueries: limit}))
+	assert.IsType(t, new(ratelimit.DynamoDBLimiter), buildLimiter(session, &config{RateLimitReleases: limit, RateLimitTable: "limits"}))
+}
+
+func TestBuildIdempotencyStore(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	assert.Nil(t, buildIdempotencyStore(session, new(config)))
+	assert.Nil(t, buildIdempotencyStore(session, &config{Mode: modeGraphQL, IdempotencyWindow: time.Hour}))
+	assert.IsType(t, new(idempotency.MemoryStore), buildIdempotencyStore(session, &config{Mode: modeHTTP, IdempotencyWindow: time.Hour}))
+	assert.IsType(t, new(idempotency.DynamoDBStore), buildIdempotencyStore(session, &config{
+		Mode:              modeGraphQL,
+		IdempotencyTable:  "responses",
+		IdempotencyWindow: time.Hour,
+	}))
+}
+
+func TestBuildMux(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "accesskey")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	session := session.Must(session.NewSession())
+
+	mux, err := buildMux(session, new(config), http.NotFoundHandler(), metrics.NewRegistry())
+
+	assert.NotNil(t, mux)
+	assert.NoError(t, err)
+}
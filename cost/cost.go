@@ -0,0 +1,175 @@
+// Package cost estimates how expensive a GraphQL operation is to execute
+// before it is executed, so that abusive queries can be rejected upfront.
+package cost
+
+import (
+	"github.com/marcinwyszynski/secretservice/document"
+	"github.com/pkg/errors"
+)
+
+// maxCost caps the computed cost, so that deeply nested lists do not
+// overflow it.
+const maxCost = 1 << 40
+
+// Weights describe the cost of fields, by field name. A field costs Default
+// unless it is listed in Fields. Fields listed in Lists return lists of the
+// given assumed size, so the cost of their selections is multiplied by it.
+type Weights struct {
+	Default int
+	Fields  map[string]int
+	Lists   map[string]int
+}
+
+// DefaultWeights is tuned for the Secret Service schema. Fields which load
+// releases from S3, list parameters from SSM or compute diffs cost more, and
+// lists of objects backed by S3 objects are assumed to be full pages.
+var DefaultWeights = Weights{
+	Default: 1,
+	Fields: map[string]int{
+		"baseRelease":         2,
+		"compare":             3,
+		"diff":                3,
+		"expiringVariables":   3,
+		"previewRelease":      5,
+		"proposals":           2,
+		"release":             2,
+		"releases":            2,
+		"scheduledOperations": 2,
+		"variables":           2,
+	},
+	Lists: map[string]int{
+		"proposals":           10,
+		"releases":            10,
+		"scheduledOperations": 10,
+	},
+}
+
+// Result is the cost of a single operation.
+type Result struct {
+	// Depth is the deepest level of nested fields, top-level fields being
+	// at depth 1.
+	Depth int
+
+	// Cost is the sum of weights of all fields the operation selects.
+	Cost int64
+}
+
+// Analyze computes the Result for an operation of a parsed document.
+func Analyze(doc *document.Document, operation *document.Operation, weights Weights) (*Result, error) {
+	a := &analyzer{
+		fragments: doc.Fragments,
+		weights:   weights,
+		results:   make(map[string]*Result),
+		visiting:  make(map[string]bool),
+	}
+
+	return a.selections(operation.Selections)
+}
+
+type analyzer struct {
+	fragments map[string][]*document.Selection
+	weights   Weights
+
+	// results of fragments already analyzed, so that fragments spread many
+	// times are only analyzed once.
+	results map[string]*Result
+
+	// visiting holds fragments being analyzed, to detect cycles.
+	visiting map[string]bool
+}
+
+// selections returns the Result of a selection set relative to its parent:
+// its fields are at depth 1, and resolved once.
+func (a *analyzer) selections(selections []*document.Selection) (*Result, error) {
+	ret := new(Result)
+
+	for _, selection := range selections {
+		var result *Result
+		var err error
+
+		switch {
+		case selection.Field != "":
+			result, err = a.field(selection)
+		case selection.Fragment != "":
+			result, err = a.spread(selection.Fragment)
+		default:
+			result, err = a.selections(selection.Selections)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Depth > ret.Depth {
+			ret.Depth = result.Depth
+		}
+		ret.Cost = saturatingAdd(ret.Cost, result.Cost)
+	}
+
+	return ret, nil
+}
+
+func (a *analyzer) field(field *document.Selection) (*Result, error) {
+	weight, ok := a.weights.Fields[field.Field]
+	if !ok {
+		weight = a.weights.Default
+	}
+
+	ret := &Result{Depth: 1, Cost: int64(weight)}
+	if len(field.Selections) == 0 {
+		return ret, nil
+	}
+
+	children, err := a.selections(field.Selections)
+	if err != nil {
+		return nil, err
+	}
+
+	if size, ok := a.weights.Lists[field.Field]; ok {
+		children.Cost = saturatingMultiply(children.Cost, int64(size))
+	}
+
+	ret.Depth += children.Depth
+	ret.Cost = saturatingAdd(ret.Cost, children.Cost)
+	return ret, nil
+}
+
+func (a *analyzer) spread(name string) (*Result, error) {
+	if result, ok := a.results[name]; ok {
+		return result, nil
+	}
+
+	selections, ok := a.fragments[name]
+	if !ok {
+		return nil, errors.Errorf("fragment %q not found", name)
+	}
+
+	if a.visiting[name] {
+		return nil, errors.Errorf("fragment %q spreads itself", name)
+	}
+
+	a.visiting[name] = true
+	defer delete(a.visiting, name)
+
+	ret, err := a.selections(selections)
+	if err != nil {
+		return nil, err
+	}
+
+	a.results[name] = ret
+	return ret, nil
+}
+
+func saturatingAdd(a, b int64) int64 {
+	if a+b > maxCost {
+		return maxCost
+	}
+	return a + b
+}
+
+func saturatingMultiply(a, b int64) int64 {
+	if a != 0 && b > maxCost/a {
+		return maxCost
+	}
+	return a * b
+}
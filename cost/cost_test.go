@@ -0,0 +1,95 @@
+package cost
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marcinwyszynski/secretservice/document"
+	"github.com/stretchr/testify/suite"
+)
+
+var testWeights = Weights{
+	Default: 1,
+	Fields:  map[string]int{"releases": 2, "diff": 3},
+	Lists:   map[string]int{"releases": 10},
+}
+
+type costTestSuite struct {
+	suite.Suite
+}
+
+func (c *costTestSuite) TestAnalyze_Shorthand() {
+	ret, err := c.analyze(`{ scope(scopeId: "scope") { id revision } }`)
+
+	c.NoError(err)
+	c.Equal(&Result{Depth: 2, Cost: 3}, ret)
+}
+
+func (c *costTestSuite) TestAnalyze_Lists() {
+	ret, err := c.analyze(`
+		query Releases($scopeId: ID!) {
+			scope(scopeId: $scopeId) {
+				releases { id diff(since: "base") { added { id } } }
+			}
+		}
+	`)
+
+	c.NoError(err)
+	c.Equal(5, ret.Depth)
+	c.EqualValues(1+2+10*(1+3+1+1), ret.Cost)
+}
+
+func (c *costTestSuite) TestAnalyze_Fragments() {
+	ret, err := c.analyze(`
+		query {
+			first: scope(scopeId: "first") { ...ScopeFields }
+			second: scope(scopeId: "second") { ...ScopeFields ... on Scope { revision } }
+		}
+
+		fragment ScopeFields on Scope { id releases { id } }
+	`)
+
+	c.NoError(err)
+	c.Equal(3, ret.Depth)
+	c.EqualValues(2*(1+1+2+10)+1, ret.Cost)
+}
+
+func (c *costTestSuite) TestAnalyze_Saturates() {
+	query := "{ " + strings.Repeat("releases { ", 20) + "id" + strings.Repeat(" }", 20) + " }"
+
+	ret, err := c.analyze(query)
+
+	c.NoError(err)
+	c.Equal(21, ret.Depth)
+	c.EqualValues(maxCost, ret.Cost)
+}
+
+func (c *costTestSuite) TestAnalyze_FragmentCycle() {
+	_, err := c.analyze(`
+		{ ...First }
+		fragment First on Query { ...Second }
+		fragment Second on Query { ...First }
+	`)
+
+	c.EqualError(err, `fragment "First" spreads itself`)
+}
+
+func (c *costTestSuite) TestAnalyze_UnknownFragment() {
+	_, err := c.analyze(`{ ...Missing }`)
+
+	c.EqualError(err, `fragment "Missing" not found`)
+}
+
+func (c *costTestSuite) analyze(query string) (*Result, error) {
+	doc, err := document.Parse(query)
+	c.Require().NoError(err)
+
+	operation, err := doc.Operation("")
+	c.Require().NoError(err)
+
+	return Analyze(doc, operation, testWeights)
+}
+
+func TestCost(t *testing.T) {
+	suite.Run(t, new(costTestSuite))
+}
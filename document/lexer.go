@@ -0,0 +1,190 @@
+package document
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenNumber
+	tokenString
+)
+
+type token struct {
+	kind   tokenKind
+	value  string
+	offset int
+}
+
+// lexer splits a GraphQL document into tokens, skipping whitespace, commas
+// and comments.
+type lexer struct {
+	input  string
+	offset int
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+
+	if l.offset >= len(l.input) {
+		return token{kind: tokenEOF, offset: l.offset}, nil
+	}
+
+	start := l.offset
+	char := l.input[start]
+
+	switch {
+	case strings.HasPrefix(l.input[start:], "..."):
+		l.offset += 3
+		return token{kind: tokenPunctuator, value: "...", offset: start}, nil
+	case strings.IndexByte("!$&():=@[]{|}", char) >= 0:
+		l.offset++
+		return token{kind: tokenPunctuator, value: string(char), offset: start}, nil
+	case isNameStart(char):
+		for l.offset < len(l.input) && isNameContinue(l.input[l.offset]) {
+			l.offset++
+		}
+		return token{kind: tokenName, value: l.input[start:l.offset], offset: start}, nil
+	case char == '-' || isDigit(char):
+		return l.number()
+	case strings.HasPrefix(l.input[start:], `"""`):
+		return l.blockString()
+	case char == '"':
+		return l.string()
+	}
+
+	return token{}, l.errorf(start, "unexpected character %q", char)
+}
+
+func (l *lexer) skipIgnored() {
+	for l.offset < len(l.input) {
+		switch char := l.input[l.offset]; {
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r' || char == ',':
+			l.offset++
+		case char == '#':
+			for l.offset < len(l.input) && l.input[l.offset] != '\n' && l.input[l.offset] != '\r' {
+				l.offset++
+			}
+		case strings.HasPrefix(l.input[l.offset:], "\ufeff"):
+			l.offset += len("\ufeff")
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.offset
+
+	if l.input[l.offset] == '-' {
+		l.offset++
+	}
+
+	digits := l.digits()
+	if digits == 0 {
+		return token{}, l.errorf(start, "invalid number")
+	}
+
+	if l.offset < len(l.input) && l.input[l.offset] == '.' {
+		l.offset++
+		if l.digits() == 0 {
+			return token{}, l.errorf(start, "invalid number")
+		}
+	}
+
+	if l.offset < len(l.input) && (l.input[l.offset] == 'e' || l.input[l.offset] == 'E') {
+		l.offset++
+		if l.offset < len(l.input) && (l.input[l.offset] == '+' || l.input[l.offset] == '-') {
+			l.offset++
+		}
+		if l.digits() == 0 {
+			return token{}, l.errorf(start, "invalid number")
+		}
+	}
+
+	if l.offset < len(l.input) && (isNameStart(l.input[l.offset]) || l.input[l.offset] == '.') {
+		return token{}, l.errorf(start, "invalid number")
+	}
+
+	return token{kind: tokenNumber, value: l.input[start:l.offset], offset: start}, nil
+}
+
+func (l *lexer) digits() int {
+	start := l.offset
+	for l.offset < len(l.input) && isDigit(l.input[l.offset]) {
+		l.offset++
+	}
+	return l.offset - start
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.offset
+	l.offset++
+
+	for l.offset < len(l.input) {
+		switch l.input[l.offset] {
+		case '"':
+			l.offset++
+			return token{kind: tokenString, value: l.input[start:l.offset], offset: start}, nil
+		case '\\':
+			l.offset += 2
+		case '\n', '\r':
+			return token{}, l.errorf(start, "unterminated string")
+		default:
+			l.offset++
+		}
+	}
+
+	return token{}, l.errorf(start, "unterminated string")
+}
+
+func (l *lexer) blockString() (token, error) {
+	start := l.offset
+	l.offset += 3
+
+	for l.offset < len(l.input) {
+		switch {
+		case strings.HasPrefix(l.input[l.offset:], `\"""`):
+			l.offset += 4
+		case strings.HasPrefix(l.input[l.offset:], `"""`):
+			l.offset += 3
+			return token{kind: tokenString, value: l.input[start:l.offset], offset: start}, nil
+		default:
+			l.offset++
+		}
+	}
+
+	return token{}, l.errorf(start, "unterminated block string")
+}
+
+// errorf returns an error pointing at the line and column of the offset.
+func (l *lexer) errorf(offset int, format string, args ...interface{}) error {
+	line, column := 1, 1
+	for _, char := range l.input[:offset] {
+		if char == '\n' {
+			line, column = line+1, 1
+		} else {
+			column++
+		}
+	}
+
+	return errors.Wrapf(errors.Errorf(format, args...), "line %d, column %d", line, column)
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isNameStart(char byte) bool {
+	return char == '_' || (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z')
+}
+
+func isNameContinue(char byte) bool {
+	return isNameStart(char) || isDigit(char)
+}
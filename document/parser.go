@@ -0,0 +1,482 @@
+// Package document parses executable GraphQL documents into the shape the
+// service needs to inspect before executing them: the kind of operations,
+// their selection sets and argument values.
+package document
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Document is a parsed executable GraphQL document. Directives and the types
+// of variables and fragments are parsed, but not retained.
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string][]*Selection
+}
+
+// Operation is a single query, mutation or subscription.
+type Operation struct {
+	// Type is "query", "mutation" or "subscription".
+	Type       string
+	Name       string
+	Selections []*Selection
+}
+
+// Selection is either a field, a fragment spread or an inline fragment. Only
+// fields have a name and arguments, only fragment spreads have a fragment.
+type Selection struct {
+	Field      string
+	Fragment   string
+	Arguments  map[string]*Value
+	Selections []*Selection
+}
+
+// Value is the value of an argument. It is either a reference to a variable,
+// or a literal, which is only retained for strings.
+type Value struct {
+	Variable string
+	String   *string
+}
+
+// Operation returns the operation with a given name. The name can only be
+// empty if the Document contains a single operation.
+func (d *Document) Operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(d.Operations) != 1 {
+			return nil, errors.New("operation name is required for documents with multiple operations")
+		}
+		return d.Operations[0], nil
+	}
+
+	for _, operation := range d.Operations {
+		if operation.Name == name {
+			return operation, nil
+		}
+	}
+
+	return nil, errors.Errorf("operation %q not found", name)
+}
+
+type parser struct {
+	lexer   *lexer
+	current token
+}
+
+// Parse parses an executable GraphQL document.
+func Parse(input string) (*Document, error) {
+	p := &parser{lexer: &lexer{input: input}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	ret := &Document{Fragments: make(map[string][]*Selection)}
+
+	for p.current.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			ret.Operations = append(ret.Operations, &Operation{Type: "query", Selections: selections})
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			ret.Operations = append(ret.Operations, operation)
+		case p.peek(tokenName, "fragment"):
+			name, selections, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := ret.Fragments[name]; exists {
+				return nil, p.errorf("fragment %q is defined more than once", name)
+			}
+			ret.Fragments[name] = selections
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(ret.Operations) == 0 {
+		return nil, p.errorf("document contains no operations")
+	}
+
+	return ret, nil
+}
+
+func (p *parser) operation() (*Operation, error) {
+	ret := &Operation{Type: p.current.value}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.current.kind == tokenName {
+		ret.Name = p.current.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek(tokenPunctuator, "(") {
+		if err := p.variableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	ret.Selections = selections
+
+	return ret, nil
+}
+
+func (p *parser) variableDefinitions() error {
+	if err := p.expect(tokenPunctuator, "("); err != nil {
+		return err
+	}
+
+	for !p.peek(tokenPunctuator, ")") {
+		if err := p.expect(tokenPunctuator, "$"); err != nil {
+			return err
+		}
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if err := p.expect(tokenPunctuator, ":"); err != nil {
+			return err
+		}
+		if err := p.typeReference(); err != nil {
+			return err
+		}
+		if p.peek(tokenPunctuator, "=") {
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if _, err := p.value(); err != nil {
+				return err
+			}
+		}
+		if err := p.directives(); err != nil {
+			return err
+		}
+	}
+
+	return p.advance()
+}
+
+func (p *parser) typeReference() error {
+	if p.peek(tokenPunctuator, "[") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.typeReference(); err != nil {
+			return err
+		}
+		if err := p.expect(tokenPunctuator, "]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+
+	if p.peek(tokenPunctuator, "!") {
+		return p.advance()
+	}
+	return nil
+}
+
+func (p *parser) fragment() (string, []*Selection, error) {
+	// Skip the "fragment" keyword.
+	if err := p.advance(); err != nil {
+		return "", nil, err
+	}
+
+	if p.peek(tokenName, "on") {
+		return "", nil, p.unexpected()
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := p.expect(tokenName, "on"); err != nil {
+		return "", nil, err
+	}
+	if _, err := p.name(); err != nil {
+		return "", nil, err
+	}
+	if err := p.directives(); err != nil {
+		return "", nil, err
+	}
+
+	selections, err := p.selectionSet()
+	return name, selections, err
+}
+
+func (p *parser) selectionSet() ([]*Selection, error) {
+	if err := p.expect(tokenPunctuator, "{"); err != nil {
+		return nil, err
+	}
+
+	var ret []*Selection
+	for {
+		selection, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, selection)
+
+		if p.peek(tokenPunctuator, "}") {
+			return ret, p.advance()
+		}
+	}
+}
+
+func (p *parser) selection() (*Selection, error) {
+	if p.peek(tokenPunctuator, "...") {
+		return p.fragmentSelection()
+	}
+
+	ret := new(Selection)
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	ret.Field = name
+
+	// The name read so far was an alias.
+	if p.peek(tokenPunctuator, ":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if ret.Field, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek(tokenPunctuator, "(") {
+		if ret.Arguments, err = p.arguments(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+
+	if p.peek(tokenPunctuator, "{") {
+		if ret.Selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+func (p *parser) fragmentSelection() (*Selection, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	ret := new(Selection)
+
+	switch {
+	case p.peek(tokenName, "on"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if _, err := p.name(); err != nil {
+			return nil, err
+		}
+	case p.current.kind == tokenName:
+		ret.Fragment = p.current.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return ret, p.directives()
+	}
+
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+
+	selections, err := p.selectionSet()
+	ret.Selections = selections
+	return ret, err
+}
+
+func (p *parser) arguments() (map[string]*Value, error) {
+	if err := p.expect(tokenPunctuator, "("); err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]*Value)
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenPunctuator, ":"); err != nil {
+			return nil, err
+		}
+		if ret[name], err = p.value(); err != nil {
+			return nil, err
+		}
+
+		if p.peek(tokenPunctuator, ")") {
+			return ret, p.advance()
+		}
+	}
+}
+
+func (p *parser) directives() error {
+	for p.peek(tokenPunctuator, "@") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if p.peek(tokenPunctuator, "(") {
+			if _, err := p.arguments(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) value() (*Value, error) {
+	ret := new(Value)
+
+	switch {
+	case p.peek(tokenPunctuator, "$"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		ret.Variable = name
+		return ret, err
+	case p.peek(tokenPunctuator, "["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peek(tokenPunctuator, "]") {
+			if _, err := p.value(); err != nil {
+				return nil, err
+			}
+		}
+		return ret, p.advance()
+	case p.peek(tokenPunctuator, "{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peek(tokenPunctuator, "}") {
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokenPunctuator, ":"); err != nil {
+				return nil, err
+			}
+			if _, err := p.value(); err != nil {
+				return nil, err
+			}
+		}
+		return ret, p.advance()
+	case p.current.kind == tokenString:
+		literal, err := p.stringValue()
+		if err != nil {
+			return nil, err
+		}
+		ret.String = &literal
+		return ret, p.advance()
+	case p.current.kind == tokenName, p.current.kind == tokenNumber:
+		return ret, p.advance()
+	}
+
+	return nil, p.unexpected()
+}
+
+// stringValue decodes the current string token.
+func (p *parser) stringValue() (string, error) {
+	raw := p.current.value
+
+	if strings.HasPrefix(raw, `"""`) {
+		return strings.Replace(raw[3:len(raw)-3], `\"""`, `"""`, -1), nil
+	}
+
+	// Apart from the escaped solidus, GraphQL escapes are valid in Go.
+	var unescaped strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) {
+			i++
+			if raw[i] != '/' {
+				unescaped.WriteByte('\\')
+			}
+		}
+		unescaped.WriteByte(raw[i])
+	}
+
+	ret, err := strconv.Unquote(unescaped.String())
+	if err != nil {
+		return "", p.errorf("invalid string %s", raw)
+	}
+	return ret, nil
+}
+
+func (p *parser) name() (string, error) {
+	if p.current.kind != tokenName {
+		return "", p.unexpected()
+	}
+
+	ret := p.current.value
+	return ret, p.advance()
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.peek(kind, value) {
+		return p.errorf("expected %q, got %s", value, p.describe())
+	}
+	return p.advance()
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.current.kind == kind && p.current.value == value
+}
+
+func (p *parser) advance() error {
+	next, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.current = next
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	return p.errorf("unexpected %s", p.describe())
+}
+
+func (p *parser) describe() string {
+	if p.current.kind == tokenEOF {
+		return "end of document"
+	}
+	return "\"" + p.current.value + "\""
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return p.lexer.errorf(p.current.offset, format, args...)
+}
@@ -0,0 +1,119 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type parserTestSuite struct {
+	suite.Suite
+}
+
+func (p *parserTestSuite) TestParse_OK() {
+	ret, err := Parse("\ufeff" + `
+		# A comment.
+		query Scope($scopeId: ID!, $within: Duration = "1h", $ids: [ID!]! = ["a", "b"]) @cached {
+			alias: scope(scopeId: $scopeId) {
+				expiringVariables(within: $within) @include(if: true) { id }
+				render(format: JSON, privileged: false, options: {indent: 2, ratio: -1.5e3})
+				... on Scope @skip(if: false) { id }
+				... @include(if: true) { revision }
+				...Fields
+			}
+		}
+
+		fragment Fields on Scope { id, requiredApprovals }
+	`)
+
+	p.Require().NoError(err)
+	p.Require().Len(ret.Operations, 1)
+
+	operation := ret.Operations[0]
+	p.Equal("query", operation.Type)
+	p.Equal("Scope", operation.Name)
+	p.Require().Len(operation.Selections, 1)
+
+	scope := operation.Selections[0]
+	p.Equal("scope", scope.Field)
+	p.Equal(map[string]*Value{"scopeId": {Variable: "scopeId"}}, scope.Arguments)
+	p.Equal([]*Selection{
+		{
+			Field:      "expiringVariables",
+			Arguments:  map[string]*Value{"within": {Variable: "within"}},
+			Selections: []*Selection{{Field: "id"}},
+		},
+		{
+			Field:     "render",
+			Arguments: map[string]*Value{"format": {}, "privileged": {}, "options": {}},
+		},
+		{Selections: []*Selection{{Field: "id"}}},
+		{Selections: []*Selection{{Field: "revision"}}},
+		{Fragment: "Fields"},
+	}, scope.Selections)
+
+	p.Equal(map[string][]*Selection{
+		"Fields": {{Field: "id"}, {Field: "requiredApprovals"}},
+	}, ret.Fragments)
+}
+
+func (p *parserTestSuite) TestParse_Shorthand() {
+	ret, err := Parse(`{ hello }`)
+
+	p.NoError(err)
+	p.Equal([]*Operation{{Type: "query", Selections: []*Selection{{Field: "hello"}}}}, ret.Operations)
+}
+
+func (p *parserTestSuite) TestParse_Strings() {
+	ret, err := Parse(`mutation {
+		first: createScope(name: "a\"b\/c\\/dé")
+		second: createScope(name: """block "quoted" \""" text""")
+	}`)
+
+	p.Require().NoError(err)
+	selections := ret.Operations[0].Selections
+	p.Equal(`a"b/c\/dé`, *selections[0].Arguments["name"].String)
+	p.Equal(`block "quoted" """ text`, *selections[1].Arguments["name"].String)
+}
+
+func (p *parserTestSuite) TestParse_Errors() {
+	for query, expected := range map[string]string{
+		``:                        `line 1, column 1: document contains no operations`,
+		`{ }`:                     `line 1, column 3: unexpected "}"`,
+		`{ id`:                    `line 1, column 5: unexpected end of document`,
+		`{ id(a: 1) `:             `line 1, column 12: unexpected end of document`,
+		`{ id(a: "b) }`:           `line 1, column 9: unterminated string`,
+		`{ id(a: "\q") }`:         `line 1, column 9: invalid string "\q"`,
+		`{ id(a: 1x) }`:           `line 1, column 9: invalid number`,
+		`{ id % }`:                `line 1, column 6: unexpected character '%'`,
+		"{\n  id(a: ) }":          `line 2, column 9: unexpected ")"`,
+		`fragment on on X { id }`: `line 1, column 10: unexpected "on"`,
+		`fragment F X { id }`:     `line 1, column 12: expected "on", got "X"`,
+		"{ a } fragment F on X { a } fragment F on X { a }": `line 1, column 50: fragment "F" is defined more than once`,
+	} {
+		_, err := Parse(query)
+		p.EqualError(err, expected, query)
+	}
+}
+
+func (p *parserTestSuite) TestOperation() {
+	doc, err := Parse(`
+		query First { first }
+		mutation Second { second { id } }
+	`)
+	p.Require().NoError(err)
+
+	ret, err := doc.Operation("Second")
+	p.NoError(err)
+	p.Equal("mutation", ret.Type)
+
+	_, err = doc.Operation("")
+	p.EqualError(err, "operation name is required for documents with multiple operations")
+
+	_, err = doc.Operation("Third")
+	p.EqualError(err, `operation "Third" not found`)
+}
+
+func TestParser(t *testing.T) {
+	suite.Run(t, new(parserTestSuite))
+}
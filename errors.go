@@ -0,0 +1,77 @@
+package secretservice
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Codes of typed errors, exposed to API clients as the "code" extension of
+// GraphQL errors.
+const (
+	CodeAlreadyExists = "ALREADY_EXISTS"
+	CodeConflict      = "CONFLICT"
+	CodeForbidden     = "FORBIDDEN"
+	CodeInternal      = "INTERNAL"
+	CodeNotFound      = "NOT_FOUND"
+	CodeUnavailable   = "UNAVAILABLE"
+	CodeValidation    = "VALIDATION"
+)
+
+// Error is a domain error whose message is safe to show to API clients.
+// Errors of any other type are treated as internal ones.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(code, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// AlreadyExists returns an error about a resource which can not be created
+// because it exists already.
+func AlreadyExists(format string, args ...interface{}) error {
+	return newError(CodeAlreadyExists, format, args...)
+}
+
+// Conflict returns an error about a request which conflicts with the current
+// state of a resource.
+func Conflict(format string, args ...interface{}) error {
+	return newError(CodeConflict, format, args...)
+}
+
+// Forbidden returns an error about a request the caller is not allowed to
+// make.
+func Forbidden(format string, args ...interface{}) error {
+	return newError(CodeForbidden, format, args...)
+}
+
+// NotFound returns an error about a resource which does not exist.
+func NotFound(format string, args ...interface{}) error {
+	return newError(CodeNotFound, format, args...)
+}
+
+// Unavailable returns an error about a temporary failure. Requests failing
+// with it can be retried.
+func Unavailable(format string, args ...interface{}) error {
+	return newError(CodeUnavailable, format, args...)
+}
+
+// Validation returns an error about invalid input.
+func Validation(format string, args ...interface{}) error {
+	return newError(CodeValidation, format, args...)
+}
+
+// ErrorCode returns the code of the typed error at the root of a chain of
+// wrapped errors, or CodeInternal if the root error is not typed.
+func ErrorCode(err error) string {
+	if typed, ok := errors.Cause(err).(*Error); ok {
+		return typed.Code
+	}
+	return CodeInternal
+}
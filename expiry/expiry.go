@@ -0,0 +1,90 @@
+// Package expiry implements a scheduled check which notifies about Variables
+// that have expired or are due for rotation.
+package expiry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+)
+
+// Checker scans all Scopes for Variables which need attention.
+type Checker struct {
+	backend  secretservice.Backend
+	notifier secretservice.Notifier
+	within   time.Duration
+}
+
+// New returns an instance of a Checker. Variables which expire or are due for
+// rotation within the given window trigger a notification.
+func New(backend secretservice.Backend, notifier secretservice.Notifier, within time.Duration) *Checker {
+	return &Checker{backend: backend, notifier: notifier, within: within}
+}
+
+// Handle serves as a Lambda handler for scheduled CloudWatch events.
+func (c *Checker) Handle(ctx context.Context, event events.CloudWatchEvent) error {
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return c.Check(ctx, now)
+}
+
+// Check sends a notification for every Variable in every Scope which has
+// expired or will expire or be due for rotation before now + window.
+func (c *Checker) Check(ctx context.Context, now time.Time) error {
+	scopes, err := c.backend.ListVariables(ctx, "scopes")
+	if err != nil {
+		return errors.Wrap(err, "could not list scopes")
+	}
+
+	for _, scope := range scopes {
+		if err := c.checkScope(ctx, scope.Name, now); err != nil {
+			return errors.Wrapf(err, "could not check scope %q", scope.Name)
+		}
+	}
+
+	return nil
+}
+
+func (c *Checker) checkScope(ctx context.Context, scopeName string, now time.Time) error {
+	variables, err := c.backend.ListVariables(ctx, fmt.Sprintf("workspace/%s", scopeName))
+	if err != nil {
+		return errors.Wrap(err, "could not list variables")
+	}
+
+	metadata, err := c.backend.ListMetadata(ctx, scopeName)
+	if err != nil {
+		return errors.Wrap(err, "could not list variable metadata")
+	}
+
+	cutoff := now.Add(c.within)
+
+	for _, variable := range variables {
+		meta, exists := metadata[variable.Name]
+		if !exists {
+			continue
+		}
+
+		deadline, ok := meta.Deadline()
+		if !ok || deadline.After(cutoff) {
+			continue
+		}
+
+		err := c.notifier.Notify(ctx, &secretservice.Notification{
+			ScopeName:    scopeName,
+			VariableName: variable.Name,
+			Deadline:     deadline,
+			Expired:      meta.Expired(now),
+		})
+		if err != nil {
+			return errors.Wrap(err, "could not send notification")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var now = time.Date(2018, 11, 10, 12, 0, 0, 0, time.UTC)
+
+type checkerTestSuite struct {
+	suite.Suite
+
+	backend  *mockBackend
+	ctx      context.Context
+	notifier *mockNotifier
+
+	sut *Checker
+}
+
+func (c *checkerTestSuite) SetupTest() {
+	c.backend = new(mockBackend)
+	c.ctx = context.Background()
+	c.notifier = new(mockNotifier)
+	c.sut = New(c.backend, c.notifier, 24*time.Hour)
+}
+
+func (c *checkerTestSuite) TestCheck_OK() {
+	expired := now.Add(-time.Hour)
+	soon := now.Add(time.Hour)
+	later := now.Add(48 * time.Hour)
+	rotateEvery := 30 * 24 * time.Hour
+
+	c.withScopes(nil)
+	c.withWorkspace(nil, "EXPIRED", "SOON", "LATER", "ROTATE", "NO_METADATA")
+	c.withMetadata(nil, map[string]*secretservice.Metadata{
+		"EXPIRED": {ExpiresAt: &expired},
+		"SOON":    {ExpiresAt: &soon},
+		"LATER":   {ExpiresAt: &later},
+		"ROTATE":  {RotateEvery: &rotateEvery, UpdatedAt: now.Add(-rotateEvery)},
+		"REMOVED": {ExpiresAt: &expired},
+	})
+
+	var notified []*secretservice.Notification
+	c.notifier.On("Notify", c.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		notified = append(notified, args.Get(1).(*secretservice.Notification))
+	}).Return(nil)
+
+	c.NoError(c.sut.Handle(c.ctx, events.CloudWatchEvent{Time: now}))
+
+	c.Len(notified, 3)
+
+	c.Equal("scopeName", notified[0].ScopeName)
+	c.Equal("EXPIRED", notified[0].VariableName)
+	c.True(notified[0].Expired)
+
+	c.Equal("SOON", notified[1].VariableName)
+	c.False(notified[1].Expired)
+
+	c.Equal("ROTATE", notified[2].VariableName)
+	c.Equal(now, notified[2].Deadline)
+}
+
+func (c *checkerTestSuite) TestCheck_ScopesFailure() {
+	c.withScopes(errors.New("bacon"))
+
+	c.EqualError(c.sut.Check(c.ctx, now), "could not list scopes: bacon")
+}
+
+func (c *checkerTestSuite) TestCheck_WorkspaceFailure() {
+	c.withScopes(nil)
+	c.withWorkspace(errors.New("bacon"))
+
+	c.EqualError(
+		c.sut.Check(c.ctx, now),
+		`could not check scope "scopeName": could not list variables: bacon`,
+	)
+}
+
+func (c *checkerTestSuite) TestCheck_MetadataFailure() {
+	c.withScopes(nil)
+	c.withWorkspace(nil, "EXPIRED")
+	c.withMetadata(errors.New("bacon"), nil)
+
+	c.EqualError(
+		c.sut.Check(c.ctx, now),
+		`could not check scope "scopeName": could not list variable metadata: bacon`,
+	)
+}
+
+func (c *checkerTestSuite) TestCheck_NotifyFailure() {
+	expired := now.Add(-time.Hour)
+
+	c.withScopes(nil)
+	c.withWorkspace(nil, "EXPIRED")
+	c.withMetadata(nil, map[string]*secretservice.Metadata{"EXPIRED": {ExpiresAt: &expired}})
+	c.notifier.On("Notify", c.ctx, mock.Anything).Return(errors.New("bacon"))
+
+	c.EqualError(
+		c.sut.Check(c.ctx, now),
+		`could not check scope "scopeName": could not send notification: bacon`,
+	)
+}
+
+func (c *checkerTestSuite) withMetadata(err error, metadata map[string]*secretservice.Metadata) {
+	c.backend.On("ListMetadata", c.ctx, "scopeName").Return(metadata, err)
+}
+
+func (c *checkerTestSuite) withScopes(err error) {
+	c.backend.
+		On("ListVariables", c.ctx, "scopes").
+		Return([]*ssmvars.Variable{{Name: "scopeName"}}, err)
+}
+
+func (c *checkerTestSuite) withWorkspace(err error, names ...string) {
+	variables := make([]*ssmvars.Variable, len(names))
+	for index, name := range names {
+		variables[index] = &ssmvars.Variable{Name: name}
+	}
+	c.backend.On("ListVariables", c.ctx, "workspace/scopeName").Return(variables, err)
+}
+
+func TestChecker(t *testing.T) {
+	suite.Run(t, new(checkerTestSuite))
+}
@@ -0,0 +1,32 @@
+package expiry
+
+import (
+	"context"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockBackend struct {
+	mock.Mock
+	secretservice.Backend
+}
+
+func (m *mockBackend) ListMetadata(ctx context.Context, scopeName string) (map[string]*secretservice.Metadata, error) {
+	args := m.Called(ctx, scopeName)
+	return args.Get(0).(map[string]*secretservice.Metadata), args.Error(1)
+}
+
+func (m *mockBackend) ListVariables(ctx context.Context, namespace string) ([]*ssmvars.Variable, error) {
+	args := m.Called(ctx, namespace)
+	return args.Get(0).([]*ssmvars.Variable), args.Error(1)
+}
+
+type mockNotifier struct {
+	mock.Mock
+}
+
+func (m *mockNotifier) Notify(ctx context.Context, notification *secretservice.Notification) error {
+	return m.Called(ctx, notification).Error(0)
+}
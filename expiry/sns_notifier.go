@@ -0,0 +1,45 @@
+package expiry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+)
+
+// SNSNotifier publishes notifications as JSON messages to an SNS topic.
+type SNSNotifier struct {
+	sns      snsiface.SNSAPI
+	topicARN *string
+}
+
+// NewSNSNotifier returns an instance of an SNSNotifier.
+func NewSNSNotifier(sns snsiface.SNSAPI, topicARN string) *SNSNotifier {
+	return &SNSNotifier{sns: sns, topicARN: aws.String(topicARN)}
+}
+
+// Notify publishes a single notification.
+func (s *SNSNotifier) Notify(ctx context.Context, notification *secretservice.Notification) error {
+	message, err := json.Marshal(notification)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal notification")
+	}
+
+	subject := fmt.Sprintf("Variable %s/%s is due for rotation", notification.ScopeName, notification.VariableName)
+	if notification.Expired {
+		subject = fmt.Sprintf("Variable %s/%s has expired", notification.ScopeName, notification.VariableName)
+	}
+
+	_, err = s.sns.PublishWithContext(ctx, &sns.PublishInput{
+		Message:  aws.String(string(message)),
+		Subject:  aws.String(subject),
+		TopicArn: s.topicARN,
+	})
+
+	return errors.Wrap(err, "could not publish to SNS")
+}
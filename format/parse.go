@@ -0,0 +1,224 @@
+// Package format converts sets of Variables to and from common configuration
+// file formats.
+package format
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Formats supported by the package. The names match the values of the
+// corresponding GraphQL enums.
+const (
+	DotEnv = "DOTENV"
+	JSON   = "JSON"
+	YAML   = "YAML"
+)
+
+// Parse reads a flat document in a given format and returns the list of
+// Variables it defines, in document order. Nested structures and duplicate
+// keys are rejected.
+func Parse(format, document string) ([]*ssmvars.Variable, error) {
+	var ret []*ssmvars.Variable
+	var err error
+
+	switch format {
+	case DotEnv:
+		ret, err = parseDotEnv(document)
+	case JSON:
+		ret, err = parseJSON(document)
+	case YAML:
+		ret, err = parseYAML(document)
+	default:
+		return nil, errors.Errorf("unsupported format %q", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(ret))
+	for _, variable := range ret {
+		if seen[variable.Name] {
+			return nil, errors.Errorf("duplicate variable %q", variable.Name)
+		}
+		seen[variable.Name] = true
+	}
+
+	return ret, nil
+}
+
+func parseDotEnv(document string) ([]*ssmvars.Variable, error) {
+	var ret []*ssmvars.Variable
+
+	scanner := bufio.NewScanner(strings.NewReader(document))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		separator := strings.Index(line, "=")
+		if separator < 0 {
+			return nil, errors.Errorf("line %d: missing '='", lineNo)
+		}
+
+		value, err := parseDotEnvValue(strings.TrimSpace(line[separator+1:]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNo)
+		}
+
+		ret = append(ret, &ssmvars.Variable{
+			Name:  strings.TrimSpace(line[:separator]),
+			Value: value,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read document")
+	}
+
+	return ret, nil
+}
+
+func parseDotEnvValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		end := closingQuote(raw)
+		if end < 0 {
+			return "", errors.New("unterminated double-quoted value")
+		}
+		return unescapeDoubleQuoted(raw[1:end]), nil
+	case strings.HasPrefix(raw, "'"):
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", errors.New("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+	default:
+		if comment := strings.Index(raw, " #"); comment >= 0 {
+			raw = raw[:comment]
+		}
+		return strings.TrimSpace(raw), nil
+	}
+}
+
+// closingQuote returns the index of the first unescaped double quote after
+// the opening one, or -1 if there is none.
+func closingQuote(raw string) int {
+	for i := 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDoubleQuoted(value string) string {
+	var ret strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			ret.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 'n':
+			ret.WriteByte('\n')
+		case 'r':
+			ret.WriteByte('\r')
+		case 't':
+			ret.WriteByte('\t')
+		default:
+			ret.WriteByte(value[i])
+		}
+	}
+
+	return ret.String()
+}
+
+func parseJSON(document string) ([]*ssmvars.Variable, error) {
+	decoder := json.NewDecoder(strings.NewReader(document))
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse JSON")
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, errors.New("JSON document must be an object")
+	}
+
+	var ret []*ssmvars.Variable
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse JSON")
+		}
+		name := token.(string)
+
+		var raw interface{}
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, errors.Wrap(err, "could not parse JSON")
+		}
+
+		value, err := scalarString(name, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, &ssmvars.Variable{Name: name, Value: value})
+	}
+
+	return ret, nil
+}
+
+func parseYAML(document string) ([]*ssmvars.Variable, error) {
+	var items yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(document), &items); err != nil {
+		return nil, errors.Wrap(err, "could not parse YAML")
+	}
+
+	ret := make([]*ssmvars.Variable, 0, len(items))
+	for _, item := range items {
+		name, ok := item.Key.(string)
+		if !ok {
+			return nil, errors.Errorf("key %v is not a string", item.Key)
+		}
+
+		value, err := scalarString(name, item.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, &ssmvars.Variable{Name: name, Value: value})
+	}
+
+	return ret, nil
+}
+
+func scalarString(name string, value interface{}) (string, error) {
+	switch typed := value.(type) {
+	case string:
+		return typed, nil
+	case nil:
+		return "", nil
+	case bool, int, int64, uint64, float64, json.Number:
+		return fmt.Sprint(typed), nil
+	default:
+		return "", errors.Errorf("value of %q is not a scalar", name)
+	}
+}
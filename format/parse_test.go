@@ -0,0 +1,110 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/suite"
+)
+
+type parseTestSuite struct {
+	suite.Suite
+}
+
+func (p *parseTestSuite) TestDotEnv_OK() {
+	ret, err := Parse(DotEnv, `
+# Comment
+PLAIN=value
+export EXPORTED=exported
+SPACED = spaced value # trailing comment
+DOUBLE="line\nbreak \"quoted\" # not a comment"
+SINGLE='literal\n'
+EMPTY=
+`)
+
+	p.NoError(err)
+	p.Equal([]*ssmvars.Variable{
+		{Name: "PLAIN", Value: "value"},
+		{Name: "EXPORTED", Value: "exported"},
+		{Name: "SPACED", Value: "spaced value"},
+		{Name: "DOUBLE", Value: "line\nbreak \"quoted\" # not a comment"},
+		{Name: "SINGLE", Value: `literal\n`},
+		{Name: "EMPTY", Value: ""},
+	}, ret)
+}
+
+func (p *parseTestSuite) TestDotEnv_MissingSeparator() {
+	ret, err := Parse(DotEnv, "A=b\nBACON")
+
+	p.Nil(ret)
+	p.EqualError(err, "line 2: missing '='")
+}
+
+func (p *parseTestSuite) TestDotEnv_Unterminated() {
+	ret, err := Parse(DotEnv, `A="bacon`)
+
+	p.Nil(ret)
+	p.EqualError(err, "line 1: unterminated double-quoted value")
+}
+
+func (p *parseTestSuite) TestJSON_OK() {
+	ret, err := Parse(JSON, `{"B": "bacon", "A": 1.50, "C": true, "D": null}`)
+
+	p.NoError(err)
+	p.Equal([]*ssmvars.Variable{
+		{Name: "B", Value: "bacon"},
+		{Name: "A", Value: "1.50"},
+		{Name: "C", Value: "true"},
+		{Name: "D", Value: ""},
+	}, ret)
+}
+
+func (p *parseTestSuite) TestJSON_NotObject() {
+	ret, err := Parse(JSON, `["bacon"]`)
+
+	p.Nil(ret)
+	p.EqualError(err, "JSON document must be an object")
+}
+
+func (p *parseTestSuite) TestJSON_Nested() {
+	ret, err := Parse(JSON, `{"A": {"B": "bacon"}}`)
+
+	p.Nil(ret)
+	p.EqualError(err, `value of "A" is not a scalar`)
+}
+
+func (p *parseTestSuite) TestYAML_OK() {
+	ret, err := Parse(YAML, "B: bacon\nA: 1\nC: 'quoted: value'\n")
+
+	p.NoError(err)
+	p.Equal([]*ssmvars.Variable{
+		{Name: "B", Value: "bacon"},
+		{Name: "A", Value: "1"},
+		{Name: "C", Value: "quoted: value"},
+	}, ret)
+}
+
+func (p *parseTestSuite) TestYAML_Nested() {
+	ret, err := Parse(YAML, "A:\n  - bacon\n")
+
+	p.Nil(ret)
+	p.EqualError(err, `value of "A" is not a scalar`)
+}
+
+func (p *parseTestSuite) TestDuplicate() {
+	ret, err := Parse(DotEnv, "A=1\nA=2\n")
+
+	p.Nil(ret)
+	p.EqualError(err, `duplicate variable "A"`)
+}
+
+func (p *parseTestSuite) TestUnsupportedFormat() {
+	ret, err := Parse("BACON", "")
+
+	p.Nil(ret)
+	p.EqualError(err, `unsupported format "BACON"`)
+}
+
+func TestParse(t *testing.T) {
+	suite.Run(t, new(parseTestSuite))
+}
@@ -0,0 +1,231 @@
+package format
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/pkg/errors"
+)
+
+// Output-only formats supported by Render, in addition to DotEnv, JSON and
+// YAML.
+const (
+	TOML           = "TOML"
+	ShellExport    = "SHELL_EXPORT"
+	JavaProperties = "JAVA_PROPERTIES"
+	K8sSecret      = "K8S_SECRET"
+	SystemdEnv     = "SYSTEMD_ENV"
+)
+
+var (
+	bareTOMLKey    = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	invalidK8sName = regexp.MustCompile(`[^a-z0-9.-]+`)
+)
+
+// Options control rendering.
+type Options struct {
+	// IncludeWriteOnly includes values of write-only Variables in the output.
+	// Otherwise write-only Variables are skipped.
+	IncludeWriteOnly bool
+
+	// Name is used as the name of generated Kubernetes Secrets.
+	Name string
+}
+
+// Render serializes a set of Variables into a given format, sorted by name.
+// It is meant to be used both on the server side and by API clients which
+// retrieve Variables directly.
+func Render(format string, variables []*ssmvars.Variable, opts Options) (string, error) {
+	var selected []*ssmvars.Variable
+	for _, variable := range variables {
+		if variable.WriteOnly && !opts.IncludeWriteOnly {
+			continue
+		}
+		selected = append(selected, variable)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Name < selected[j].Name
+	})
+
+	switch format {
+	case DotEnv:
+		return renderLines(selected, func(name, value string) string {
+			return fmt.Sprintf("%s=%s", name, doubleQuote(value, true))
+		}), nil
+	case JSON:
+		return renderJSON(selected)
+	case YAML:
+		return renderLines(selected, func(name, value string) string {
+			return fmt.Sprintf("%s: %s", jsonString(name), jsonString(value))
+		}), nil
+	case TOML:
+		return renderLines(selected, func(name, value string) string {
+			key := name
+			if !bareTOMLKey.MatchString(name) {
+				key = jsonString(name)
+			}
+			return fmt.Sprintf("%s = %s", key, jsonString(value))
+		}), nil
+	case ShellExport:
+		return renderLines(selected, func(name, value string) string {
+			return fmt.Sprintf("export %s=%s", name, singleQuote(value))
+		}), nil
+	case JavaProperties:
+		return renderLines(selected, func(name, value string) string {
+			return fmt.Sprintf("%s=%s", escapeProperty(name, true), escapeProperty(value, false))
+		}), nil
+	case K8sSecret:
+		return renderK8sSecret(selected, opts.Name), nil
+	case SystemdEnv:
+		return renderLines(selected, func(name, value string) string {
+			return fmt.Sprintf("%s=%s", name, doubleQuote(value, false))
+		}), nil
+	default:
+		return "", errors.Errorf("unsupported format %q", format)
+	}
+}
+
+func renderLines(variables []*ssmvars.Variable, line func(name, value string) string) string {
+	var ret strings.Builder
+	for _, variable := range variables {
+		ret.WriteString(line(variable.Name, variable.Value))
+		ret.WriteByte('\n')
+	}
+	return ret.String()
+}
+
+func renderJSON(variables []*ssmvars.Variable) (string, error) {
+	object := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		object[variable.Name] = variable.Value
+	}
+
+	var ret bytes.Buffer
+	encoder := json.NewEncoder(&ret)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(object); err != nil {
+		return "", errors.Wrap(err, "could not marshal JSON")
+	}
+
+	return ret.String(), nil
+}
+
+func renderK8sSecret(variables []*ssmvars.Variable, name string) string {
+	name = strings.Trim(invalidK8sName.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if name == "" {
+		name = "secretservice"
+	}
+
+	var ret strings.Builder
+	ret.WriteString("apiVersion: v1\n")
+	ret.WriteString("kind: Secret\n")
+	ret.WriteString("metadata:\n")
+	fmt.Fprintf(&ret, "  name: %s\n", jsonString(name))
+	ret.WriteString("type: Opaque\n")
+
+	if len(variables) == 0 {
+		ret.WriteString("data: {}\n")
+		return ret.String()
+	}
+
+	ret.WriteString("data:\n")
+	for _, variable := range variables {
+		fmt.Fprintf(
+			&ret,
+			"  %s: %s\n",
+			jsonString(variable.Name),
+			base64.StdEncoding.EncodeToString([]byte(variable.Value)),
+		)
+	}
+
+	return ret.String()
+}
+
+// jsonString returns a JSON string literal, which is also a valid YAML
+// double-quoted scalar and a valid TOML basic string.
+func jsonString(value string) string {
+	var ret bytes.Buffer
+	encoder := json.NewEncoder(&ret)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(ret.String(), "\n")
+}
+
+// doubleQuote quotes the value for dotenv files and systemd environment files.
+// Dotenv parsers expand C-style escapes, while systemd only supports escaping
+// quotes, backslashes and dollar signs and keeps newlines verbatim.
+func doubleQuote(value string, escapeControl bool) string {
+	var ret strings.Builder
+	ret.WriteByte('"')
+
+	for _, char := range value {
+		switch {
+		case char == '"' || char == '\\' || char == '$' || char == '`':
+			ret.WriteByte('\\')
+			ret.WriteRune(char)
+		case escapeControl && char == '\n':
+			ret.WriteString(`\n`)
+		case escapeControl && char == '\r':
+			ret.WriteString(`\r`)
+		case escapeControl && char == '\t':
+			ret.WriteString(`\t`)
+		default:
+			ret.WriteRune(char)
+		}
+	}
+
+	ret.WriteByte('"')
+	return ret.String()
+}
+
+// singleQuote quotes the value for POSIX shells.
+func singleQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+// escapeProperty escapes a key or a value in a Java properties file, which is
+// read using the ISO 8859-1 encoding.
+func escapeProperty(value string, isKey bool) string {
+	var ret strings.Builder
+
+	for index, char := range value {
+		switch {
+		case char == '\\':
+			ret.WriteString(`\\`)
+		case char == '\n':
+			ret.WriteString(`\n`)
+		case char == '\r':
+			ret.WriteString(`\r`)
+		case char == '\t':
+			ret.WriteString(`\t`)
+		case char == '\f':
+			ret.WriteString(`\f`)
+		case char == ' ' && (isKey || index == 0):
+			ret.WriteString(`\ `)
+		case strings.ContainsRune("=:#!", char):
+			ret.WriteByte('\\')
+			ret.WriteRune(char)
+		case char < 0x20 || char > 0x7e:
+			if char >= 0x10000 {
+				high, low := utf16.EncodeRune(char)
+				fmt.Fprintf(&ret, `\u%04x\u%04x`, high, low)
+			} else {
+				fmt.Fprintf(&ret, `\u%04x`, char)
+			}
+		default:
+			ret.WriteRune(char)
+		}
+	}
+
+	return ret.String()
+}
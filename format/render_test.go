@@ -0,0 +1,115 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/suite"
+)
+
+type renderTestSuite struct {
+	suite.Suite
+
+	variables []*ssmvars.Variable
+}
+
+func (r *renderTestSuite) SetupTest() {
+	r.variables = []*ssmvars.Variable{
+		{Name: "TRICKY", Value: "it's \"$HOME\"\n\\ok=€"},
+		{Name: "PLAIN", Value: "bacon"},
+		{Name: "SECRET", Value: "hidden", WriteOnly: true},
+	}
+}
+
+func (r *renderTestSuite) TestDotEnv() {
+	r.render(DotEnv, "PLAIN=\"bacon\"\nTRICKY=\"it's \\\"\\$HOME\\\"\\n\\\\ok=€\"\n")
+}
+
+func (r *renderTestSuite) TestDotEnv_RoundTrip() {
+	ret, err := Render(DotEnv, r.variables, Options{IncludeWriteOnly: true})
+	r.NoError(err)
+
+	parsed, err := Parse(DotEnv, ret)
+	r.NoError(err)
+	r.Len(parsed, 3)
+	r.Equal(r.variables[1].Value, parsed[0].Value)
+	r.Equal(r.variables[2].Value, parsed[1].Value)
+	r.Equal(r.variables[0].Value, parsed[2].Value)
+}
+
+func (r *renderTestSuite) TestJSON() {
+	r.render(JSON, "{\n  \"PLAIN\": \"bacon\",\n  \"TRICKY\": \"it's \\\"$HOME\\\"\\n\\\\ok=€\"\n}\n")
+}
+
+func (r *renderTestSuite) TestYAML_RoundTrip() {
+	ret, err := Render(YAML, r.variables, Options{})
+	r.NoError(err)
+
+	parsed, err := Parse(YAML, ret)
+	r.NoError(err)
+	r.Equal([]*ssmvars.Variable{r.variables[1], r.variables[0]}, parsed)
+}
+
+func (r *renderTestSuite) TestTOML() {
+	r.render(TOML, "PLAIN = \"bacon\"\nTRICKY = \"it's \\\"$HOME\\\"\\n\\\\ok=€\"\n")
+}
+
+func (r *renderTestSuite) TestShellExport() {
+	r.render(ShellExport, "export PLAIN='bacon'\nexport TRICKY='it'\\''s \"$HOME\"\n\\ok=€'\n")
+}
+
+func (r *renderTestSuite) TestJavaProperties() {
+	r.render(JavaProperties, "PLAIN=bacon\nTRICKY=it's \"$HOME\"\\n\\\\ok\\=\\u20ac\n")
+}
+
+func (r *renderTestSuite) TestK8sSecret() {
+	ret, err := Render(K8sSecret, r.variables, Options{Name: "My_Scope"})
+
+	r.NoError(err)
+	r.Equal(`apiVersion: v1
+kind: Secret
+metadata:
+  name: "my-scope"
+type: Opaque
+data:
+  "PLAIN": YmFjb24=
+  "TRICKY": aXQncyAiJEhPTUUiClxvaz3igqw=
+`, ret)
+}
+
+func (r *renderTestSuite) TestK8sSecret_Empty() {
+	ret, err := Render(K8sSecret, nil, Options{})
+
+	r.NoError(err)
+	r.Contains(ret, "name: \"secretservice\"\n")
+	r.Contains(ret, "data: {}\n")
+}
+
+func (r *renderTestSuite) TestSystemdEnv() {
+	r.render(SystemdEnv, "PLAIN=\"bacon\"\nTRICKY=\"it's \\\"\\$HOME\\\"\n\\\\ok=€\"\n")
+}
+
+func (r *renderTestSuite) TestIncludeWriteOnly() {
+	ret, err := Render(DotEnv, r.variables, Options{IncludeWriteOnly: true})
+
+	r.NoError(err)
+	r.Contains(ret, "SECRET=\"hidden\"\n")
+}
+
+func (r *renderTestSuite) TestUnsupportedFormat() {
+	ret, err := Render("BACON", r.variables, Options{})
+
+	r.Empty(ret)
+	r.EqualError(err, `unsupported format "BACON"`)
+}
+
+func (r *renderTestSuite) render(format, expected string) {
+	ret, err := Render(format, r.variables, Options{})
+
+	r.NoError(err)
+	r.Equal(expected, ret)
+}
+
+func TestRender(t *testing.T) {
+	suite.Run(t, new(renderTestSuite))
+}
@@ -0,0 +1,162 @@
+// Package generator produces random secret values using a cryptographically
+// secure source of entropy.
+package generator
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of secrets supported by the generator. The names match the values of
+// the SecretKind GraphQL enum.
+const (
+	KindPassword   = "PASSWORD"
+	KindHex        = "HEX"
+	KindBase64     = "BASE64"
+	KindUUID       = "UUID"
+	KindRSAKey     = "RSA_KEY"
+	KindED25519Key = "ED25519_KEY"
+)
+
+const (
+	defaultCharset        = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#%+-.:=@^_~"
+	defaultPasswordLength = 32
+	defaultBytesLength    = 32
+	defaultRSABits        = 4096
+	maxLength             = 4096
+	minRSABits            = 2048
+)
+
+var defaultEntropySource = rand.Reader
+
+// Spec describes the secret to generate. Length is the number of characters
+// for passwords, the number of random bytes for HEX and BASE64 secrets and the
+// key size in bits for RSA keys. It is ignored for UUIDs and ED25519 keys.
+// Charset is only used for passwords. Zero values select sensible defaults.
+type Spec struct {
+	Kind    string
+	Length  int
+	Charset string
+}
+
+// Generate returns a new random value matching the spec.
+func Generate(spec Spec) (string, error) {
+	return generate(defaultEntropySource, spec)
+}
+
+func generate(entropy io.Reader, spec Spec) (string, error) {
+	if spec.Length < 0 || spec.Length > maxLength {
+		return "", errors.Errorf("length must be between 0 and %d", maxLength)
+	}
+
+	switch spec.Kind {
+	case KindPassword:
+		return password(entropy, withDefault(spec.Length, defaultPasswordLength), spec.Charset)
+	case KindHex:
+		data, err := randomBytes(entropy, withDefault(spec.Length, defaultBytesLength))
+		return hex.EncodeToString(data), err
+	case KindBase64:
+		data, err := randomBytes(entropy, withDefault(spec.Length, defaultBytesLength))
+		return base64.StdEncoding.EncodeToString(data), err
+	case KindUUID:
+		return uuid(entropy)
+	case KindRSAKey:
+		return rsaKey(entropy, withDefault(spec.Length, defaultRSABits))
+	case KindED25519Key:
+		return ed25519Key(entropy)
+	default:
+		return "", errors.Errorf("unsupported secret kind %q", spec.Kind)
+	}
+}
+
+func password(entropy io.Reader, length int, charset string) (string, error) {
+	if charset == "" {
+		charset = defaultCharset
+	}
+
+	alphabet := []rune(charset)
+	if len(alphabet) < 2 {
+		return "", errors.New("charset must contain at least two characters")
+	}
+
+	max := big.NewInt(int64(len(alphabet)))
+	ret := make([]rune, length)
+	for i := range ret {
+		index, err := rand.Int(entropy, max)
+		if err != nil {
+			return "", errors.Wrap(err, "could not read entropy")
+		}
+		ret[i] = alphabet[index.Int64()]
+	}
+
+	return string(ret), nil
+}
+
+func randomBytes(entropy io.Reader, length int) ([]byte, error) {
+	ret := make([]byte, length)
+	if _, err := io.ReadFull(entropy, ret); err != nil {
+		return nil, errors.Wrap(err, "could not read entropy")
+	}
+	return ret, nil
+}
+
+// uuid returns a random (version 4) UUID as defined in RFC 4122.
+func uuid(entropy io.Reader) (string, error) {
+	data, err := randomBytes(entropy, 16)
+	if err != nil {
+		return "", err
+	}
+
+	data[6] = (data[6] & 0x0f) | 0x40
+	data[8] = (data[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", data[0:4], data[4:6], data[6:8], data[8:10], data[10:]), nil
+}
+
+func rsaKey(entropy io.Reader, bits int) (string, error) {
+	if bits < minRSABits {
+		return "", errors.Errorf("RSA keys must be at least %d bits long", minRSABits)
+	}
+
+	key, err := rsa.GenerateKey(entropy, bits)
+	if err != nil {
+		return "", errors.Wrap(err, "could not generate RSA key")
+	}
+
+	return encodePrivateKey(key)
+}
+
+func ed25519Key(entropy io.Reader) (string, error) {
+	_, key, err := ed25519.GenerateKey(entropy)
+	if err != nil {
+		return "", errors.Wrap(err, "could not generate ED25519 key")
+	}
+
+	return encodePrivateKey(key)
+}
+
+func encodePrivateKey(key interface{}) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal private key")
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+func withDefault(value, fallback int) int {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,131 @@
+package generator
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("bacon")
+}
+
+type generatorTestSuite struct {
+	suite.Suite
+}
+
+func (g *generatorTestSuite) TestPassword_Default() {
+	ret, err := Generate(Spec{Kind: KindPassword})
+
+	g.NoError(err)
+	g.Len(ret, defaultPasswordLength)
+}
+
+func (g *generatorTestSuite) TestPassword_Charset() {
+	ret, err := Generate(Spec{Kind: KindPassword, Length: 64, Charset: "ab"})
+
+	g.NoError(err)
+	g.Regexp(regexp.MustCompile("^[ab]{64}$"), ret)
+}
+
+func (g *generatorTestSuite) TestPassword_CharsetTooShort() {
+	ret, err := Generate(Spec{Kind: KindPassword, Charset: "a"})
+
+	g.Empty(ret)
+	g.EqualError(err, "charset must contain at least two characters")
+}
+
+func (g *generatorTestSuite) TestPassword_EntropyFailure() {
+	ret, err := generate(failingReader{}, Spec{Kind: KindPassword})
+
+	g.Empty(ret)
+	g.EqualError(err, "could not read entropy: bacon")
+}
+
+func (g *generatorTestSuite) TestHex() {
+	ret, err := Generate(Spec{Kind: KindHex, Length: 16})
+	g.NoError(err)
+
+	data, err := hex.DecodeString(ret)
+	g.NoError(err)
+	g.Len(data, 16)
+}
+
+func (g *generatorTestSuite) TestBase64() {
+	ret, err := Generate(Spec{Kind: KindBase64})
+	g.NoError(err)
+
+	data, err := base64.StdEncoding.DecodeString(ret)
+	g.NoError(err)
+	g.Len(data, defaultBytesLength)
+}
+
+func (g *generatorTestSuite) TestUUID() {
+	ret, err := Generate(Spec{Kind: KindUUID})
+
+	g.NoError(err)
+	g.Regexp(regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"), ret)
+}
+
+func (g *generatorTestSuite) TestRSAKey() {
+	ret, err := Generate(Spec{Kind: KindRSAKey, Length: minRSABits})
+	g.NoError(err)
+
+	key, ok := g.parseKey(ret).(*rsa.PrivateKey)
+	g.True(ok)
+	g.Equal(minRSABits, key.N.BitLen())
+}
+
+func (g *generatorTestSuite) TestRSAKey_TooShort() {
+	ret, err := Generate(Spec{Kind: KindRSAKey, Length: 1024})
+
+	g.Empty(ret)
+	g.EqualError(err, "RSA keys must be at least 2048 bits long")
+}
+
+func (g *generatorTestSuite) TestED25519Key() {
+	ret, err := Generate(Spec{Kind: KindED25519Key})
+	g.NoError(err)
+
+	_, ok := g.parseKey(ret).(ed25519.PrivateKey)
+	g.True(ok)
+}
+
+func (g *generatorTestSuite) TestInvalidLength() {
+	ret, err := Generate(Spec{Kind: KindHex, Length: -1})
+
+	g.Empty(ret)
+	g.EqualError(err, "length must be between 0 and 4096")
+}
+
+func (g *generatorTestSuite) TestUnsupportedKind() {
+	ret, err := Generate(Spec{Kind: "BACON"})
+
+	g.Empty(ret)
+	g.EqualError(err, `unsupported secret kind "BACON"`)
+}
+
+func (g *generatorTestSuite) parseKey(encoded string) interface{} {
+	block, _ := pem.Decode([]byte(encoded))
+	g.Require().NotNil(block)
+	g.Equal("PRIVATE KEY", block.Type)
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	g.Require().NoError(err)
+
+	return key
+}
+
+func TestGenerator(t *testing.T) {
+	suite.Run(t, new(generatorTestSuite))
+}
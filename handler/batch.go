@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
+)
+
+const (
+	// maxBatchSize is the maximum number of operations in a single batch.
+	maxBatchSize = 20
+
+	// maxBatchConcurrency is the maximum number of operations from a single
+	// batch executed at any given time.
+	maxBatchConcurrency = 4
+)
+
+// handleBatch executes a JSON array of operations, returning a JSON array of
+// their responses in the same order. Queries run concurrently, but everything
+// else runs on its own and in order, so that it sees the effects of all the
+// operations before it, and the operations after it see its effects.
+func (h *Handler) handleBatch(ctx context.Context, input string) ([]byte, error) {
+	var reqs []*graphQLRequest
+
+	if err := json.NewDecoder(strings.NewReader(input)).Decode(&reqs); err != nil {
+		return nil, secretservice.Validation("could not unmarshal request: %v", err)
+	}
+
+	if len(reqs) == 0 {
+		return nil, secretservice.Validation("batch must contain at least one operation")
+	}
+
+	if len(reqs) > maxBatchSize {
+		return nil, secretservice.Validation("batch can contain at most %d operations, got %d", maxBatchSize, len(reqs))
+	}
+
+	for i, req := range reqs {
+		if req == nil {
+			return nil, secretservice.Validation("operation %d of the batch is null", i)
+		}
+	}
+
+	if err := h.limitRate(ctx, reqs...); err != nil {
+		return nil, err
+	}
+
+	responses := make([]*graphql.Response, len(reqs))
+	semaphore := make(chan struct{}, maxBatchConcurrency)
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		if !h.readOnly(req) {
+			wg.Wait()
+			responses[i] = h.execute(batchContext(ctx, i), req)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, req *graphQLRequest) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			responses[i] = h.execute(batchContext(ctx, i), req)
+		}(i, req)
+	}
+	wg.Wait()
+
+	return marshalResponse(responses)
+}
+
+// batchContext derives the context of an operation in a batch, so that each
+// mutation in the batch gets a different idempotency key.
+func batchContext(ctx context.Context, index int) context.Context {
+	if key := idempotencyKeyFromContext(ctx); key != "" {
+		return withIdempotencyKey(ctx, fmt.Sprintf("%s/%d", key, index))
+	}
+	return ctx
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func (h *handlerTestSuite) TestBatch_OK() {
+	ret := h.batch(`[
+		{"query": "{ hello }"},
+		{"query": "{ forbidden }"},
+		{"query": "{ whoami }"}
+	]`)
+
+	h.Equal(http.StatusOK, ret.StatusCode)
+	h.JSONEq(`[
+		{
+			"data": {"hello": "bacon"}
+		},
+		{
+			"data": {"forbidden": null},
+			"errors": [{
+				"message": "could not say hello: not for you",
+				"path": ["forbidden"],
+				"extensions": {"code": "FORBIDDEN"}
+			}]
+		},
+		{
+			"data": {"whoami": null}
+		}
+	]`, ret.Body)
+}
+
+func (h *handlerTestSuite) TestBatch_MutationsInOrder() {
+	operations := make([]string, maxBatchSize)
+	expected := make([]string, maxBatchSize)
+	for i := range operations {
+		operations[i] = `{"query": "mutation { increment(by: 1) }"}`
+		expected[i] = fmt.Sprintf(`{"data":{"increment":%d}}`, i+1)
+	}
+
+	ret := h.batch("[" + strings.Join(operations, ",") + "]")
+
+	h.Equal(http.StatusOK, ret.StatusCode)
+	h.JSONEq("["+strings.Join(expected, ",")+"]", ret.Body)
+}
+
+func (h *handlerTestSuite) TestBatch_Empty() {
+	ret := h.batch(`[]`)
+
+	h.Equal(http.StatusBadRequest, ret.StatusCode)
+	h.Equal("batch must contain at least one operation", ret.Body)
+}
+
+func (h *handlerTestSuite) TestBatch_TooLarge() {
+	operations := make([]string, maxBatchSize+1)
+	for i := range operations {
+		operations[i] = `{"query": "{ hello }"}`
+	}
+
+	ret := h.batch("[" + strings.Join(operations, ",") + "]")
+
+	h.Equal(http.StatusBadRequest, ret.StatusCode)
+	h.Equal(fmt.Sprintf("batch can contain at most %d operations, got %d", maxBatchSize, maxBatchSize+1), ret.Body)
+}
+
+func (h *handlerTestSuite) TestBatch_NullOperation() {
+	ret := h.batch(`[{"query": "{ hello }"}, null]`)
+
+	h.Equal(http.StatusBadRequest, ret.StatusCode)
+	h.Equal("operation 1 of the batch is null", ret.Body)
+}
+
+func (h *handlerTestSuite) batch(body string) events.APIGatewayProxyResponse {
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Body:       body,
+	})
+	h.Require().NoError(err)
+
+	return ret
+}
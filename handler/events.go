@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+)
+
+const payloadVersion2 = "2.0"
+
+// Handle serves as a main Lambda handler. It detects whether the event comes
+// from a REST API Gateway, an HTTP API Gateway, a Lambda function URL or an
+// Application Load Balancer, and responds in the format the source expects.
+func (h *Handler) Handle(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	var shape struct {
+		Version        string `json:"version"`
+		RequestContext struct {
+			ELB *json.RawMessage `json:"elb"`
+		} `json:"requestContext"`
+	}
+
+	if err := json.Unmarshal(payload, &shape); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal event")
+	}
+
+	switch {
+	case shape.RequestContext.ELB != nil:
+		var event events.ALBTargetGroupRequest
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal ALB event")
+		}
+		return h.HandleALB(ctx, event)
+	case shape.Version == payloadVersion2:
+		var event events.APIGatewayV2HTTPRequest
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal HTTP API event")
+		}
+		return h.HandleHTTPAPI(ctx, event)
+	default:
+		var event events.APIGatewayProxyRequest
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, errors.Wrap(err, "could not unmarshal API Gateway event")
+		}
+		return h.HandleAPIGateway(ctx, event)
+	}
+}
+
+// HandleAPIGateway translates REST API Gateway requests to GraphQL, and
+// GraphQL responses back to API Gateway ones. Malformed requests are
+// rejected with a 400 response without failing the invocation.
+func (h *Handler) HandleAPIGateway(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if principal := principalFromRequest(event.RequestContext); principal != nil {
+		ctx = secretservice.WithPrincipal(ctx, principal)
+	}
+
+	response, err := h.serve(ctx, httpRequest{
+		method:        event.HTTPMethod,
+		body:          event.Body,
+		base64Encoded: event.IsBase64Encoded,
+		query:         event.QueryStringParameters,
+
+		headers:        eventHeaders(event.Headers, event.MultiValueHeaders),
+		idempotencyKey: headerValue(event.Headers, event.MultiValueHeaders, idempotencyKeyHeader),
+	})
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: response.status,
+		Headers:    response.headers,
+		Body:       response.body,
+	}, err
+}
+
+// HandleHTTPAPI translates HTTP API Gateway requests to GraphQL. Lambda
+// function URLs use the same payload format, so it serves them as well.
+func (h *Handler) HandleHTTPAPI(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	if principal := principalFromAuthorizer(event.RequestContext.Authorizer); principal != nil {
+		ctx = secretservice.WithPrincipal(ctx, principal)
+	}
+
+	response, err := h.serve(ctx, httpRequest{
+		method:        event.RequestContext.HTTP.Method,
+		body:          event.Body,
+		base64Encoded: event.IsBase64Encoded,
+		query:         event.QueryStringParameters,
+
+		headers:        eventHeaders(event.Headers, nil),
+		idempotencyKey: headerValue(event.Headers, nil, idempotencyKeyHeader),
+	})
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: response.status,
+		Headers:    response.headers,
+		Body:       response.body,
+	}, err
+}
+
+// HandleALB translates Application Load Balancer requests to GraphQL. The
+// load balancer does not decode query string parameters, so they are decoded
+// here.
+func (h *Handler) HandleALB(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	if principal := h.principalFromHeaders(event.Headers, event.MultiValueHeaders); principal != nil {
+		ctx = secretservice.WithPrincipal(ctx, principal)
+	}
+
+	query, err := albQuery(event.QueryStringParameters, event.MultiValueQueryStringParameters)
+	if err != nil {
+		return albResponse(event, newErrorResponse(err)), nil
+	}
+
+	response, err := h.serve(ctx, httpRequest{
+		method:        event.HTTPMethod,
+		body:          event.Body,
+		base64Encoded: event.IsBase64Encoded,
+		query:         query,
+
+		headers:        eventHeaders(event.Headers, event.MultiValueHeaders),
+		idempotencyKey: headerValue(event.Headers, event.MultiValueHeaders, idempotencyKeyHeader),
+	})
+
+	return albResponse(event, response), err
+}
+
+// albQuery decodes query string parameters of an ALB request, taking the
+// first value of each one if the target group uses multi-value parameters.
+func albQuery(single map[string]string, multi map[string][]string) (map[string]string, error) {
+	ret := make(map[string]string, len(single)+len(multi))
+
+	for key, value := range single {
+		ret[key] = value
+	}
+	for key, values := range multi {
+		if len(values) > 0 {
+			ret[key] = values[0]
+		}
+	}
+
+	for key, value := range ret {
+		decoded, err := url.QueryUnescape(value)
+		if err != nil {
+			return nil, secretservice.Validation("could not decode query parameter %q: %v", key, err)
+		}
+		ret[key] = decoded
+	}
+
+	return ret, nil
+}
+
+// albResponse builds an ALB response, using multi-value headers if the
+// request used them, as the load balancer ignores the other kind.
+func albResponse(event events.ALBTargetGroupRequest, response httpResponse) events.ALBTargetGroupResponse {
+	ret := events.ALBTargetGroupResponse{
+		StatusCode:        response.status,
+		StatusDescription: fmt.Sprintf("%d %s", response.status, http.StatusText(response.status)),
+		Body:              response.body,
+	}
+
+	if event.MultiValueHeaders == nil {
+		ret.Headers = response.headers
+		return ret
+	}
+
+	ret.MultiValueHeaders = make(map[string][]string, len(response.headers))
+	for key, value := range response.headers {
+		ret.MultiValueHeaders[key] = []string{value}
+	}
+	return ret
+}
+
+func responseHeaders(status int) map[string]string {
+	if status == http.StatusOK {
+		return map[string]string{"Content-Type": "application/json"}
+	}
+	return map[string]string{"Content-Type": "text/plain; charset=utf-8"}
+}
+
+// principalFromRequest identifies the caller using the principal ID set by a
+// custom authorizer, or the ARN of the IAM user if IAM authorization is used.
+// Custom authorizers can grant roles as a comma-separated "roles" value.
+func principalFromRequest(request events.APIGatewayProxyRequestContext) *secretservice.Principal {
+	if id, ok := request.Authorizer["principalId"].(string); ok && id != "" {
+		return &secretservice.Principal{ID: id, Roles: rolesFromAuthorizer(request.Authorizer)}
+	}
+
+	if request.Identity.UserArn != "" {
+		return &secretservice.Principal{ID: request.Identity.UserArn}
+	}
+
+	return nil
+}
+
+// principalFromAuthorizer identifies the caller of an HTTP API or function
+// URL using, in order, the principal ID set by a Lambda authorizer, the
+// subject of a JWT, or the ARN of the IAM user. Lambda authorizers and JWTs
+// can grant roles as a comma-separated "roles" value.
+func principalFromAuthorizer(authorizer *events.APIGatewayV2HTTPRequestContextAuthorizerDescription) *secretservice.Principal {
+	if authorizer == nil {
+		return nil
+	}
+
+	if id, ok := authorizer.Lambda["principalId"].(string); ok && id != "" {
+		return &secretservice.Principal{ID: id, Roles: rolesFromAuthorizer(authorizer.Lambda)}
+	}
+
+	if authorizer.JWT != nil && authorizer.JWT.Claims["sub"] != "" {
+		return &secretservice.Principal{
+			ID:    authorizer.JWT.Claims["sub"],
+			Roles: splitRoles(authorizer.JWT.Claims["roles"]),
+		}
+	}
+
+	if authorizer.IAM != nil && authorizer.IAM.UserARN != "" {
+		return &secretservice.Principal{ID: authorizer.IAM.UserARN}
+	}
+
+	return nil
+}
+
+// principalFromHeaders identifies the caller of an ALB request using the
+// identity header, if the Handler is configured with one.
+func (h *Handler) principalFromHeaders(single map[string]string, multi map[string][]string) *secretservice.Principal {
+	if h.identityHeader == "" {
+		return nil
+	}
+
+	if id := headerValue(single, multi, h.identityHeader); id != "" {
+		return &secretservice.Principal{ID: id}
+	}
+
+	return nil
+}
+
+// headerValue returns the value of a header with a lowercase name, looking
+// for it in both single and multi-value headers of an event.
+func headerValue(single map[string]string, multi map[string][]string, name string) string {
+	for key, value := range single {
+		if strings.ToLower(key) == name && value != "" {
+			return value
+		}
+	}
+
+	for key, values := range multi {
+		if strings.ToLower(key) == name && len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+// eventHeaders merges single and multi-value headers of an event.
+func eventHeaders(single map[string]string, multi map[string][]string) http.Header {
+	ret := make(http.Header, len(single)+len(multi))
+
+	for key, value := range single {
+		ret.Set(key, value)
+	}
+	for key, values := range multi {
+		ret[http.CanonicalHeaderKey(key)] = values
+	}
+
+	return ret
+}
+
+func rolesFromAuthorizer(authorizer map[string]interface{}) []string {
+	value, _ := authorizer["roles"].(string)
+	return splitRoles(value)
+}
+
+func splitRoles(value string) []string {
+	var ret []string
+	for _, role := range strings.Split(value, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			ret = append(ret, role)
+		}
+	}
+	return ret
+}
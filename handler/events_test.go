@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/graph-gophers/graphql-go"
+)
+
+func (h *handlerTestSuite) TestHandle_APIGateway() {
+	ret, err := h.handle(`{
+		"httpMethod": "POST",
+		"body": "{\"query\":\"{ whoami }\"}",
+		"requestContext": {"authorizer": {"principalId": "bacon"}}
+	}`)
+
+	h.NoError(err)
+
+	response, ok := ret.(events.APIGatewayProxyResponse)
+	h.Require().True(ok)
+	h.Equal(http.StatusOK, response.StatusCode)
+	h.Equal("application/json", response.Headers["Content-Type"])
+	h.JSONEq(`{"data":{"whoami":"bacon"}}`, response.Body)
+}
+
+func (h *handlerTestSuite) TestHandle_HTTPAPI() {
+	ret, err := h.handle(`{
+		"version": "2.0",
+		"queryStringParameters": {"query": "{ whoami }"},
+		"requestContext": {
+			"http": {"method": "GET"},
+			"authorizer": {"jwt": {"claims": {"sub": "bacon"}}}
+		}
+	}`)
+
+	h.NoError(err)
+
+	response, ok := ret.(events.APIGatewayV2HTTPResponse)
+	h.Require().True(ok)
+	h.Equal(http.StatusOK, response.StatusCode)
+	h.JSONEq(`{"data":{"whoami":"bacon"}}`, response.Body)
+}
+
+func (h *handlerTestSuite) TestHandle_FunctionURL() {
+	body := base64.StdEncoding.EncodeToString([]byte(`{"query":"{ whoami }"}`))
+
+	ret, err := h.handle(`{
+		"version": "2.0",
+		"body": "` + body + `",
+		"isBase64Encoded": true,
+		"requestContext": {
+			"domainName": "abc.lambda-url.eu-west-1.on.aws",
+			"http": {"method": "POST"},
+			"authorizer": {"iam": {"userArn": "arn"}}
+		}
+	}`)
+
+	h.NoError(err)
+
+	response, ok := ret.(events.APIGatewayV2HTTPResponse)
+	h.Require().True(ok)
+	h.Equal(http.StatusOK, response.StatusCode)
+	h.JSONEq(`{"data":{"whoami":"arn"}}`, response.Body)
+}
+
+func (h *handlerTestSuite) TestHandle_ALB() {
+	ret, err := h.handle(`{
+		"httpMethod": "GET",
+		"queryStringParameters": {"query": "%7B%20hello%20%7D"},
+		"headers": {"x-amzn-oidc-identity": "bacon"},
+		"requestContext": {"elb": {"targetGroupArn": "arn"}}
+	}`)
+
+	h.NoError(err)
+
+	response, ok := ret.(events.ALBTargetGroupResponse)
+	h.Require().True(ok)
+	h.Equal(http.StatusOK, response.StatusCode)
+	h.Equal("200 OK", response.StatusDescription)
+	h.Equal("application/json", response.Headers["Content-Type"])
+	h.Nil(response.MultiValueHeaders)
+	h.JSONEq(`{"data":{"hello":"bacon"}}`, response.Body)
+}
+
+func (h *handlerTestSuite) TestHandle_InvalidEvent() {
+	ret, err := h.handle(`bacon`)
+
+	h.Nil(ret)
+	h.Error(err)
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_MethodNotAllowed() {
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPut,
+	})
+
+	h.NoError(err)
+	h.Equal(http.StatusMethodNotAllowed, ret.StatusCode)
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_InvalidVariables() {
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodGet,
+		QueryStringParameters: map[string]string{"query": "{ hello }", "variables": "bacon"},
+	})
+
+	h.NoError(err)
+	h.Equal(http.StatusBadRequest, ret.StatusCode)
+	h.Contains(ret.Body, "could not unmarshal variables")
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_GetMutation() {
+	h.resolver = new(testResolver)
+	h.sut = New(graphql.MustParseSchema(testSchema, h.resolver))
+
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodGet,
+		QueryStringParameters: map[string]string{"query": "mutation { increment(by: 1) }"},
+	})
+
+	h.NoError(err)
+	h.Equal(http.StatusMethodNotAllowed, ret.StatusCode)
+	h.Equal("only query operations can be sent as GET requests", ret.Body)
+	h.Zero(h.resolver.counter)
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_GetNamedMutation() {
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		QueryStringParameters: map[string]string{
+			"query":         "query Read { hello } mutation Write { increment(by: 1) }",
+			"operationName": "Write",
+		},
+	})
+
+	h.NoError(err)
+	h.Equal(http.StatusMethodNotAllowed, ret.StatusCode)
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_GetUnparseable() {
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodGet,
+		QueryStringParameters: map[string]string{"query": "{ hello"},
+	})
+
+	h.NoError(err)
+	h.Equal(http.StatusMethodNotAllowed, ret.StatusCode)
+}
+
+func (h *handlerTestSuite) TestHandleHTTPAPI_InvalidBase64() {
+	event := events.APIGatewayV2HTTPRequest{Body: "bacon!", IsBase64Encoded: true}
+	event.RequestContext.HTTP.Method = http.MethodPost
+
+	ret, err := h.sut.HandleHTTPAPI(context.Background(), event)
+
+	h.NoError(err)
+	h.Equal(http.StatusBadRequest, ret.StatusCode)
+	h.Contains(ret.Body, "could not decode request body")
+}
+
+func (h *handlerTestSuite) TestHandleALB_IdentityHeader() {
+	h.sut = New(graphql.MustParseSchema(testSchema, new(testResolver)), WithIdentityHeader("X-Amzn-Oidc-Identity"))
+
+	ret, err := h.sut.HandleALB(context.Background(), events.ALBTargetGroupRequest{
+		HTTPMethod:                      http.MethodGet,
+		MultiValueQueryStringParameters: map[string][]string{"query": {"%7B+whoami+%7D"}},
+		MultiValueHeaders:               map[string][]string{"x-amzn-oidc-identity": {"bacon"}},
+	})
+
+	h.NoError(err)
+	h.Equal(http.StatusOK, ret.StatusCode)
+	h.Equal([]string{"application/json"}, ret.MultiValueHeaders["Content-Type"])
+	h.Nil(ret.Headers)
+	h.JSONEq(`{"data":{"whoami":"bacon"}}`, ret.Body)
+}
+
+func (h *handlerTestSuite) TestHandleALB_IdentityHeaderNotTrusted() {
+	ret, err := h.sut.HandleALB(context.Background(), events.ALBTargetGroupRequest{
+		HTTPMethod:            http.MethodGet,
+		QueryStringParameters: map[string]string{"query": "%7B+whoami+%7D"},
+		Headers:               map[string]string{"x-amzn-oidc-identity": "bacon"},
+	})
+
+	h.NoError(err)
+	h.JSONEq(`{"data":{"whoami":null}}`, ret.Body)
+}
+
+func (h *handlerTestSuite) TestHandleALB_InvalidQuery() {
+	ret, err := h.sut.HandleALB(context.Background(), events.ALBTargetGroupRequest{
+		HTTPMethod:            http.MethodGet,
+		QueryStringParameters: map[string]string{"query": "%zz"},
+	})
+
+	h.NoError(err)
+	h.Equal(http.StatusBadRequest, ret.StatusCode)
+	h.Equal("400 Bad Request", ret.StatusDescription)
+}
+
+func (h *handlerTestSuite) TestPrincipalFromRequest_Authorizer() {
+	ret := principalFromRequest(events.APIGatewayProxyRequestContext{
+		Authorizer: map[string]interface{}{"principalId": "bacon"},
+		Identity:   events.APIGatewayRequestIdentity{UserArn: "arn"},
+	})
+
+	h.Equal("bacon", ret.ID)
+	h.Empty(ret.Roles)
+}
+
+func (h *handlerTestSuite) TestPrincipalFromRequest_AuthorizerRoles() {
+	ret := principalFromRequest(events.APIGatewayProxyRequestContext{
+		Authorizer: map[string]interface{}{"principalId": "bacon", "roles": "admin, auditor,"},
+	})
+
+	h.Equal([]string{"admin", "auditor"}, ret.Roles)
+}
+
+func (h *handlerTestSuite) TestPrincipalFromRequest_IAM() {
+	ret := principalFromRequest(events.APIGatewayProxyRequestContext{
+		Identity: events.APIGatewayRequestIdentity{UserArn: "arn"},
+	})
+
+	h.Equal("arn", ret.ID)
+}
+
+func (h *handlerTestSuite) TestPrincipalFromRequest_Anonymous() {
+	h.Nil(principalFromRequest(events.APIGatewayProxyRequestContext{}))
+}
+
+func (h *handlerTestSuite) TestPrincipalFromAuthorizer_Lambda() {
+	ret := principalFromAuthorizer(&events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+		Lambda: map[string]interface{}{"principalId": "bacon", "roles": "admin"},
+	})
+
+	h.Equal("bacon", ret.ID)
+	h.Equal([]string{"admin"}, ret.Roles)
+}
+
+func (h *handlerTestSuite) TestPrincipalFromAuthorizer_JWT() {
+	ret := principalFromAuthorizer(&events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+		JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+			Claims: map[string]string{"sub": "bacon", "roles": "admin,auditor"},
+		},
+	})
+
+	h.Equal("bacon", ret.ID)
+	h.Equal([]string{"admin", "auditor"}, ret.Roles)
+}
+
+func (h *handlerTestSuite) TestPrincipalFromAuthorizer_Anonymous() {
+	h.Nil(principalFromAuthorizer(nil))
+	h.Nil(principalFromAuthorizer(&events.APIGatewayV2HTTPRequestContextAuthorizerDescription{}))
+}
+
+func (h *handlerTestSuite) handle(payload string) (interface{}, error) {
+	return h.sut.Handle(context.Background(), json.RawMessage(payload))
+}
@@ -2,57 +2,533 @@ package handler
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-xray-sdk-go/xray"
+	log "github.com/Sirupsen/logrus"
 	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/secretservice/cost"
+	"github.com/marcinwyszynski/secretservice/document"
+	"github.com/marcinwyszynski/secretservice/idempotency"
+	"github.com/marcinwyszynski/secretservice/loader"
+	"github.com/marcinwyszynski/secretservice/metrics"
+	"github.com/marcinwyszynski/secretservice/ratelimit"
+	"github.com/marcinwyszynski/secretservice/tracing"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Handler wraps a GraphQL schema to interface with AWS API Gateway.
+const (
+	internalErrorMessage = "internal error"
+	panicMessagePrefix   = "graphql: panic occurred"
+	anonymousOperation   = "anonymous"
+	otherOperation       = "other"
+
+	// maxOperationNames is the number of distinct operation names recorded
+	// before the rest are folded into otherOperation.
+	maxOperationNames = 100
+)
+
+// Handler wraps a GraphQL schema to interface with AWS API Gateway, Lambda
+// function URLs and Application Load Balancers.
 type Handler struct {
-	schema *graphql.Schema
+	schema         *graphql.Schema
+	identityHeader string
+	persisted      PersistedQueries
+	rejectAdHoc    bool
+	maxCost        int64
+
+	idempotency       idempotency.Store
+	idempotencyWindow time.Duration
+
+	limiter    ratelimit.Limiter
+	rateLimits RateLimits
+
+	recorder metrics.Recorder
+
+	mu             sync.Mutex
+	operationNames map[string]bool
+}
+
+// Option customizes the Handler.
+type Option func(*Handler)
+
+// WithIdentityHeader makes the Handler identify callers behind an Application
+// Load Balancer by the value of the header, e.g. "x-amzn-oidc-identity". Only
+// use it if the load balancer authenticates all requests, as clients can set
+// the header themselves otherwise.
+func WithIdentityHeader(header string) Option {
+	return func(h *Handler) {
+		h.identityHeader = strings.ToLower(header)
+	}
+}
+
+// WithMaxCost makes the Handler reject operations whose estimated cost exceeds
+// maxCost before executing them, and report the estimate in the "cost"
+// extension of the response. Zero disables the limit. The depth of operations
+// is limited by the schema instead, see graphql.MaxDepth.
+func WithMaxCost(maxCost int64) Option {
+	return func(h *Handler) {
+		h.maxCost = maxCost
+	}
+}
+
+// WithMetrics makes the Handler record the duration and the code of the first
+// error of each operation, by its name. Operations without a name are
+// recorded as "anonymous". Clients choose the names, so only the first 100
+// distinct names of operations found in their documents are recorded, and the
+// rest as "other", along with requests which could not be parsed.
+func WithMetrics(recorder metrics.Recorder) Option {
+	return func(h *Handler) {
+		h.recorder = recorder
+	}
 }
 
 // New returns an instance of a Handler.
-func New(schema *graphql.Schema) *Handler {
-	return &Handler{schema: schema}
+func New(schema *graphql.Schema, opts ...Option) *Handler {
+	ret := &Handler{schema: schema, operationNames: make(map[string]bool)}
+
+	for _, opt := range opts {
+		opt(ret)
+	}
+
+	return ret
 }
 
-// Handle serves as a main Lambda handler, translating API Gateway requests
-// to GraphQL, and GraphQL responses back to API Gateway ones.
-func (h *Handler) Handle(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	var ret events.APIGatewayProxyResponse
-	data, err := h.handle(ctx, event.Body)
+// ServeHTTP serves GraphQL requests over plain HTTP.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		ret.StatusCode = http.StatusInternalServerError
-		ret.Body = err.Error()
-	} else {
-		ret.StatusCode = http.StatusOK
-		ret.Body = string(data)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := tracing.Extract(r.Context(), r.Header)
+	if key := r.Header.Get(idempotencyKeyHeader); key != "" {
+		ctx = withIdempotencyKey(ctx, key)
+	}
+
+	data, err := h.handle(ctx, string(body))
+	if err != nil {
+		response := newErrorResponse(err)
+		for key, value := range response.headers {
+			w.Header().Set(key, value)
+		}
+		http.Error(w, response.body, response.status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// httpRequest is the part of an HTTP request the Handler needs, regardless of
+// the kind of event it arrived in.
+type httpRequest struct {
+	method        string
+	body          string
+	base64Encoded bool
+	query         map[string]string
+
+	headers        http.Header
+	idempotencyKey string
+}
+
+// httpResponse is what the Handler responds with, regardless of the kind of
+// event the request arrived in.
+type httpResponse struct {
+	status  int
+	headers map[string]string
+	body    string
+}
+
+func newHTTPResponse(status int, body string) httpResponse {
+	return httpResponse{status: status, headers: responseHeaders(status), body: body}
+}
+
+// newErrorResponse builds the response to a request which could not be
+// executed at all.
+func newErrorResponse(err error) httpResponse {
+	ret := newHTTPResponse(errorResponse(err))
+	if limited, ok := errors.Cause(err).(*rateLimitedError); ok {
+		ret.headers["Retry-After"] = limited.retryAfterSeconds()
+	}
+	return ret
+}
+
+// serve executes a GraphQL request sent either as a JSON body of a POST
+// request, or as query string parameters of a GET request, which can only
+// carry queries. It returns an error only if the request failed for reasons
+// other than the client's.
+func (h *Handler) serve(ctx context.Context, request httpRequest) (httpResponse, error) {
+	var data []byte
+	var err error
+
+	ctx = tracing.Extract(ctx, request.headers)
+
+	if request.idempotencyKey != "" {
+		ctx = withIdempotencyKey(ctx, request.idempotencyKey)
+	}
+
+	switch request.method {
+	case http.MethodGet:
+		data, err = h.handleQuery(ctx, request.query)
+	case http.MethodPost:
+		data, err = h.handleBody(ctx, request.body, request.base64Encoded)
+	default:
+		return newHTTPResponse(http.StatusMethodNotAllowed, "only GET and POST requests are supported"), nil
+	}
+
+	if err == nil {
+		return newHTTPResponse(http.StatusOK, string(data)), nil
+	}
+
+	response := newErrorResponse(err)
+	if response.status == http.StatusInternalServerError {
+		return response, err
+	}
+	return response, nil
+}
+
+func (h *Handler) handleBody(ctx context.Context, body string, base64Encoded bool) ([]byte, error) {
+	if !base64Encoded {
+		return h.handle(ctx, body)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, secretservice.Validation("could not decode request body: %v", err)
+	}
+	return h.handle(ctx, string(decoded))
+}
+
+func (h *Handler) handleQuery(ctx context.Context, params map[string]string) ([]byte, error) {
+	req := &graphQLRequest{
+		OperationName: params["operationName"],
+		Query:         params["query"],
+	}
+
+	if variables := params["variables"]; variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			return nil, secretservice.Validation("could not unmarshal variables: %v", err)
+		}
+	}
+
+	if extensions := params["extensions"]; extensions != "" {
+		if err := json.Unmarshal([]byte(extensions), &req.Extensions); err != nil {
+			return nil, secretservice.Validation("could not unmarshal extensions: %v", err)
+		}
+	}
+
+	if err := h.checkReadOnly(req); err != nil {
+		return nil, err
+	}
+
+	if err := h.limitRate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return marshalResponse(h.execute(ctx, req))
+}
+
+// methodNotAllowedError rejects an operation which can not be sent with the
+// HTTP method of the request.
+type methodNotAllowedError struct{}
+
+func (*methodNotAllowedError) Error() string {
+	return "only query operations can be sent as GET requests"
+}
+
+// checkReadOnly rejects a GET request unless it executes a query. Browsers
+// send GET requests across origins along with credentials, so mutations sent
+// that way would be open to cross-site request forgery. Requests which do not
+// parse are rejected too, since they can not be shown to be queries.
+func (h *Handler) checkReadOnly(req *graphQLRequest) error {
+	if _, err := h.resolveQuery(req); err != nil {
+		// The request is rejected when it is executed, without running
+		// anything.
+		return nil
+	}
+
+	if !h.readOnly(req) {
+		return new(methodNotAllowedError)
+	}
+
+	return nil
+}
+
+// readOnly returns true if the request is known to execute a query.
+func (h *Handler) readOnly(req *graphQLRequest) bool {
+	query, err := h.resolveQuery(req)
+	if err != nil {
+		return false
 	}
-	return ret, err
+
+	_, operation, err := parseOperation(query, req.OperationName)
+	return err == nil && operation.Type == "query"
+}
+
+type graphQLRequest struct {
+	OperationName string                 `json:"operationName"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *struct {
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
 }
 
+// handle executes a single operation, or a batch of them sent as a JSON
+// array.
 func (h *Handler) handle(ctx context.Context, input string) ([]byte, error) {
-	var req struct {
-		OperationName string                 `json:"operationName"`
-		Query         string                 `json:"query"`
-		Variables     map[string]interface{} `json:"variables"`
+	if strings.HasPrefix(strings.TrimSpace(input), "[") {
+		return h.handleBatch(ctx, input)
+	}
+
+	req := new(graphQLRequest)
+
+	if err := json.NewDecoder(strings.NewReader(input)).Decode(req); err != nil {
+		return nil, secretservice.Validation("could not unmarshal request: %v", err)
 	}
 
-	if err := json.NewDecoder(strings.NewReader(input)).Decode(&req); err != nil {
-		return nil, errors.Wrap(err, "could not unmarshal request")
+	if err := h.limitRate(ctx, req); err != nil {
+		return nil, err
 	}
 
-	xray.AddAnnotation(ctx, "Operation", req.OperationName)
+	return marshalResponse(h.execute(ctx, req))
+}
+
+// execute runs a single operation. Failures are reported as errors in the
+// GraphQL response, so that a failing operation does not affect others in
+// the same batch.
+func (h *Handler) execute(ctx context.Context, req *graphQLRequest) (response *graphql.Response) {
+	start, name := time.Now(), otherOperation
+
+	ctx, span := tracing.Start(ctx, "GraphQL operation", trace.WithSpanKind(trace.SpanKindServer))
+	defer func() {
+		span.SetAttributes(attribute.String("graphql.operation.name", name))
+		endSpan(span, response)
+	}()
+
+	if h.recorder != nil {
+		defer func() { h.recorder.ObserveOperation(name, responseCode(response), time.Since(start)) }()
+	}
 
-	ret, err := json.Marshal(h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+	query, err := h.resolveQuery(req)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	// The operation is parsed here to name it, estimate its cost, or to find
+	// its idempotency key. The schema reports parse errors itself.
+	doc, operation, parseErr := parseOperation(query, req.OperationName)
+	if parseErr == nil {
+		name = h.operationName(operation)
+	}
+
+	analysis, err := h.analyze(doc, operation, parseErr)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	run := func() *graphql.Response {
+		// Resolvers share reads within an operation, but never across them.
+		response := h.schema.Exec(loader.WithCache(ctx), query, req.OperationName, req.Variables)
+		for _, queryErr := range response.Errors {
+			annotateError(queryErr)
+		}
+		return response
+	}
+
+	if key := h.idempotencyKey(ctx, req, operation); key != "" {
+		response = h.executeOnce(ctx, key, query, req, doc, operation, run)
+	} else {
+		response = run()
+	}
+
+	if analysis != nil {
+		if response.Extensions == nil {
+			response.Extensions = make(map[string]interface{})
+		}
+		response.Extensions["cost"] = map[string]interface{}{
+			"depth": analysis.Depth,
+			"total": analysis.Cost,
+		}
+	}
+
+	return response
+}
+
+// endSpan ends the span of an operation, marking it as failed with the code
+// of the first error.
+func endSpan(span trace.Span, response *graphql.Response) {
+	if code := responseCode(response); code != metrics.CodeOK {
+		span.SetStatus(codes.Error, code)
+	}
+	span.End()
+}
+
+// operationName returns the name an operation found in the document of a
+// request is recorded with.
+func (h *Handler) operationName(operation *document.Operation) string {
+	if operation.Name == "" {
+		return anonymousOperation
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.operationNames[operation.Name] {
+		if len(h.operationNames) >= maxOperationNames {
+			return otherOperation
+		}
+		h.operationNames[operation.Name] = true
+	}
+
+	return operation.Name
+}
+
+// responseCode returns the code of the first error of a response, or
+// metrics.CodeOK if there are none.
+func responseCode(response *graphql.Response) string {
+	if len(response.Errors) == 0 {
+		return metrics.CodeOK
+	}
+
+	code, _ := response.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+// analyze estimates the cost of an operation, and rejects it if it exceeds
+// the limit of the Handler. Without a limit nothing is estimated, and
+// documents which can not be parsed are left for the schema to report on.
+func (h *Handler) analyze(doc *document.Document, operation *document.Operation, err error) (*cost.Result, error) {
+	if h.maxCost == 0 {
+		return nil, nil
+	}
+
+	var ret *cost.Result
+	if err == nil {
+		ret, err = cost.Analyze(doc, operation, cost.DefaultWeights)
+	}
+	if err != nil {
+		return nil, secretservice.Validation("could not analyze query: %v", err)
+	}
+
+	if ret.Cost > h.maxCost {
+		return nil, secretservice.Validation("query cost %d exceeds the limit of %d", ret.Cost, h.maxCost)
+	}
+
+	return ret, nil
+}
+
+// parseOperation parses the query to find the operation the request
+// executes.
+func parseOperation(query, operationName string) (*document.Document, *document.Operation, error) {
+	doc, err := document.Parse(query)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not parse query")
+	}
+
+	operation, err := doc.Operation(operationName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return doc, operation, nil
+}
+
+// selectedFields returns the names of the fields of a selection set, including
+// the ones selected through fragments. Documents are not validated yet when
+// they are inspected, so each fragment is only expanded once, which also
+// guards against cycles.
+func selectedFields(doc *document.Document, selections []*document.Selection, expanded map[string]bool) []string {
+	if expanded == nil {
+		expanded = make(map[string]bool)
+	}
+
+	var ret []string
+	for _, selection := range selections {
+		switch {
+		case selection.Field != "":
+			ret = append(ret, selection.Field)
+		case selection.Fragment != "":
+			if !expanded[selection.Fragment] {
+				expanded[selection.Fragment] = true
+				ret = append(ret, selectedFields(doc, doc.Fragments[selection.Fragment], expanded)...)
+			}
+		default:
+			ret = append(ret, selectedFields(doc, selection.Selections, expanded)...)
+		}
+	}
+	return ret
+}
+
+func errorResult(err error) *graphql.Response {
+	queryErr := &gqlerrors.QueryError{Message: err.Error(), ResolverError: err}
+	annotateError(queryErr)
+	return &graphql.Response{Errors: []*gqlerrors.QueryError{queryErr}}
+}
+
+func marshalResponse(response interface{}) ([]byte, error) {
+	ret, err := json.Marshal(response)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not write back the response")
 	}
 	return ret, nil
 }
+
+// errorResponse returns the HTTP status and body for an error which prevented
+// the request from being executed at all.
+func errorResponse(err error) (int, string) {
+	if _, ok := errors.Cause(err).(*rateLimitedError); ok {
+		return http.StatusTooManyRequests, err.Error()
+	}
+	if _, ok := errors.Cause(err).(*methodNotAllowedError); ok {
+		return http.StatusMethodNotAllowed, err.Error()
+	}
+	if secretservice.ErrorCode(err) == secretservice.CodeValidation {
+		return http.StatusBadRequest, err.Error()
+	}
+	return http.StatusInternalServerError, internalErrorMessage
+}
+
+// annotateError exposes the code of a GraphQL error as its "code" extension.
+// Errors not raised by resolvers come from parsing and validating the query,
+// unless they are recovered panics. Messages of internal errors are logged
+// and replaced, as they may include AWS error text.
+func annotateError(err *gqlerrors.QueryError) {
+	code := secretservice.CodeValidation
+
+	switch {
+	case err.ResolverError != nil:
+		code = secretservice.ErrorCode(err.ResolverError)
+	case strings.HasPrefix(err.Message, panicMessagePrefix):
+		code = secretservice.CodeInternal
+	}
+
+	if code == secretservice.CodeInternal {
+		log.WithField("path", err.Path).Error(err.Message)
+		err.Message = internalErrorMessage
+	}
+
+	if err.Extensions == nil {
+		err.Extensions = make(map[string]interface{})
+	}
+	err.Extensions["code"] = code
+}
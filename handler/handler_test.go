@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testSchema = `
+schema {
+  query: Query
+  mutation: Mutation
+}
+
+type Query {
+  hello: String!
+  forbidden: String
+  broken: String
+  whoami: String
+}
+
+type Mutation {
+  increment(by: Int!, clientMutationId: String): Int!
+}
+`
+
+type testResolver struct {
+	counter  int32
+	inFlight int32
+}
+
+func (*testResolver) Hello() string {
+	return "bacon"
+}
+
+func (*testResolver) Forbidden() (*string, error) {
+	return nil, errors.Wrap(secretservice.Forbidden("not for you"), "could not say hello")
+}
+
+func (*testResolver) Whoami(ctx context.Context) *string {
+	principal, ok := secretservice.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return &principal.ID
+}
+
+func (*testResolver) Broken() (*string, error) {
+	return nil, errors.Wrap(errors.New("AccessDenied: arn:aws:iam::123456789012"), "could not say hello")
+}
+
+func (t *testResolver) Increment(args struct {
+	By               int32
+	ClientMutationID *string
+}) (int32, error) {
+	if args.By < 0 {
+		return 0, secretservice.Validation("can not decrement")
+	}
+
+	// Overlapping increments fail, so that tests notice mutations running
+	// concurrently.
+	defer atomic.AddInt32(&t.inFlight, -1)
+	if atomic.AddInt32(&t.inFlight, 1) > 1 {
+		return 0, errors.New("concurrent increment")
+	}
+	time.Sleep(time.Millisecond)
+
+	return atomic.AddInt32(&t.counter, args.By), nil
+}
+
+type handlerTestSuite struct {
+	suite.Suite
+
+	resolver *testResolver
+	sut      *Handler
+
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+}
+
+func (h *handlerTestSuite) SetupTest() {
+	h.sut = New(graphql.MustParseSchema(testSchema, new(testResolver)))
+}
+
+// TearDownTest restores the global tracer provider and propagator replaced by
+// withTracing. The initial ones can not be set again unless they have been
+// replaced, so they are only restored then.
+func (h *handlerTestSuite) TearDownTest() {
+	if h.tracerProvider == nil {
+		return
+	}
+
+	otel.SetTracerProvider(h.tracerProvider)
+	otel.SetTextMapPropagator(h.propagator)
+	h.tracerProvider, h.propagator = nil, nil
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_OK() {
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Body:       `{"query":"{ hello }"}`,
+	})
+
+	h.NoError(err)
+	h.Equal(http.StatusOK, ret.StatusCode)
+	h.JSONEq(`{"data":{"hello":"bacon"}}`, ret.Body)
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_MalformedRequest() {
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Body:       "bacon",
+	})
+
+	h.NoError(err)
+	h.Equal(http.StatusBadRequest, ret.StatusCode)
+	h.Contains(ret.Body, "could not unmarshal request")
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_TypedError() {
+	queryErr := h.queryError(`{"query":"{ forbidden }"}`)
+
+	h.Equal("could not say hello: not for you", queryErr.Message)
+	h.Equal(secretservice.CodeForbidden, queryErr.Extensions["code"])
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_InternalError() {
+	queryErr := h.queryError(`{"query":"{ broken }"}`)
+
+	h.Equal("internal error", queryErr.Message)
+	h.Equal(secretservice.CodeInternal, queryErr.Extensions["code"])
+}
+
+func (h *handlerTestSuite) TestHandleAPIGateway_InvalidQuery() {
+	queryErr := h.queryError(`{"query":"{ bacon }"}`)
+
+	h.Contains(queryErr.Message, "bacon")
+	h.Equal(secretservice.CodeValidation, queryErr.Extensions["code"])
+}
+
+func (h *handlerTestSuite) TestMaxCost_WithinLimit() {
+	h.withMaxCost(2)
+
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Body:       `{"query":"{ hello whoami }"}`,
+	})
+
+	h.NoError(err)
+	h.JSONEq(`{"data":{"hello":"bacon","whoami":null},"extensions":{"cost":{"depth":1,"total":2}}}`, ret.Body)
+}
+
+func (h *handlerTestSuite) TestMaxCost_TooExpensive() {
+	h.withMaxCost(2)
+
+	queryErr := h.queryError(`{"query":"{ hello whoami first: hello }"}`)
+
+	h.Equal("query cost 3 exceeds the limit of 2", queryErr.Message)
+	h.Equal(secretservice.CodeValidation, queryErr.Extensions["code"])
+}
+
+func (h *handlerTestSuite) TestMaxCost_SyntaxError() {
+	h.withMaxCost(2)
+
+	queryErr := h.queryError(`{"query":"{ hello"}`)
+
+	h.Equal("could not analyze query: could not parse query: line 1, column 8: unexpected end of document", queryErr.Message)
+	h.Equal(secretservice.CodeValidation, queryErr.Extensions["code"])
+}
+
+func (h *handlerTestSuite) TestMaxCost_SyntaxErrorWithoutLimit() {
+	queryErr := h.queryError(`{"query":"{ hello"}`)
+
+	h.Contains(queryErr.Message, "syntax error")
+	h.Equal(secretservice.CodeValidation, queryErr.Extensions["code"])
+}
+
+func (h *handlerTestSuite) TestServeHTTP_OK() {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"query":"{ hello }"}`))
+
+	h.sut.ServeHTTP(recorder, request)
+
+	h.Equal(http.StatusOK, recorder.Code)
+	h.Equal("application/json", recorder.Header().Get("Content-Type"))
+	h.JSONEq(`{"data":{"hello":"bacon"}}`, recorder.Body.String())
+}
+
+func (h *handlerTestSuite) TestServeHTTP_MethodNotAllowed() {
+	recorder := httptest.NewRecorder()
+
+	h.sut.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	h.Equal(http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func (h *handlerTestSuite) TestServeHTTP_InvalidBody() {
+	recorder := httptest.NewRecorder()
+
+	h.sut.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("bacon")))
+
+	h.Equal(http.StatusBadRequest, recorder.Code)
+}
+
+func (h *handlerTestSuite) withMaxCost(maxCost int64) {
+	h.sut = New(graphql.MustParseSchema(testSchema, new(testResolver)), WithMaxCost(maxCost))
+}
+
+func (h *handlerTestSuite) queryError(body string) (ret struct {
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions"`
+}) {
+	resp, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Body:       body,
+	})
+	h.Require().NoError(err)
+	h.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var response struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	h.Require().NoError(json.Unmarshal([]byte(resp.Body), &response))
+	h.Require().Len(response.Errors, 1)
+	h.Require().NoError(json.Unmarshal(response.Errors[0], &ret))
+
+	return ret
+}
+
+func TestHandler(t *testing.T) {
+	suite.Run(t, new(handlerTestSuite))
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/secretservice/document"
+	"github.com/marcinwyszynski/secretservice/idempotency"
+	"github.com/pkg/errors"
+)
+
+const (
+	idempotencyKeyHeader     = "idempotency-key"
+	clientMutationIDArgument = "clientMutationId"
+
+	// defaultIdempotencyLease is how long a key is claimed for while a
+	// request without a deadline is executed.
+	defaultIdempotencyLease = 5 * time.Minute
+)
+
+type idempotencyKeyContextKey struct{}
+
+// storedResponse is what the Handler keeps under an idempotency key. The
+// fingerprint of the request tells retries from reuses of the key.
+type storedResponse struct {
+	Fingerprint string            `json:"fingerprint"`
+	Response    *graphql.Response `json:"response"`
+}
+
+// WithIdempotency makes the Handler store responses to mutations sent with an
+// "Idempotency-Key" header or a "clientMutationId" argument for the duration
+// of the window. Retries with the same key from the same principal get the
+// stored response instead of executing the mutation again. Responses are
+// stored as they were sent, so the store holds any Variable values the
+// mutations returned, and has to be protected like the Scopes themselves.
+// Keys are only claimed until the deadline of the request while it is being
+// executed, so that requests cut short by a timeout or a crash can be retried.
+func WithIdempotency(store idempotency.Store, window time.Duration) Option {
+	return func(h *Handler) {
+		h.idempotency = store
+		h.idempotencyWindow = window
+	}
+}
+
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+func idempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// idempotencyKey returns the key to store the response to a mutation under,
+// or an empty string if the request is not idempotent. Keys of different
+// principals never collide.
+func (h *Handler) idempotencyKey(ctx context.Context, req *graphQLRequest, operation *document.Operation) string {
+	if h.idempotency == nil || operation == nil || operation.Type != "mutation" {
+		return ""
+	}
+
+	key := idempotencyKeyFromContext(ctx)
+	if key == "" {
+		key = clientMutationID(operation, req.Variables)
+	}
+	if key == "" {
+		return ""
+	}
+
+	var principalID string
+	if principal, ok := secretservice.PrincipalFromContext(ctx); ok {
+		principalID = principal.ID
+	}
+
+	hash := sha256.Sum256([]byte(principalID + "\x00" + key))
+	return hex.EncodeToString(hash[:])
+}
+
+// clientMutationID returns the first "clientMutationId" argument of the
+// top-level fields of a mutation.
+func clientMutationID(operation *document.Operation, variables map[string]interface{}) string {
+	for _, selection := range operation.Selections {
+		value, ok := selection.Arguments[clientMutationIDArgument]
+		if !ok {
+			continue
+		}
+
+		if value.Variable != "" {
+			if id, _ := variables[value.Variable].(string); id != "" {
+				return id
+			}
+		} else if value.String != nil && *value.String != "" {
+			return *value.String
+		}
+	}
+
+	return ""
+}
+
+// executeOnce runs a mutation unless a response to it is stored under the
+// key already. Responses are stored once any of the top-level fields of the
+// mutation resolved, so that retries never repeat their effects. Mutations
+// which had no effect at all can be retried.
+func (h *Handler) executeOnce(ctx context.Context, key, query string, req *graphQLRequest, doc *document.Document, operation *document.Operation, run func() *graphql.Response) *graphql.Response {
+	fingerprint, err := requestFingerprint(query, req)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	stored, err := h.idempotency.Begin(ctx, key, h.idempotencyLease(ctx))
+	if err != nil {
+		return errorResult(errors.Wrap(err, "could not claim idempotency key"))
+	}
+
+	if stored != nil {
+		return replayResponse(stored, fingerprint)
+	}
+
+	response := run()
+
+	if !executed(doc, operation, response) {
+		if err := h.idempotency.Abandon(ctx, key); err != nil {
+			log.WithError(err).Error("could not release idempotency key")
+		}
+		return response
+	}
+
+	encoded, err := json.Marshal(&storedResponse{Fingerprint: fingerprint, Response: response})
+	if err == nil {
+		err = h.idempotency.Complete(ctx, key, encoded, h.idempotencyWindow)
+	}
+	if err != nil {
+		log.WithError(err).Error("could not store idempotent response")
+		if err := h.idempotency.Abandon(ctx, key); err != nil {
+			log.WithError(err).Error("could not release idempotency key")
+		}
+	}
+
+	return response
+}
+
+// idempotencyLease returns how long to claim a key for while its request is
+// executed. Lambda functions get a context with a deadline set by their
+// timeout, other requests are given defaultIdempotencyLease.
+func (h *Handler) idempotencyLease(ctx context.Context) time.Duration {
+	lease := defaultIdempotencyLease
+	if deadline, ok := ctx.Deadline(); ok {
+		// Stores may round expiry times down to a second.
+		lease = time.Until(deadline) + time.Second
+	}
+
+	if lease > h.idempotencyWindow {
+		return h.idempotencyWindow
+	}
+	return lease
+}
+
+// executed returns true if any of the top-level fields of an operation
+// resolved. Errors without a path prevented the operation from running at
+// all.
+func executed(doc *document.Document, operation *document.Operation, response *graphql.Response) bool {
+	failed := make(map[interface{}]bool)
+	for _, queryErr := range response.Errors {
+		if len(queryErr.Path) == 0 {
+			return false
+		}
+		if len(queryErr.Path) == 1 {
+			failed[queryErr.Path[0]] = true
+		}
+	}
+
+	return len(failed) < len(selectedFields(doc, operation.Selections, nil))
+}
+
+func replayResponse(stored []byte, fingerprint string) *graphql.Response {
+	var record storedResponse
+	if err := json.Unmarshal(stored, &record); err != nil {
+		return errorResult(errors.Wrap(err, "could not unmarshal stored response"))
+	}
+
+	if record.Fingerprint != fingerprint {
+		return errorResult(secretservice.Conflict("the idempotency key was used for a different request"))
+	}
+
+	if record.Response.Extensions == nil {
+		record.Response.Extensions = make(map[string]interface{})
+	}
+	record.Response.Extensions["replayed"] = true
+
+	return record.Response
+}
+
+// requestFingerprint hashes the request, using the text of the query as
+// persisted queries can be sent without it.
+func requestFingerprint(query string, req *graphQLRequest) (string, error) {
+	encoded, err := json.Marshal(struct {
+		OperationName string                 `json:"operationName"`
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables"`
+	}{req.OperationName, query, req.Variables})
+
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal request")
+	}
+
+	return queryHash(string(encoded)), nil
+}
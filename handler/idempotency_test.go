@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/secretservice/idempotency"
+)
+
+const incrementMutation = `{"query":"mutation { increment(by: 1) }"}`
+
+func (h *handlerTestSuite) TestIdempotency_HeaderReplaysResponse() {
+	h.withIdempotency()
+
+	first := h.mutate(incrementMutation, "key", nil)
+	second := h.mutate(incrementMutation, "key", nil)
+
+	h.JSONEq(`{"data":{"increment":1}}`, first.Body)
+	h.JSONEq(`{"data":{"increment":1},"extensions":{"replayed":true}}`, second.Body)
+}
+
+func (h *handlerTestSuite) TestIdempotency_ClientMutationID() {
+	h.withIdempotency()
+	literal := `{"query":"mutation { increment(by: 1, clientMutationId: \"key\") }"}`
+	variable := `{"query":"mutation ($id: String) { increment(by: 1, clientMutationId: $id) }","variables":{"id":"other"}}`
+
+	h.JSONEq(`{"data":{"increment":1}}`, h.mutate(literal, "", nil).Body)
+	h.Contains(h.mutate(literal, "", nil).Body, `"replayed":true`)
+
+	h.JSONEq(`{"data":{"increment":2}}`, h.mutate(variable, "", nil).Body)
+	h.Contains(h.mutate(variable, "", nil).Body, `"replayed":true`)
+}
+
+func (h *handlerTestSuite) TestIdempotency_KeysArePerPrincipal() {
+	h.withIdempotency()
+
+	h.mutate(incrementMutation, "key", map[string]interface{}{"principalId": "first"})
+	ret := h.mutate(incrementMutation, "key", map[string]interface{}{"principalId": "second"})
+
+	h.JSONEq(`{"data":{"increment":2}}`, ret.Body)
+}
+
+func (h *handlerTestSuite) TestIdempotency_KeyReusedForDifferentRequest() {
+	h.withIdempotency()
+
+	h.mutate(incrementMutation, "key", nil)
+	ret := h.mutate(`{"query":"mutation { increment(by: 2) }"}`, "key", nil)
+
+	h.Contains(ret.Body, "the idempotency key was used for a different request")
+	h.Contains(ret.Body, secretservice.CodeConflict)
+}
+
+func (h *handlerTestSuite) TestIdempotency_FailuresAreNotStored() {
+	h.withIdempotency()
+	failing := `{"query":"mutation { increment(by: -1) }"}`
+
+	h.Contains(h.mutate(failing, "key", nil).Body, "can not decrement")
+
+	ret := h.mutate(failing, "key", nil)
+	h.Contains(ret.Body, "can not decrement")
+	h.NotContains(ret.Body, "replayed")
+}
+
+func (h *handlerTestSuite) TestIdempotency_PartialFailuresAreStored() {
+	h.withIdempotency()
+	partial := `{"query":"mutation { first: increment(by: 1) ...Second } fragment Second on Mutation { second: increment(by: -1) }"}`
+
+	h.Contains(h.mutate(partial, "key", nil).Body, "can not decrement")
+
+	ret := h.mutate(partial, "key", nil)
+	h.Contains(ret.Body, "can not decrement")
+	h.Contains(ret.Body, `"replayed":true`)
+	h.JSONEq(`{"data":{"increment":2}}`, h.mutate(incrementMutation, "", nil).Body)
+}
+
+func (h *handlerTestSuite) TestIdempotency_QueriesAreNotStored() {
+	h.withIdempotency()
+
+	h.mutate(`{"query":"{ hello }"}`, "key", nil)
+	ret := h.mutate(`{"query":"{ hello }"}`, "key", nil)
+
+	h.NotContains(ret.Body, "replayed")
+}
+
+func (h *handlerTestSuite) TestIdempotency_Batch() {
+	h.withIdempotency()
+	batch := "[" + incrementMutation + "," + incrementMutation + "]"
+
+	first := h.mutate(batch, "key", nil)
+	second := h.mutate(batch, "key", nil)
+
+	h.Contains(first.Body, `"increment":1`)
+	h.Contains(first.Body, `"increment":2`)
+	h.Equal(2, strings.Count(second.Body, `"replayed":true`))
+}
+
+func (h *handlerTestSuite) TestIdempotency_ServeHTTP() {
+	h.withIdempotency()
+
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(incrementMutation))
+		request.Header.Set("Idempotency-Key", "key")
+
+		h.sut.ServeHTTP(recorder, request)
+
+		h.Contains(recorder.Body.String(), `"increment":1`)
+	}
+}
+
+type recordingStore struct {
+	*idempotency.MemoryStore
+
+	beginTTL, completeTTL time.Duration
+}
+
+func (r *recordingStore) Begin(ctx context.Context, key string, ttl time.Duration) ([]byte, error) {
+	r.beginTTL = ttl
+	return r.MemoryStore.Begin(ctx, key, ttl)
+}
+
+func (r *recordingStore) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	r.completeTTL = ttl
+	return r.MemoryStore.Complete(ctx, key, response, ttl)
+}
+
+func (h *handlerTestSuite) TestIdempotency_ClaimsAreLeased() {
+	store := &recordingStore{MemoryStore: idempotency.NewMemoryStore()}
+	h.sut = New(graphql.MustParseSchema(testSchema, new(testResolver)), WithIdempotency(store, time.Hour))
+
+	h.mutate(incrementMutation, "key", nil)
+
+	h.Equal(defaultIdempotencyLease, store.beginTTL)
+	h.Equal(time.Hour, store.completeTTL)
+}
+
+func (h *handlerTestSuite) TestIdempotencyLease() {
+	h.withIdempotency()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	h.InDelta(31*time.Second, h.sut.idempotencyLease(ctx), float64(time.Second))
+	h.Equal(defaultIdempotencyLease, h.sut.idempotencyLease(context.Background()))
+
+	h.sut.idempotencyWindow = time.Minute
+	h.Equal(time.Minute, h.sut.idempotencyLease(context.Background()))
+}
+
+func (h *handlerTestSuite) withIdempotency() {
+	h.sut = New(
+		graphql.MustParseSchema(testSchema, new(testResolver)),
+		WithIdempotency(idempotency.NewMemoryStore(), time.Hour),
+	)
+}
+
+func (h *handlerTestSuite) mutate(body, key string, authorizer map[string]interface{}) events.APIGatewayProxyResponse {
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodPost,
+		Body:           body,
+		RequestContext: events.APIGatewayProxyRequestContext{Authorizer: authorizer},
+	}
+	if key != "" {
+		request.Headers = map[string]string{"Idempotency-Key": key}
+	}
+
+	ret, err := h.sut.HandleAPIGateway(context.Background(), request)
+	h.Require().NoError(err)
+	h.Require().Equal(http.StatusOK, ret.StatusCode)
+
+	return ret
+}
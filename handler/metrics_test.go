@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice/metrics"
+)
+
+func (h *handlerTestSuite) TestMetrics_RecordsOperations() {
+	registry := metrics.NewRegistry()
+	h.sut = New(graphql.MustParseSchema(testSchema, new(testResolver)), WithMetrics(registry))
+
+	for _, body := range []string{
+		`{"query":"{ hello }"}`,
+		`{"query":"query Forbidden { forbidden }","operationName":"Forbidden"}`,
+		`{"query":"{ nonexistent }"}`,
+	} {
+		_, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodPost,
+			Body:       body,
+		})
+		h.Require().NoError(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+
+	h.Contains(body, `secretservice_graphql_operations_total{operation="anonymous",code="OK"} 1`)
+	h.Contains(body, `secretservice_graphql_operations_total{operation="anonymous",code="VALIDATION"} 1`)
+	h.Contains(body, `secretservice_graphql_operations_total{operation="Forbidden",code="FORBIDDEN"} 1`)
+	h.Contains(body, `secretservice_graphql_operation_duration_seconds_count{operation="anonymous"} 2`)
+}
+
+func (h *handlerTestSuite) TestMetrics_BoundsOperationNames() {
+	registry := metrics.NewRegistry()
+	h.sut = New(graphql.MustParseSchema(testSchema, new(testResolver)), WithMetrics(registry))
+
+	bodies := []string{
+		`{"query":"query Hello { hello }","operationName":"Missing"}`,
+		`{"query":"not a query","operationName":"Unparseable"}`,
+	}
+	for i := 0; i <= maxOperationNames; i++ {
+		bodies = append(bodies, fmt.Sprintf(`{"query":"query Hello%d { hello }"}`, i))
+	}
+
+	for _, body := range bodies {
+		_, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodPost,
+			Body:       body,
+		})
+		h.Require().NoError(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+
+	h.NotContains(body, "Missing")
+	h.NotContains(body, "Unparseable")
+	h.Contains(body, fmt.Sprintf(`secretservice_graphql_operations_total{operation="Hello%d",code="OK"} 1`, maxOperationNames-1))
+	h.NotContains(body, fmt.Sprintf(`operation="Hello%d"`, maxOperationNames))
+	h.Contains(body, `secretservice_graphql_operations_total{operation="other",code="OK"} 1`)
+	h.Contains(body, `secretservice_graphql_operation_duration_seconds_count{operation="other"} 3`)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+)
+
+// PersistedQueries is a registry of vetted queries, keyed by the hex-encoded
+// SHA-256 hash of their text.
+type PersistedQueries map[string]string
+
+// LoadPersistedQueries reads a registry of persisted queries from a JSON file
+// with an object mapping hashes to queries. Every hash is checked against
+// its query.
+func LoadPersistedQueries(path string) (PersistedQueries, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open persisted queries file")
+	}
+	defer file.Close()
+
+	var queries map[string]string
+	if err := json.NewDecoder(file).Decode(&queries); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal persisted queries")
+	}
+
+	ret := make(PersistedQueries, len(queries))
+	for hash, query := range queries {
+		hash = strings.ToLower(hash)
+		if queryHash(query) != hash {
+			return nil, errors.Errorf("hash %s does not match its query", hash)
+		}
+		ret[hash] = query
+	}
+
+	return ret, nil
+}
+
+// WithPersistedQueries lets clients run queries from the registry by sending
+// just their hash as the "persistedQuery" extension. Unless rejectAdHoc is
+// set, clients can still send queries which are not in the registry.
+func WithPersistedQueries(queries PersistedQueries, rejectAdHoc bool) Option {
+	return func(h *Handler) {
+		h.persisted = queries
+		h.rejectAdHoc = rejectAdHoc
+	}
+}
+
+// resolveQuery returns the text of the query to execute, looking it up in
+// the registry if the request only carries its hash.
+func (h *Handler) resolveQuery(req *graphQLRequest) (string, error) {
+	var hash string
+	if persisted := req.Extensions.PersistedQuery; persisted != nil {
+		hash = strings.ToLower(persisted.SHA256Hash)
+	}
+
+	if req.Query == "" && hash != "" {
+		query, ok := h.persisted[hash]
+		if !ok {
+			return "", secretservice.NotFound("persisted query %s not found", hash)
+		}
+		return query, nil
+	}
+
+	if hash != "" && queryHash(req.Query) != hash {
+		return "", secretservice.Validation("query does not match its sha256Hash")
+	}
+
+	if h.rejectAdHoc {
+		if _, ok := h.persisted[queryHash(req.Query)]; !ok {
+			return "", secretservice.Forbidden("only persisted queries are allowed")
+		}
+	}
+
+	return req.Query, nil
+}
+
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
+)
+
+const helloQuery = "{ hello }"
+
+func (h *handlerTestSuite) TestLoadPersistedQueries_OK() {
+	path := h.persistedQueriesFile(`{"` + queryHash(helloQuery) + `": "{ hello }"}`)
+	defer os.Remove(path)
+
+	ret, err := LoadPersistedQueries(path)
+
+	h.NoError(err)
+	h.Equal(PersistedQueries{queryHash(helloQuery): helloQuery}, ret)
+}
+
+func (h *handlerTestSuite) TestLoadPersistedQueries_HashMismatch() {
+	path := h.persistedQueriesFile(`{"bacon": "{ hello }"}`)
+	defer os.Remove(path)
+
+	ret, err := LoadPersistedQueries(path)
+
+	h.Nil(ret)
+	h.EqualError(err, "hash bacon does not match its query")
+}
+
+func (h *handlerTestSuite) TestLoadPersistedQueries_MissingFile() {
+	ret, err := LoadPersistedQueries("/nonexistent/queries.json")
+
+	h.Nil(ret)
+	h.Error(err)
+}
+
+func (h *handlerTestSuite) TestPersistedQuery_ByHash() {
+	h.withPersistedQueries(false)
+
+	ret := h.getPersisted(queryHash(helloQuery), "")
+
+	h.JSONEq(`{"data":{"hello":"bacon"}}`, ret.Body)
+}
+
+func (h *handlerTestSuite) TestPersistedQuery_UnknownHash() {
+	h.withPersistedQueries(false)
+
+	queryErr := h.queryError(`{"extensions":{"persistedQuery":{"sha256Hash":"bacon"}}}`)
+
+	h.Equal("persisted query bacon not found", queryErr.Message)
+	h.Equal(secretservice.CodeNotFound, queryErr.Extensions["code"])
+}
+
+func (h *handlerTestSuite) TestPersistedQuery_HashMismatch() {
+	h.withPersistedQueries(false)
+
+	queryErr := h.queryError(`{"query":"{ whoami }","extensions":{"persistedQuery":{"sha256Hash":"` + queryHash(helloQuery) + `"}}}`)
+
+	h.Equal("query does not match its sha256Hash", queryErr.Message)
+	h.Equal(secretservice.CodeValidation, queryErr.Extensions["code"])
+}
+
+func (h *handlerTestSuite) TestPersistedQuery_AdHocAllowed() {
+	h.withPersistedQueries(false)
+
+	ret := h.getPersisted("", "{ whoami }")
+
+	h.JSONEq(`{"data":{"whoami":null}}`, ret.Body)
+}
+
+func (h *handlerTestSuite) TestPersistedQuery_AdHocRejected() {
+	h.withPersistedQueries(true)
+
+	queryErr := h.queryError(`{"query":"{ whoami }"}`)
+
+	h.Equal("only persisted queries are allowed", queryErr.Message)
+	h.Equal(secretservice.CodeForbidden, queryErr.Extensions["code"])
+}
+
+func (h *handlerTestSuite) TestPersistedQuery_VettedQueryAllowed() {
+	h.withPersistedQueries(true)
+
+	ret := h.getPersisted("", helloQuery)
+
+	h.JSONEq(`{"data":{"hello":"bacon"}}`, ret.Body)
+}
+
+func (h *handlerTestSuite) withPersistedQueries(rejectAdHoc bool) {
+	h.sut = New(
+		graphql.MustParseSchema(testSchema, new(testResolver)),
+		WithPersistedQueries(PersistedQueries{queryHash(helloQuery): helloQuery}, rejectAdHoc),
+	)
+}
+
+func (h *handlerTestSuite) getPersisted(hash, query string) events.APIGatewayProxyResponse {
+	params := map[string]string{"query": query}
+	if hash != "" {
+		params["extensions"] = `{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}`
+	}
+
+	ret, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodGet,
+		QueryStringParameters: params,
+	})
+	h.Require().NoError(err)
+	h.Require().Equal(http.StatusOK, ret.StatusCode)
+
+	return ret
+}
+
+func (h *handlerTestSuite) persistedQueriesFile(content string) string {
+	file, err := ioutil.TempFile("", "queries")
+	h.Require().NoError(err)
+	defer file.Close()
+
+	_, err = file.WriteString(content)
+	h.Require().NoError(err)
+
+	return file.Name()
+}
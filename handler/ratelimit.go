@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/secretservice/ratelimit"
+)
+
+const anonymousPrincipal = "anonymous"
+
+// releaseFields are the mutations which count against the Releases limit,
+// as they create Releases either right away or once approved.
+var releaseFields = map[string]bool{
+	"approveRelease": true,
+	"createRelease":  true,
+	"requestRelease": true,
+}
+
+// RateLimits are the Limits applied to each principal. Every query operation
+// counts once against the Queries limit, while every top-level field of a
+// mutation counts once against the Mutations limit, and the ones creating
+// Releases against the Releases limit as well.
+type RateLimits struct {
+	Queries   ratelimit.Limit
+	Mutations ratelimit.Limit
+	Releases  ratelimit.Limit
+}
+
+// rateLimitedError rejects a request which exceeded one of the RateLimits.
+type rateLimitedError struct {
+	class      string
+	retryAfter time.Duration
+}
+
+func (r *rateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit for %s exceeded, retry in %s seconds", r.class, r.retryAfterSeconds())
+}
+
+// retryAfterSeconds is the value of the "Retry-After" header, rounded up to
+// a whole number of seconds.
+func (r *rateLimitedError) retryAfterSeconds() string {
+	seconds := int64(math.Ceil(r.retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("%d", seconds)
+}
+
+// WithRateLimits makes the Handler count operations of each principal using
+// the limiter, and reject requests exceeding the limits with a 429 response
+// before executing any of their operations. Requests without a principal
+// share a single set of limits.
+func WithRateLimits(limiter ratelimit.Limiter, limits RateLimits) Option {
+	return func(h *Handler) {
+		h.limiter = limiter
+		h.rateLimits = limits
+	}
+}
+
+// limitRate counts the operations of a request against the limits of the
+// principal. Operations which can not be parsed count as queries. Failures
+// of the limiter are logged, and do not prevent the request from executing.
+func (h *Handler) limitRate(ctx context.Context, reqs ...*graphQLRequest) error {
+	if h.limiter == nil {
+		return nil
+	}
+
+	principalID := anonymousPrincipal
+	if principal, ok := secretservice.PrincipalFromContext(ctx); ok {
+		principalID = principal.ID
+	}
+
+	for _, req := range reqs {
+		for _, limit := range h.operationLimits(req) {
+			retryAfter, err := h.limiter.Allow(ctx, limit.class+":"+principalID, limit.Limit)
+			if err != nil {
+				log.WithField("principal", principalID).Errorf("Could not apply rate limit: %v", err)
+				continue
+			}
+			if retryAfter > 0 {
+				return &rateLimitedError{class: limit.class, retryAfter: retryAfter}
+			}
+		}
+	}
+
+	return nil
+}
+
+// classLimit is a Limit along with the class of operations it applies to.
+type classLimit struct {
+	ratelimit.Limit
+	class string
+}
+
+// operationLimits returns the limits an operation counts against, once for
+// every element.
+func (h *Handler) operationLimits(req *graphQLRequest) []classLimit {
+	queries := []classLimit{{Limit: h.rateLimits.Queries, class: "queries"}}
+
+	query, err := h.resolveQuery(req)
+	if err != nil {
+		return queries
+	}
+
+	doc, operation, err := parseOperation(query, req.OperationName)
+	if err != nil || operation.Type != "mutation" {
+		return queries
+	}
+
+	var ret []classLimit
+	for _, field := range selectedFields(doc, operation.Selections, nil) {
+		ret = append(ret, classLimit{Limit: h.rateLimits.Mutations, class: "mutations"})
+		if releaseFields[field] {
+			ret = append(ret, classLimit{Limit: h.rateLimits.Releases, class: "releases"})
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice/ratelimit"
+	"github.com/pkg/errors"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, ratelimit.Limit) (time.Duration, error) {
+	return 0, errors.New("bacon")
+}
+
+func (h *handlerTestSuite) TestRateLimits_Queries() {
+	h.withRateLimits(ratelimit.NewMemoryLimiter(), RateLimits{Queries: ratelimit.Limit{Count: 1, Period: time.Hour}})
+
+	h.Equal(http.StatusOK, h.limited(`{"query":"{ hello }"}`, "first").StatusCode)
+
+	ret := h.limited(`{"query":"{ hello }"}`, "first")
+	h.Equal(http.StatusTooManyRequests, ret.StatusCode)
+	h.Equal("3600", ret.Headers["Retry-After"])
+	h.Equal("rate limit for queries exceeded, retry in 3600 seconds", ret.Body)
+
+	// Mutations and other principals are counted separately.
+	h.Equal(http.StatusOK, h.limited(incrementMutation, "first").StatusCode)
+	h.Equal(http.StatusOK, h.limited(`{"query":"{ hello }"}`, "second").StatusCode)
+}
+
+func (h *handlerTestSuite) TestRateLimits_Mutations() {
+	h.withRateLimits(ratelimit.NewMemoryLimiter(), RateLimits{Mutations: ratelimit.Limit{Count: 1, Period: time.Minute}})
+
+	h.Equal(http.StatusOK, h.limited(incrementMutation, "").StatusCode)
+	h.Equal(http.StatusTooManyRequests, h.limited(incrementMutation, "").StatusCode)
+	h.Equal(http.StatusOK, h.limited(`{"query":"{ hello }"}`, "").StatusCode)
+}
+
+func (h *handlerTestSuite) TestRateLimits_BatchIsRejectedAsAWhole() {
+	h.withRateLimits(ratelimit.NewMemoryLimiter(), RateLimits{Mutations: ratelimit.Limit{Count: 1, Period: time.Minute}})
+
+	ret := h.limited("["+incrementMutation+","+incrementMutation+"]", "")
+
+	h.Equal(http.StatusTooManyRequests, ret.StatusCode)
+	h.Equal(int32(0), h.resolver.counter)
+}
+
+func (h *handlerTestSuite) TestRateLimits_MutationsPerField() {
+	h.withRateLimits(ratelimit.NewMemoryLimiter(), RateLimits{Mutations: ratelimit.Limit{Count: 2, Period: time.Minute}})
+
+	ret := h.limited(`{"query":"mutation { first: increment(by: 1) second: increment(by: 1) third: increment(by: 1) }"}`, "")
+
+	h.Equal(http.StatusTooManyRequests, ret.StatusCode)
+	h.Equal(int32(0), h.resolver.counter)
+}
+
+func (h *handlerTestSuite) TestRateLimits_LimiterFailure() {
+	h.withRateLimits(failingLimiter{}, RateLimits{Queries: ratelimit.Limit{Count: 1, Period: time.Minute}})
+
+	h.Equal(http.StatusOK, h.limited(`{"query":"{ hello }"}`, "").StatusCode)
+}
+
+func (h *handlerTestSuite) TestRateLimits_ServeHTTP() {
+	h.withRateLimits(ratelimit.NewMemoryLimiter(), RateLimits{Queries: ratelimit.Limit{Count: 1, Period: time.Minute}})
+
+	var recorder *httptest.ResponseRecorder
+	for _, status := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		recorder = httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"query":"{ hello }"}`))
+
+		h.sut.ServeHTTP(recorder, request)
+
+		h.Equal(status, recorder.Code)
+	}
+	h.Equal("60", recorder.Header().Get("Retry-After"))
+}
+
+func (h *handlerTestSuite) TestOperationLimits_Releases() {
+	h.withRateLimits(ratelimit.NewMemoryLimiter(), RateLimits{
+		Queries:   ratelimit.Limit{Count: 1, Period: time.Second},
+		Mutations: ratelimit.Limit{Count: 2, Period: time.Second},
+		Releases:  ratelimit.Limit{Count: 3, Period: time.Second},
+	})
+
+	ret := h.sut.operationLimits(&graphQLRequest{Query: `mutation { createRelease(scopeId: "scope") { id } }`})
+
+	h.Equal([]classLimit{
+		{Limit: ratelimit.Limit{Count: 2, Period: time.Second}, class: "mutations"},
+		{Limit: ratelimit.Limit{Count: 3, Period: time.Second}, class: "releases"},
+	}, ret)
+
+	ret = h.sut.operationLimits(&graphQLRequest{Query: `
+		mutation {
+			first: createRelease(scopeId: "scope") { id }
+			...Release
+			... on Mutation { addVariable(scopeId: "scope", variable: {}) { id } }
+		}
+
+		fragment Release on Mutation {
+			second: requestRelease(scopeId: "scope", message: "bacon") { id }
+			...Release
+		}
+	`})
+
+	mutations := classLimit{Limit: ratelimit.Limit{Count: 2, Period: time.Second}, class: "mutations"}
+	releases := classLimit{Limit: ratelimit.Limit{Count: 3, Period: time.Second}, class: "releases"}
+	h.Equal([]classLimit{mutations, releases, mutations, releases, mutations}, ret)
+
+	ret = h.sut.operationLimits(&graphQLRequest{Query: "not a query"})
+
+	h.Equal([]classLimit{{Limit: ratelimit.Limit{Count: 1, Period: time.Second}, class: "queries"}}, ret)
+}
+
+func (h *handlerTestSuite) withRateLimits(limiter ratelimit.Limiter, limits RateLimits) {
+	h.resolver = new(testResolver)
+	h.sut = New(graphql.MustParseSchema(testSchema, h.resolver), WithRateLimits(limiter, limits))
+}
+
+func (h *handlerTestSuite) limited(body, principalID string) events.APIGatewayProxyResponse {
+	request := events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Body: body}
+	if principalID != "" {
+		request.RequestContext.Authorizer = map[string]interface{}{"principalId": principalID}
+	}
+
+	ret, err := h.sut.HandleAPIGateway(context.Background(), request)
+	h.Require().NoError(err)
+
+	return ret
+}
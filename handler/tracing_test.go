@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/marcinwyszynski/secretservice"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func (h *handlerTestSuite) TestTracing_RecordsOperations() {
+	recorder := h.withTracing()
+
+	_, err := h.sut.HandleAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Headers:    map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		Body:       `{"query":"query Forbidden { forbidden }","operationName":"Forbidden"}`,
+	})
+	h.Require().NoError(err)
+
+	spans := recorder.Ended()
+	h.Require().Len(spans, 1)
+	h.Equal("GraphQL operation", spans[0].Name())
+	h.Equal("4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	h.Equal(sdktrace.Status{Code: codes.Error, Description: secretservice.CodeForbidden}, spans[0].Status())
+}
+
+// withTracing replaces the global tracer provider and propagator until the end
+// of the test, and returns the recorder of the spans.
+func (h *handlerTestSuite) withTracing() *tracetest.SpanRecorder {
+	h.tracerProvider = otel.GetTracerProvider()
+	h.propagator = otel.GetTextMapPropagator()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return recorder
+}
@@ -0,0 +1,40 @@
+package secretservice
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/marcinwyszynski/ssmvars"
+)
+
+// ContentHash returns a hex-encoded HMAC-SHA256 of a set of Variables. The
+// hash does not depend on the order of Variables. It covers values of
+// write-only Variables too, so the key must be kept secret for the hash not
+// to reveal them to anyone able to guess a value.
+func ContentHash(key []byte, variables []*ssmvars.Variable) string {
+	sorted := make([]*ssmvars.Variable, len(variables))
+	copy(sorted, variables)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	// Marshaling a slice of structs with string and bool fields can not fail.
+	data, _ := json.Marshal(sorted)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ContentHashKeyID returns a hex-encoded identifier of a content hash key,
+// stored along with content hashes to tell which key they were computed
+// with. It does not reveal the key.
+func ContentHashKeyID(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("secretservice content hash key"))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
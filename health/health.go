@@ -0,0 +1,119 @@
+// Package health serves the endpoints used to probe the service: "/healthz"
+// tells that the process is up, "/readyz" that the Backend is reachable and
+// "/version" which build of the service is running.
+package health
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/marcinwyszynski/secretservice"
+)
+
+const readinessTimeout = 5 * time.Second
+
+// BuildInfo describes the running build of the service.
+type BuildInfo struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	GoVersion  string `json:"goVersion"`
+	SchemaHash string `json:"schemaHash"`
+}
+
+// NewBuildInfo returns the BuildInfo of a given version and commit, along
+// with the Go version and a hex-encoded SHA-256 hash of the GraphQL schema.
+func NewBuildInfo(version, commit string) BuildInfo {
+	sum := sha256.Sum256([]byte(secretservice.Schema))
+
+	return BuildInfo{
+		Version:    version,
+		Commit:     commit,
+		GoVersion:  runtime.Version(),
+		SchemaHash: hex.EncodeToString(sum[:]),
+	}
+}
+
+// Handler serves the probe endpoints.
+type Handler struct {
+	backend secretservice.Backend
+	info    BuildInfo
+	timeout time.Duration
+}
+
+// New returns an instance of a Handler. The service is ready once the Backend
+// reports so, or right away if the Backend does not implement
+// secretservice.ReadinessChecker.
+func New(backend secretservice.Backend, info BuildInfo) *Handler {
+	return &Handler{backend: backend, info: info, timeout: readinessTimeout}
+}
+
+// Register adds the endpoints to a ServeMux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", h.serveHealth)
+	mux.HandleFunc("/readyz", h.serveReady)
+	mux.HandleFunc("/version", h.serveVersion)
+}
+
+func (h *Handler) serveHealth(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r) {
+		return
+	}
+	writeText(w, http.StatusOK, "ok")
+}
+
+// serveReady checks the Backend. Failures are logged, but not exposed, as
+// AWS error text may reveal internal details like bucket names.
+func (h *Handler) serveReady(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r) {
+		return
+	}
+
+	checker, ok := h.backend.(secretservice.ReadinessChecker)
+	if !ok {
+		writeText(w, http.StatusOK, "ok")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	if err := checker.Ready(ctx); err != nil {
+		log.Errorf("Backend is not ready: %v", err)
+		writeText(w, http.StatusServiceUnavailable, "not ready")
+		return
+	}
+
+	writeText(w, http.StatusOK, "ok")
+}
+
+func (h *Handler) serveVersion(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r) {
+		return
+	}
+
+	// Marshaling a struct with string fields can not fail.
+	data, _ := json.Marshal(h.info)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	http.Error(w, "only GET and HEAD requests are supported", http.StatusMethodNotAllowed)
+	return false
+}
+
+func writeText(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(text + "\n"))
+}
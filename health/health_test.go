@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/stretchr/testify/suite"
+)
+
+type checkingBackend struct {
+	secretservice.Backend
+
+	err error
+}
+
+func (c *checkingBackend) Ready(context.Context) error {
+	return c.err
+}
+
+type healthTestSuite struct {
+	suite.Suite
+
+	backend *checkingBackend
+	mux     *http.ServeMux
+}
+
+func (h *healthTestSuite) SetupTest() {
+	h.backend = new(checkingBackend)
+	h.mux = http.NewServeMux()
+	New(h.backend, NewBuildInfo("1.0.0", "abc")).Register(h.mux)
+}
+
+func (h *healthTestSuite) TestHealth() {
+	ret := h.get("/healthz")
+
+	h.Equal(http.StatusOK, ret.Code)
+	h.Equal("ok\n", ret.Body.String())
+}
+
+func (h *healthTestSuite) TestReady_OK() {
+	ret := h.get("/readyz")
+
+	h.Equal(http.StatusOK, ret.Code)
+}
+
+func (h *healthTestSuite) TestReady_Failure() {
+	h.backend.err = errors.New("bacon")
+
+	ret := h.get("/readyz")
+
+	h.Equal(http.StatusServiceUnavailable, ret.Code)
+	h.Equal("not ready\n", ret.Body.String())
+}
+
+func (h *healthTestSuite) TestReady_WithoutChecker() {
+	h.mux = http.NewServeMux()
+	New(struct{ secretservice.Backend }{}, BuildInfo{}).Register(h.mux)
+
+	ret := h.get("/readyz")
+
+	h.Equal(http.StatusOK, ret.Code)
+}
+
+func (h *healthTestSuite) TestVersion() {
+	ret := h.get("/version")
+
+	h.Equal(http.StatusOK, ret.Code)
+	h.Equal("application/json", ret.Header().Get("Content-Type"))
+
+	var info BuildInfo
+	h.Require().NoError(json.Unmarshal(ret.Body.Bytes(), &info))
+	h.Equal("1.0.0", info.Version)
+	h.Equal("abc", info.Commit)
+	h.Equal(runtime.Version(), info.GoVersion)
+	h.Len(info.SchemaHash, 64)
+}
+
+func (h *healthTestSuite) TestMethodNotAllowed() {
+	ret := httptest.NewRecorder()
+	h.mux.ServeHTTP(ret, httptest.NewRequest(http.MethodPost, "/healthz", nil))
+
+	h.Equal(http.StatusMethodNotAllowed, ret.Code)
+}
+
+func (h *healthTestSuite) get(path string) *httptest.ResponseRecorder {
+	ret := httptest.NewRecorder()
+	h.mux.ServeHTTP(ret, httptest.NewRequest(http.MethodGet, path, nil))
+	return ret
+}
+
+func TestHealth(t *testing.T) {
+	suite.Run(t, new(healthTestSuite))
+}
@@ -0,0 +1,104 @@
+package idempotency
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+)
+
+// DynamoDBStore is a Store shared by all instances of the service. It keeps
+// responses in items of a DynamoDB table with a string partition key called
+// "key". Items carry their expiry as a Unix timestamp in the "expires"
+// attribute, to be used as the TTL attribute of the table. Responses are kept
+// as they were sent, Variable values included, so the table should be
+// encrypted with a customer managed KMS key.
+type DynamoDBStore struct {
+	api       dynamodbiface.DynamoDBAPI
+	tableName *string
+	now       func() time.Time
+}
+
+// NewDynamoDBStore returns an instance of a DynamoDBStore.
+func NewDynamoDBStore(api dynamodbiface.DynamoDBAPI, tableName string) *DynamoDBStore {
+	return &DynamoDBStore{api: api, tableName: aws.String(tableName), now: time.Now}
+}
+
+// Begin claims a key, or returns the response stored under it. Items which
+// have expired but have not been removed by DynamoDB yet are claimed again.
+func (d *DynamoDBStore) Begin(ctx context.Context, key string, ttl time.Duration) ([]byte, error) {
+	now := d.now()
+
+	_, err := d.api.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           d.tableName,
+		Item:                d.item(key, now.Add(ttl)),
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR #expires <= :now"),
+		ExpressionAttributeNames: map[string]*string{
+			"#key":     aws.String("key"),
+			"#expires": aws.String("expires"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(strconv.FormatInt(now.Unix(), 10))},
+		},
+	})
+
+	if awsErr, ok := errors.Cause(err).(awserr.Error); !ok || awsErr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+		return nil, errors.Wrap(err, "could not claim idempotency key in DynamoDB")
+	}
+
+	output, err := d.api.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      d.tableName,
+		Key:            d.key(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get idempotency key from DynamoDB")
+	}
+
+	if response, ok := output.Item["response"]; ok && response.B != nil {
+		return response.B, nil
+	}
+
+	// The item may also have been abandoned in the meantime, but the client
+	// can not tell it from one still in progress either way.
+	return nil, secretservice.Conflict("a request with the same idempotency key is in progress")
+}
+
+// Complete stores the response under a key.
+func (d *DynamoDBStore) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	item := d.item(key, d.now().Add(ttl))
+	item["response"] = &dynamodb.AttributeValue{B: response}
+
+	_, err := d.api.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: d.tableName,
+		Item:      item,
+	})
+
+	return errors.Wrap(err, "could not store response in DynamoDB")
+}
+
+// Abandon releases a key.
+func (d *DynamoDBStore) Abandon(ctx context.Context, key string) error {
+	_, err := d.api.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: d.tableName,
+		Key:       d.key(key),
+	})
+
+	return errors.Wrap(err, "could not release idempotency key in DynamoDB")
+}
+
+func (d *DynamoDBStore) key(key string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"key": {S: aws.String(key)}}
+}
+
+func (d *DynamoDBStore) item(key string, expires time.Time) map[string]*dynamodb.AttributeValue {
+	ret := d.key(key)
+	ret["expires"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expires.Unix(), 10))}
+	return ret
+}
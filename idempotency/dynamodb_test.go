@@ -0,0 +1,179 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type mockDynamoDB struct {
+	mock.Mock
+	dynamodbiface.DynamoDBAPI
+}
+
+func (m *mockDynamoDB) DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	args := m.Called(ctx, input, opts)
+	return args.Get(0).(*dynamodb.DeleteItemOutput), args.Error(1)
+}
+
+func (m *mockDynamoDB) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	args := m.Called(ctx, input, opts)
+	return args.Get(0).(*dynamodb.GetItemOutput), args.Error(1)
+}
+
+func (m *mockDynamoDB) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	args := m.Called(ctx, input, opts)
+	return args.Get(0).(*dynamodb.PutItemOutput), args.Error(1)
+}
+
+type dynamoDBTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	api *mockDynamoDB
+	sut *DynamoDBStore
+}
+
+func (d *dynamoDBTestSuite) SetupTest() {
+	d.ctx = context.Background()
+	d.api = new(mockDynamoDB)
+	d.sut = NewDynamoDBStore(d.api, "table")
+	d.sut.now = func() time.Time { return time.Unix(1564660845, 0) }
+}
+
+func (d *dynamoDBTestSuite) TestBegin_Claims() {
+	d.withClaim(nil)
+
+	ret, err := d.sut.Begin(d.ctx, "key", time.Minute)
+
+	d.NoError(err)
+	d.Nil(ret)
+	d.api.AssertNotCalled(d.T(), "GetItemWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (d *dynamoDBTestSuite) TestBegin_ClaimsExpired() {
+	d.api.On(
+		"PutItemWithContext",
+		d.ctx,
+		mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			// Claims which were never completed expire like stored responses.
+			return aws.StringValue(input.ConditionExpression) == "attribute_not_exists(#key) OR #expires <= :now" &&
+				aws.StringValue(input.ExpressionAttributeNames["#expires"]) == "expires"
+		}),
+		[]request.Option(nil),
+	).Return(new(dynamodb.PutItemOutput), nil)
+
+	ret, err := d.sut.Begin(d.ctx, "key", time.Minute)
+
+	d.NoError(err)
+	d.Nil(ret)
+}
+
+func (d *dynamoDBTestSuite) TestBegin_Completed() {
+	d.withClaim(awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "bacon", nil))
+	d.withGetItem(map[string]*dynamodb.AttributeValue{
+		"key":      {S: aws.String("key")},
+		"response": {B: []byte("bacon")},
+	}, nil)
+
+	ret, err := d.sut.Begin(d.ctx, "key", time.Minute)
+
+	d.NoError(err)
+	d.Equal([]byte("bacon"), ret)
+}
+
+func (d *dynamoDBTestSuite) TestBegin_InProgress() {
+	d.withClaim(awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "bacon", nil))
+	d.withGetItem(map[string]*dynamodb.AttributeValue{"key": {S: aws.String("key")}}, nil)
+
+	_, err := d.sut.Begin(d.ctx, "key", time.Minute)
+
+	d.EqualError(err, "a request with the same idempotency key is in progress")
+	d.Equal(secretservice.CodeConflict, secretservice.ErrorCode(err))
+}
+
+func (d *dynamoDBTestSuite) TestBegin_ClaimError() {
+	d.withClaim(errors.New("bacon"))
+
+	_, err := d.sut.Begin(d.ctx, "key", time.Minute)
+
+	d.EqualError(err, "could not claim idempotency key in DynamoDB: bacon")
+}
+
+func (d *dynamoDBTestSuite) TestBegin_GetError() {
+	d.withClaim(awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "bacon", nil))
+	d.withGetItem(nil, errors.New("bacon"))
+
+	_, err := d.sut.Begin(d.ctx, "key", time.Minute)
+
+	d.EqualError(err, "could not get idempotency key from DynamoDB: bacon")
+}
+
+func (d *dynamoDBTestSuite) TestComplete() {
+	d.api.On(
+		"PutItemWithContext",
+		d.ctx,
+		mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return input.ConditionExpression == nil &&
+				aws.StringValue(input.Item["key"].S) == "key" &&
+				aws.StringValue(input.Item["expires"].N) == "1564664445" &&
+				string(input.Item["response"].B) == "bacon"
+		}),
+		[]request.Option(nil),
+	).Return(new(dynamodb.PutItemOutput), nil)
+
+	d.NoError(d.sut.Complete(d.ctx, "key", []byte("bacon"), time.Hour))
+}
+
+func (d *dynamoDBTestSuite) TestAbandon() {
+	d.api.On(
+		"DeleteItemWithContext",
+		d.ctx,
+		mock.MatchedBy(func(input *dynamodb.DeleteItemInput) bool {
+			return aws.StringValue(input.TableName) == "table" && aws.StringValue(input.Key["key"].S) == "key"
+		}),
+		[]request.Option(nil),
+	).Return(new(dynamodb.DeleteItemOutput), errors.New("bacon"))
+
+	d.EqualError(d.sut.Abandon(d.ctx, "key"), "could not release idempotency key in DynamoDB: bacon")
+}
+
+func (d *dynamoDBTestSuite) withClaim(err error) {
+	d.api.On(
+		"PutItemWithContext",
+		d.ctx,
+		mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return aws.StringValue(input.TableName) == "table" &&
+				input.ConditionExpression != nil &&
+				aws.StringValue(input.Item["key"].S) == "key" &&
+				aws.StringValue(input.Item["expires"].N) == "1564660905" &&
+				aws.StringValue(input.ExpressionAttributeValues[":now"].N) == "1564660845"
+		}),
+		[]request.Option(nil),
+	).Return(new(dynamodb.PutItemOutput), err)
+}
+
+func (d *dynamoDBTestSuite) withGetItem(item map[string]*dynamodb.AttributeValue, err error) {
+	d.api.On(
+		"GetItemWithContext",
+		d.ctx,
+		mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+			return aws.StringValue(input.Key["key"].S) == "key" && aws.BoolValue(input.ConsistentRead)
+		}),
+		[]request.Option(nil),
+	).Return(&dynamodb.GetItemOutput{Item: item}, err)
+}
+
+func TestDynamoDBStore(t *testing.T) {
+	suite.Run(t, new(dynamoDBTestSuite))
+}
@@ -0,0 +1,24 @@
+// Package idempotency keeps responses to mutations, so that retried requests
+// get the original response instead of being executed again.
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps responses under idempotency keys for a limited time.
+type Store interface {
+	// Begin claims a key for a request about to be executed, for at most
+	// ttl, after which it can be claimed again. It returns the stored response if a request with the key has
+	// completed already, and fails with a Conflict error if one is still
+	// being executed.
+	Begin(ctx context.Context, key string, ttl time.Duration) ([]byte, error)
+
+	// Complete stores the response under a claimed key for ttl.
+	Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error
+
+	// Abandon releases a claimed key without storing a response, so that
+	// the request can be retried.
+	Abandon(ctx context.Context, key string) error
+}
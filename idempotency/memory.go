@@ -0,0 +1,73 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/marcinwyszynski/secretservice"
+)
+
+type memoryEntry struct {
+	response []byte
+	expires  time.Time
+}
+
+// MemoryStore is a Store which keeps responses in memory. It only protects
+// against retries reaching the same process, so it is best suited for a
+// single long-running server.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	now     func() time.Time
+}
+
+// NewMemoryStore returns an instance of a MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry), now: time.Now}
+}
+
+// Begin claims a key, or returns the response stored under it.
+func (m *MemoryStore) Begin(ctx context.Context, key string, ttl time.Duration) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.expire(now)
+
+	if entry, exists := m.entries[key]; exists {
+		if entry.response == nil {
+			return nil, secretservice.Conflict("a request with the same idempotency key is in progress")
+		}
+		return entry.response, nil
+	}
+
+	m.entries[key] = &memoryEntry{expires: now.Add(ttl)}
+	return nil, nil
+}
+
+// Complete stores the response under a key.
+func (m *MemoryStore) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = &memoryEntry{response: response, expires: m.now().Add(ttl)}
+	return nil
+}
+
+// Abandon releases a key.
+func (m *MemoryStore) Abandon(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *MemoryStore) expire(now time.Time) {
+	for key, entry := range m.entries {
+		if !now.Before(entry.expires) {
+			delete(m.entries, key)
+		}
+	}
+}
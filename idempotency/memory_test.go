@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/stretchr/testify/suite"
+)
+
+type memoryTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	now time.Time
+	sut *MemoryStore
+}
+
+func (m *memoryTestSuite) SetupTest() {
+	m.ctx = context.Background()
+	m.now = time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+	m.sut = NewMemoryStore()
+	m.sut.now = func() time.Time { return m.now }
+}
+
+func (m *memoryTestSuite) TestBegin_Claims() {
+	ret, err := m.sut.Begin(m.ctx, "key", time.Minute)
+
+	m.NoError(err)
+	m.Nil(ret)
+}
+
+func (m *memoryTestSuite) TestBegin_InProgress() {
+	_, err := m.sut.Begin(m.ctx, "key", time.Minute)
+	m.Require().NoError(err)
+
+	_, err = m.sut.Begin(m.ctx, "key", time.Minute)
+
+	m.EqualError(err, "a request with the same idempotency key is in progress")
+	m.Equal(secretservice.CodeConflict, secretservice.ErrorCode(err))
+}
+
+func (m *memoryTestSuite) TestBegin_Completed() {
+	_, err := m.sut.Begin(m.ctx, "key", time.Minute)
+	m.Require().NoError(err)
+	m.Require().NoError(m.sut.Complete(m.ctx, "key", []byte("bacon"), time.Hour))
+
+	ret, err := m.sut.Begin(m.ctx, "key", time.Minute)
+
+	m.NoError(err)
+	m.Equal([]byte("bacon"), ret)
+}
+
+func (m *memoryTestSuite) TestBegin_Abandoned() {
+	_, err := m.sut.Begin(m.ctx, "key", time.Minute)
+	m.Require().NoError(err)
+	m.Require().NoError(m.sut.Abandon(m.ctx, "key"))
+
+	ret, err := m.sut.Begin(m.ctx, "key", time.Minute)
+
+	m.NoError(err)
+	m.Nil(ret)
+}
+
+func (m *memoryTestSuite) TestBegin_Expired() {
+	_, err := m.sut.Begin(m.ctx, "key", time.Minute)
+	m.Require().NoError(err)
+	m.Require().NoError(m.sut.Complete(m.ctx, "key", []byte("bacon"), time.Hour))
+
+	m.now = m.now.Add(time.Hour)
+	ret, err := m.sut.Begin(m.ctx, "key", time.Minute)
+
+	m.NoError(err)
+	m.Nil(ret)
+	m.Len(m.sut.entries, 1)
+}
+
+func (m *memoryTestSuite) TestBegin_ClaimExpired() {
+	_, err := m.sut.Begin(m.ctx, "key", time.Minute)
+	m.Require().NoError(err)
+
+	m.now = m.now.Add(time.Minute)
+	ret, err := m.sut.Begin(m.ctx, "key", time.Minute)
+
+	m.NoError(err)
+	m.Nil(ret)
+}
+
+func TestMemoryStore(t *testing.T) {
+	suite.Run(t, new(memoryTestSuite))
+}
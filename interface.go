@@ -11,8 +11,38 @@ type Backend interface {
 	ssmvars.ReadWriter
 
 	ArchiveRelease(ctx context.Context, scopeName, releaseID string) error
-	CreateRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable) (*Release, error)
+	ContentHash(variables []*ssmvars.Variable) string
+	CreateProposal(ctx context.Context, proposal *Proposal) (*Proposal, error)
+	CreateRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable, baseReleaseID string) (*Release, error)
+	DeleteMetadata(ctx context.Context, scopeName, variableName string) error
+	GetProposal(ctx context.Context, scopeName, proposalID string) (*Proposal, error)
 	GetRelease(ctx context.Context, scopeName, releaseID string) (*Release, error)
+	GetScheduledOperation(ctx context.Context, scopeName, operationID string) (*ScheduledOperation, error)
+	ListMetadata(ctx context.Context, scopeName string) (map[string]*Metadata, error)
+	ListPendingOperations(ctx context.Context, scopeName string) ([]*ScheduledOperation, error)
+	ListProposals(ctx context.Context, scopeName string) ([]*Proposal, error)
 	ListReleases(ctx context.Context, scopeName string, before *string) ([]string, error)
+	ListScheduledOperations(ctx context.Context, scopeName string) ([]*ScheduledOperation, error)
+	ScheduleOperation(ctx context.Context, operation *ScheduledOperation) (*ScheduledOperation, error)
 	Scope(ctx context.Context, scopeName string) (*Scope, error)
+	SetMetadata(ctx context.Context, scopeName, variableName string, metadata *Metadata) error
+	UpdateProposal(ctx context.Context, proposal *Proposal) error
+	UpdateScheduledOperation(ctx context.Context, operation *ScheduledOperation) error
+}
+
+// ReadinessChecker is implemented by Backends which can tell whether the
+// services they depend on are reachable.
+type ReadinessChecker interface {
+	Ready(ctx context.Context) error
+}
+
+// Notifier delivers notifications about Variables which need attention.
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// Signer signs serialized Releases and verifies their signatures.
+type Signer interface {
+	Sign(ctx context.Context, data []byte) ([]byte, error)
+	Verify(ctx context.Context, data, signature []byte) error
 }
@@ -0,0 +1,183 @@
+// Package loader deduplicates reads from the Backend made while executing a
+// single GraphQL operation. Resolvers run concurrently and often need the
+// same Release, Scope or workspace, so each of them is loaded at most once
+// per operation.
+package loader
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/pkg/errors"
+)
+
+type contextKey struct{}
+
+// cache holds the results of reads, keyed by the method and its arguments.
+type cache struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// call is a read which is either in flight, or has completed. Callers
+// waiting for the same read share its result.
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// WithCache returns a context carrying a new, empty cache. Reads made through
+// a Backend with contexts derived from it share their results.
+func WithCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, &cache{calls: make(map[string]*call)})
+}
+
+// Backend wraps a secretservice.Backend, caching GetRelease, Scope and
+// ListVariables for as long as the context carries the same cache. Without
+// a cache in the context, calls go straight to the wrapped Backend. Writes
+// which could make cached values stale clear the cache.
+type Backend struct {
+	secretservice.Backend
+}
+
+// New returns an instance of a Backend.
+func New(backend secretservice.Backend) *Backend {
+	return &Backend{Backend: backend}
+}
+
+// GetRelease returns a Release, loading it once per cache.
+func (b *Backend) GetRelease(ctx context.Context, scopeName, releaseID string) (*secretservice.Release, error) {
+	value, err := load(ctx, cacheKey("release", scopeName, releaseID), func() (interface{}, error) {
+		return b.Backend.GetRelease(ctx, scopeName, releaseID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	release, ok := value.(*secretservice.Release)
+	if !ok || release == nil {
+		return nil, nil
+	}
+
+	// Callers are free to modify what they get, so they get a copy.
+	ret := *release
+	ret.Variables = append([]*ssmvars.Variable(nil), ret.Variables...)
+	return &ret, nil
+}
+
+// Scope returns a Scope, loading it once per cache.
+func (b *Backend) Scope(ctx context.Context, scopeName string) (*secretservice.Scope, error) {
+	value, err := load(ctx, cacheKey("scope", scopeName), func() (interface{}, error) {
+		return b.Backend.Scope(ctx, scopeName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scope, ok := value.(*secretservice.Scope)
+	if !ok || scope == nil {
+		return nil, nil
+	}
+
+	ret := *scope
+	return &ret, nil
+}
+
+// ListVariables returns Variables in a namespace, listing them once per
+// cache.
+func (b *Backend) ListVariables(ctx context.Context, namespace string) ([]*ssmvars.Variable, error) {
+	value, err := load(ctx, cacheKey("variables", namespace), func() (interface{}, error) {
+		return b.Backend.ListVariables(ctx, namespace)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	variables, _ := value.([]*ssmvars.Variable)
+	return append([]*ssmvars.Variable(nil), variables...), nil
+}
+
+// ArchiveRelease archives a Release and clears the cache.
+func (b *Backend) ArchiveRelease(ctx context.Context, scopeName, releaseID string) error {
+	defer invalidate(ctx)
+	return b.Backend.ArchiveRelease(ctx, scopeName, releaseID)
+}
+
+// CreateRelease creates a Release and clears the cache.
+func (b *Backend) CreateRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable, baseReleaseID string) (*secretservice.Release, error) {
+	defer invalidate(ctx)
+	return b.Backend.CreateRelease(ctx, scopeName, variables, baseReleaseID)
+}
+
+// CreateVariable creates or updates a Variable and clears the cache.
+func (b *Backend) CreateVariable(ctx context.Context, namespace string, variable *ssmvars.Variable) (*ssmvars.Variable, error) {
+	defer invalidate(ctx)
+	return b.Backend.CreateVariable(ctx, namespace, variable)
+}
+
+// DeleteVariable deletes a Variable and clears the cache.
+func (b *Backend) DeleteVariable(ctx context.Context, namespace, name string) (*ssmvars.Variable, error) {
+	defer invalidate(ctx)
+	return b.Backend.DeleteVariable(ctx, namespace, name)
+}
+
+// Reset deletes all Variables in a namespace and clears the cache.
+func (b *Backend) Reset(ctx context.Context, namespace string) error {
+	defer invalidate(ctx)
+	return b.Backend.Reset(ctx, namespace)
+}
+
+// load returns the result of a read, sharing it with other callers of the
+// same key. Failed reads are not cached, so that they can be retried.
+func load(ctx context.Context, key string, read func() (interface{}, error)) (interface{}, error) {
+	cache, ok := ctx.Value(contextKey{}).(*cache)
+	if !ok {
+		return read()
+	}
+
+	cache.mu.Lock()
+	if existing, ok := cache.calls[key]; ok {
+		cache.mu.Unlock()
+		<-existing.done
+		return existing.value, existing.err
+	}
+
+	// Waiters must not block forever if the read panics.
+	current := &call{done: make(chan struct{}), err: errors.New("read did not complete")}
+	cache.calls[key] = current
+	cache.mu.Unlock()
+
+	func() {
+		defer close(current.done)
+		current.value, current.err = read()
+	}()
+
+	if current.err != nil {
+		cache.mu.Lock()
+		if cache.calls[key] == current {
+			delete(cache.calls, key)
+		}
+		cache.mu.Unlock()
+	}
+
+	return current.value, current.err
+}
+
+func invalidate(ctx context.Context) {
+	cache, ok := ctx.Value(contextKey{}).(*cache)
+	if !ok {
+		return
+	}
+
+	cache.mu.Lock()
+	cache.calls = make(map[string]*call)
+	cache.mu.Unlock()
+}
+
+func cacheKey(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,161 @@
+package loader
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type mockBackend struct {
+	mock.Mock
+	secretservice.Backend
+}
+
+func (m *mockBackend) CreateVariable(ctx context.Context, namespace string, variable *ssmvars.Variable) (*ssmvars.Variable, error) {
+	args := m.Called(ctx, namespace, variable)
+	return args.Get(0).(*ssmvars.Variable), args.Error(1)
+}
+
+func (m *mockBackend) GetRelease(ctx context.Context, scopeName, releaseID string) (*secretservice.Release, error) {
+	args := m.Called(ctx, scopeName, releaseID)
+	return args.Get(0).(*secretservice.Release), args.Error(1)
+}
+
+func (m *mockBackend) ListVariables(ctx context.Context, namespace string) ([]*ssmvars.Variable, error) {
+	args := m.Called(ctx, namespace)
+	return args.Get(0).([]*ssmvars.Variable), args.Error(1)
+}
+
+func (m *mockBackend) Scope(ctx context.Context, scopeName string) (*secretservice.Scope, error) {
+	args := m.Called(ctx, scopeName)
+	return args.Get(0).(*secretservice.Scope), args.Error(1)
+}
+
+type loaderTestSuite struct {
+	suite.Suite
+
+	ctx     context.Context
+	backend *mockBackend
+	sut     *Backend
+}
+
+func (l *loaderTestSuite) SetupTest() {
+	l.ctx = WithCache(context.Background())
+	l.backend = new(mockBackend)
+	l.sut = New(l.backend)
+}
+
+func (l *loaderTestSuite) TestGetRelease_Deduplicated() {
+	l.withGetRelease(nil).Once()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ret, err := l.sut.GetRelease(l.ctx, "scope", "release")
+			l.NoError(err)
+			l.Equal("release", ret.ID)
+		}()
+	}
+	wg.Wait()
+
+	l.backend.AssertExpectations(l.T())
+}
+
+func (l *loaderTestSuite) TestGetRelease_ReturnsCopies() {
+	l.withGetRelease(nil).Once()
+
+	first, err := l.sut.GetRelease(l.ctx, "scope", "release")
+	l.Require().NoError(err)
+	first.Live = false
+	first.Variables[0] = &ssmvars.Variable{Name: "bacon"}
+
+	second, err := l.sut.GetRelease(l.ctx, "scope", "release")
+	l.Require().NoError(err)
+	l.True(second.Live)
+	l.Equal("name", second.Variables[0].Name)
+}
+
+func (l *loaderTestSuite) TestGetRelease_ErrorNotCached() {
+	l.withGetRelease(errors.New("bacon")).Once()
+	l.withGetRelease(nil).Once()
+
+	_, err := l.sut.GetRelease(l.ctx, "scope", "release")
+	l.EqualError(err, "bacon")
+
+	ret, err := l.sut.GetRelease(l.ctx, "scope", "release")
+	l.NoError(err)
+	l.Equal("release", ret.ID)
+}
+
+func (l *loaderTestSuite) TestGetRelease_NoCache() {
+	l.withGetRelease(nil).Twice()
+
+	for i := 0; i < 2; i++ {
+		_, err := l.sut.GetRelease(context.Background(), "scope", "release")
+		l.NoError(err)
+	}
+
+	l.backend.AssertExpectations(l.T())
+}
+
+func (l *loaderTestSuite) TestScope_Cached() {
+	l.backend.On("Scope", mock.Anything, "scope").Return(&secretservice.Scope{Name: "scope"}, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		ret, err := l.sut.Scope(l.ctx, "scope")
+		l.NoError(err)
+		l.Equal("scope", ret.Name)
+	}
+
+	// Each cache is separate.
+	l.backend.On("Scope", mock.Anything, "scope").Return(&secretservice.Scope{Name: "scope"}, nil).Once()
+	_, err := l.sut.Scope(WithCache(context.Background()), "scope")
+	l.NoError(err)
+
+	l.backend.AssertExpectations(l.T())
+}
+
+func (l *loaderTestSuite) TestListVariables_InvalidatedByWrites() {
+	variable := &ssmvars.Variable{Name: "name", Value: "value"}
+	l.backend.On("ListVariables", mock.Anything, "workspace/scope").Return([]*ssmvars.Variable{variable}, nil).Twice()
+	l.backend.On("CreateVariable", mock.Anything, "workspace/scope", variable).Return(variable, nil)
+
+	_, err := l.sut.ListVariables(l.ctx, "workspace/scope")
+	l.Require().NoError(err)
+	_, err = l.sut.ListVariables(l.ctx, "workspace/scope")
+	l.Require().NoError(err)
+
+	_, err = l.sut.CreateVariable(l.ctx, "workspace/scope", variable)
+	l.Require().NoError(err)
+
+	ret, err := l.sut.ListVariables(l.ctx, "workspace/scope")
+	l.NoError(err)
+	l.Equal([]*ssmvars.Variable{variable}, ret)
+
+	l.backend.AssertExpectations(l.T())
+}
+
+func (l *loaderTestSuite) withGetRelease(err error) *mock.Call {
+	var release *secretservice.Release
+	if err == nil {
+		release = &secretservice.Release{
+			ID:        "release",
+			Live:      true,
+			Variables: []*ssmvars.Variable{{Name: "name", Value: "value"}},
+		}
+	}
+
+	return l.backend.On("GetRelease", mock.Anything, "scope", "release").Return(release, err)
+}
+
+func TestLoader(t *testing.T) {
+	suite.Run(t, new(loaderTestSuite))
+}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+)
+
+// Backend wraps a secretservice.Backend, recording the duration and the
+// error code of every call.
+type Backend struct {
+	secretservice.Backend
+
+	recorder Recorder
+}
+
+// NewBackend returns an instance of a Backend.
+func NewBackend(backend secretservice.Backend, recorder Recorder) *Backend {
+	return &Backend{Backend: backend, recorder: recorder}
+}
+
+// ArchiveRelease archives a Release.
+func (b *Backend) ArchiveRelease(ctx context.Context, scopeName, releaseID string) (err error) {
+	defer b.observe("ArchiveRelease", time.Now(), &err)
+	return b.Backend.ArchiveRelease(ctx, scopeName, releaseID)
+}
+
+// CreateProposal stores a new Proposal.
+func (b *Backend) CreateProposal(ctx context.Context, proposal *secretservice.Proposal) (ret *secretservice.Proposal, err error) {
+	defer b.observe("CreateProposal", time.Now(), &err)
+	return b.Backend.CreateProposal(ctx, proposal)
+}
+
+// CreateRelease creates a Release.
+func (b *Backend) CreateRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable, baseReleaseID string) (ret *secretservice.Release, err error) {
+	defer b.observe("CreateRelease", time.Now(), &err)
+	return b.Backend.CreateRelease(ctx, scopeName, variables, baseReleaseID)
+}
+
+// DeleteMetadata removes Metadata of a Variable.
+func (b *Backend) DeleteMetadata(ctx context.Context, scopeName, variableName string) (err error) {
+	defer b.observe("DeleteMetadata", time.Now(), &err)
+	return b.Backend.DeleteMetadata(ctx, scopeName, variableName)
+}
+
+// GetProposal returns a Proposal.
+func (b *Backend) GetProposal(ctx context.Context, scopeName, proposalID string) (ret *secretservice.Proposal, err error) {
+	defer b.observe("GetProposal", time.Now(), &err)
+	return b.Backend.GetProposal(ctx, scopeName, proposalID)
+}
+
+// GetRelease returns a Release.
+func (b *Backend) GetRelease(ctx context.Context, scopeName, releaseID string) (ret *secretservice.Release, err error) {
+	defer b.observe("GetRelease", time.Now(), &err)
+	return b.Backend.GetRelease(ctx, scopeName, releaseID)
+}
+
+// GetScheduledOperation returns a ScheduledOperation.
+func (b *Backend) GetScheduledOperation(ctx context.Context, scopeName, operationID string) (ret *secretservice.ScheduledOperation, err error) {
+	defer b.observe("GetScheduledOperation", time.Now(), &err)
+	return b.Backend.GetScheduledOperation(ctx, scopeName, operationID)
+}
+
+// ListMetadata returns Metadata of all Variables in a Scope.
+func (b *Backend) ListMetadata(ctx context.Context, scopeName string) (ret map[string]*secretservice.Metadata, err error) {
+	defer b.observe("ListMetadata", time.Now(), &err)
+	return b.Backend.ListMetadata(ctx, scopeName)
+}
+
+// ListPendingOperations returns pending ScheduledOperations in a Scope.
+func (b *Backend) ListPendingOperations(ctx context.Context, scopeName string) (ret []*secretservice.ScheduledOperation, err error) {
+	defer b.observe("ListPendingOperations", time.Now(), &err)
+	return b.Backend.ListPendingOperations(ctx, scopeName)
+}
+
+// ListProposals returns all Proposals in a Scope.
+func (b *Backend) ListProposals(ctx context.Context, scopeName string) (ret []*secretservice.Proposal, err error) {
+	defer b.observe("ListProposals", time.Now(), &err)
+	return b.Backend.ListProposals(ctx, scopeName)
+}
+
+// ListReleases returns a page of Release IDs.
+func (b *Backend) ListReleases(ctx context.Context, scopeName string, before *string) (ret []string, err error) {
+	defer b.observe("ListReleases", time.Now(), &err)
+	return b.Backend.ListReleases(ctx, scopeName, before)
+}
+
+// ListScheduledOperations returns all ScheduledOperations in a Scope.
+func (b *Backend) ListScheduledOperations(ctx context.Context, scopeName string) (ret []*secretservice.ScheduledOperation, err error) {
+	defer b.observe("ListScheduledOperations", time.Now(), &err)
+	return b.Backend.ListScheduledOperations(ctx, scopeName)
+}
+
+// ScheduleOperation stores a new ScheduledOperation.
+func (b *Backend) ScheduleOperation(ctx context.Context, operation *secretservice.ScheduledOperation) (ret *secretservice.ScheduledOperation, err error) {
+	defer b.observe("ScheduleOperation", time.Now(), &err)
+	return b.Backend.ScheduleOperation(ctx, operation)
+}
+
+// Scope returns a Scope by its name.
+func (b *Backend) Scope(ctx context.Context, scopeName string) (ret *secretservice.Scope, err error) {
+	defer b.observe("Scope", time.Now(), &err)
+	return b.Backend.Scope(ctx, scopeName)
+}
+
+// SetMetadata stores Metadata of a Variable.
+func (b *Backend) SetMetadata(ctx context.Context, scopeName, variableName string, metadata *secretservice.Metadata) (err error) {
+	defer b.observe("SetMetadata", time.Now(), &err)
+	return b.Backend.SetMetadata(ctx, scopeName, variableName, metadata)
+}
+
+// UpdateProposal stores changes to a Proposal.
+func (b *Backend) UpdateProposal(ctx context.Context, proposal *secretservice.Proposal) (err error) {
+	defer b.observe("UpdateProposal", time.Now(), &err)
+	return b.Backend.UpdateProposal(ctx, proposal)
+}
+
+// UpdateScheduledOperation stores changes to a ScheduledOperation.
+func (b *Backend) UpdateScheduledOperation(ctx context.Context, operation *secretservice.ScheduledOperation) (err error) {
+	defer b.observe("UpdateScheduledOperation", time.Now(), &err)
+	return b.Backend.UpdateScheduledOperation(ctx, operation)
+}
+
+// ListVariables returns all Variables in a namespace.
+func (b *Backend) ListVariables(ctx context.Context, namespace string) (ret []*ssmvars.Variable, err error) {
+	defer b.observe("ListVariables", time.Now(), &err)
+	return b.Backend.ListVariables(ctx, namespace)
+}
+
+// ShowVariable returns a single Variable.
+func (b *Backend) ShowVariable(ctx context.Context, namespace, name string) (ret *ssmvars.Variable, err error) {
+	defer b.observe("ShowVariable", time.Now(), &err)
+	return b.Backend.ShowVariable(ctx, namespace, name)
+}
+
+// CreateVariable creates or overwrites a Variable.
+func (b *Backend) CreateVariable(ctx context.Context, namespace string, variable *ssmvars.Variable) (ret *ssmvars.Variable, err error) {
+	defer b.observe("CreateVariable", time.Now(), &err)
+	return b.Backend.CreateVariable(ctx, namespace, variable)
+}
+
+// DeleteVariable deletes a Variable.
+func (b *Backend) DeleteVariable(ctx context.Context, namespace, name string) (ret *ssmvars.Variable, err error) {
+	defer b.observe("DeleteVariable", time.Now(), &err)
+	return b.Backend.DeleteVariable(ctx, namespace, name)
+}
+
+// Reset deletes all Variables in a namespace.
+func (b *Backend) Reset(ctx context.Context, namespace string) (err error) {
+	defer b.observe("Reset", time.Now(), &err)
+	return b.Backend.Reset(ctx, namespace)
+}
+
+func (b *Backend) observe(method string, start time.Time, err *error) {
+	b.recorder.ObserveBackendCall(method, ErrorCode(*err), time.Since(start))
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type mockBackend struct {
+	mock.Mock
+	secretservice.Backend
+}
+
+func (m *mockBackend) GetRelease(ctx context.Context, scopeName, releaseID string) (*secretservice.Release, error) {
+	args := m.Called(ctx, scopeName, releaseID)
+	return args.Get(0).(*secretservice.Release), args.Error(1)
+}
+
+func (m *mockBackend) ArchiveRelease(ctx context.Context, scopeName, releaseID string) error {
+	return m.Called(ctx, scopeName, releaseID).Error(0)
+}
+
+type mockRecorder struct {
+	mock.Mock
+}
+
+func (m *mockRecorder) ObserveOperation(operation, code string, duration time.Duration) {
+	m.Called(operation, code, duration)
+}
+
+func (m *mockRecorder) ObserveBackendCall(method, code string, duration time.Duration) {
+	m.Called(method, code, duration)
+}
+
+type backendTestSuite struct {
+	suite.Suite
+
+	ctx      context.Context
+	backend  *mockBackend
+	recorder *mockRecorder
+	sut      *Backend
+}
+
+func (b *backendTestSuite) SetupTest() {
+	b.ctx = context.Background()
+	b.backend = new(mockBackend)
+	b.recorder = new(mockRecorder)
+	b.sut = NewBackend(b.backend, b.recorder)
+}
+
+func (b *backendTestSuite) TestGetRelease_OK() {
+	release := &secretservice.Release{ID: "release"}
+	b.backend.On("GetRelease", b.ctx, "scope", "release").Return(release, nil)
+	b.recorder.On("ObserveBackendCall", "GetRelease", CodeOK, mock.AnythingOfType("time.Duration")).Return()
+
+	ret, err := b.sut.GetRelease(b.ctx, "scope", "release")
+
+	b.NoError(err)
+	b.Equal(release, ret)
+	b.recorder.AssertExpectations(b.T())
+}
+
+func (b *backendTestSuite) TestGetRelease_Failure() {
+	b.backend.On("GetRelease", b.ctx, "scope", "release").Return((*secretservice.Release)(nil), secretservice.NotFound("bacon"))
+	b.recorder.On("ObserveBackendCall", "GetRelease", secretservice.CodeNotFound, mock.AnythingOfType("time.Duration")).Return()
+
+	_, err := b.sut.GetRelease(b.ctx, "scope", "release")
+
+	b.EqualError(err, "bacon")
+	b.recorder.AssertExpectations(b.T())
+}
+
+func (b *backendTestSuite) TestArchiveRelease_UntypedFailure() {
+	b.backend.On("ArchiveRelease", b.ctx, "scope", "release").Return(errors.New("bacon"))
+	b.recorder.On("ObserveBackendCall", "ArchiveRelease", secretservice.CodeInternal, mock.AnythingOfType("time.Duration")).Return()
+
+	b.EqualError(b.sut.ArchiveRelease(b.ctx, "scope", "release"), "bacon")
+	b.recorder.AssertExpectations(b.T())
+}
+
+func TestBackend(t *testing.T) {
+	suite.Run(t, new(backendTestSuite))
+}
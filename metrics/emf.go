@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// EMF is a Recorder writing each observation as a line in the CloudWatch
+// embedded metric format. Lambda sends the lines to CloudWatch Logs, which
+// extracts the metrics from them.
+type EMF struct {
+	mu        sync.Mutex
+	w         io.Writer
+	namespace string
+	now       func() time.Time
+}
+
+// NewEMF returns an instance of an EMF recorder writing to w, usually
+// os.Stdout, with metrics in a given CloudWatch namespace.
+func NewEMF(w io.Writer, namespace string) *EMF {
+	return &EMF{w: w, namespace: namespace, now: time.Now}
+}
+
+// ObserveOperation records a GraphQL operation as the "Operations" and
+// "OperationDuration" metrics.
+func (e *EMF) ObserveOperation(operation, code string, duration time.Duration) {
+	e.write("Operation", operation, code, "Operations", "OperationDuration", duration)
+}
+
+// ObserveBackendCall records a call to a Backend method as the
+// "BackendCalls" and "BackendCallDuration" metrics.
+func (e *EMF) ObserveBackendCall(method, code string, duration time.Duration) {
+	e.write("Method", method, code, "BackendCalls", "BackendCallDuration", duration)
+}
+
+// write writes a count and a duration, aggregated both by the dimension and
+// by the dimension along with the code.
+func (e *EMF) write(dimension, value, code, countMetric, durationMetric string, duration time.Duration) {
+	line := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": e.now().UnixNano() / int64(time.Millisecond),
+			"CloudWatchMetrics": []interface{}{map[string]interface{}{
+				"Namespace":  e.namespace,
+				"Dimensions": [][]string{{dimension}, {dimension, "Code"}},
+				"Metrics": []map[string]string{
+					{"Name": countMetric, "Unit": "Count"},
+					{"Name": durationMetric, "Unit": "Milliseconds"},
+				},
+			}},
+		},
+		dimension:      value,
+		"Code":         code,
+		countMetric:    1,
+		durationMetric: float64(duration) / float64(time.Millisecond),
+	}
+
+	// Marshaling maps of strings and numbers can not fail.
+	data, _ := json.Marshal(line)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, err := e.w.Write(append(data, '\n')); err != nil {
+		log.Errorf("Could not write metrics: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEMF_ObserveOperation(t *testing.T) {
+	var buffer bytes.Buffer
+	sut := NewEMF(&buffer, "SecretService")
+	sut.now = func() time.Time { return time.Unix(1564660845, 0) }
+
+	sut.ObserveOperation("hello", CodeOK, 1500*time.Microsecond)
+
+	assert.JSONEq(t, `{
+		"_aws": {
+			"Timestamp": 1564660845000,
+			"CloudWatchMetrics": [{
+				"Namespace": "SecretService",
+				"Dimensions": [["Operation"], ["Operation", "Code"]],
+				"Metrics": [
+					{"Name": "Operations", "Unit": "Count"},
+					{"Name": "OperationDuration", "Unit": "Milliseconds"}
+				]
+			}]
+		},
+		"Operation": "hello",
+		"Code": "OK",
+		"Operations": 1,
+		"OperationDuration": 1.5
+	}`, buffer.String())
+	assert.Equal(t, byte('\n'), buffer.Bytes()[buffer.Len()-1])
+}
+
+func TestEMF_ObserveBackendCall(t *testing.T) {
+	var buffer bytes.Buffer
+	sut := NewEMF(&buffer, "SecretService")
+
+	sut.ObserveBackendCall("GetRelease", "NOT_FOUND", time.Millisecond)
+	sut.ObserveBackendCall("Scope", CodeOK, time.Millisecond)
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.Contains(t, string(lines[0]), `"Method":"GetRelease"`)
+	assert.Contains(t, string(lines[0]), `"Code":"NOT_FOUND"`)
+	assert.Contains(t, string(lines[0]), `"BackendCalls":1`)
+	assert.Contains(t, string(lines[1]), `"Method":"Scope"`)
+}
@@ -0,0 +1,31 @@
+// Package metrics records GraphQL operations and Backend calls, either for
+// Prometheus to scrape, or as CloudWatch embedded metric format log lines.
+package metrics
+
+import (
+	"time"
+
+	"github.com/marcinwyszynski/secretservice"
+)
+
+// CodeOK is the code of operations and calls which did not fail.
+const CodeOK = "OK"
+
+// Recorder records observations of the service. Codes are either CodeOK, or
+// one of the secretservice Code constants.
+type Recorder interface {
+	// ObserveOperation records a GraphQL operation, along with the code of
+	// its first error.
+	ObserveOperation(operation, code string, duration time.Duration)
+
+	// ObserveBackendCall records a call to a Backend method.
+	ObserveBackendCall(method, code string, duration time.Duration)
+}
+
+// ErrorCode returns the code an error is recorded with.
+func ErrorCode(err error) string {
+	if err == nil {
+		return CodeOK
+	}
+	return secretservice.ErrorCode(err)
+}
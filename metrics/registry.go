@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	kindCounter   = "counter"
+	kindHistogram = "histogram"
+)
+
+// labelValueEscaper escapes label values the way the text format requires.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// defaultBuckets are the upper bounds of histogram buckets, in seconds.
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry is a Recorder keeping metrics in memory, and serving them in the
+// Prometheus text format. It only knows the two counters and two histograms
+// the service records, which keeps the Prometheus client library and its
+// dependencies out of the Lambda binary, where metrics go through EMF anyway.
+// Label values are not bounded here, callers are expected to bound them.
+type Registry struct {
+	mu sync.Mutex
+
+	operations        *family
+	operationDuration *family
+	backendCalls      *family
+	backendDuration   *family
+}
+
+// NewRegistry returns an instance of a Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		operations: newFamily(
+			"secretservice_graphql_operations_total", kindCounter,
+			"Number of GraphQL operations executed, by the code of their first error.",
+			"operation", "code",
+		),
+		operationDuration: newFamily(
+			"secretservice_graphql_operation_duration_seconds", kindHistogram,
+			"Time spent executing GraphQL operations.",
+			"operation",
+		),
+		backendCalls: newFamily(
+			"secretservice_backend_calls_total", kindCounter,
+			"Number of calls to the Backend, by the code of their error.",
+			"method", "code",
+		),
+		backendDuration: newFamily(
+			"secretservice_backend_call_duration_seconds", kindHistogram,
+			"Time spent in calls to the Backend.",
+			"method",
+		),
+	}
+}
+
+// ObserveOperation records a GraphQL operation.
+func (r *Registry) ObserveOperation(operation, code string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.operations.observe(1, operation, code)
+	r.operationDuration.observe(duration.Seconds(), operation)
+}
+
+// ObserveBackendCall records a call to a Backend method.
+func (r *Registry) ObserveBackendCall(method, code string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.backendCalls.observe(1, method, code)
+	r.backendDuration.observe(duration.Seconds(), method)
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	var buffer bytes.Buffer
+
+	r.mu.Lock()
+	for _, family := range []*family{r.operations, r.operationDuration, r.backendCalls, r.backendDuration} {
+		family.write(&buffer)
+	}
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buffer.Bytes())
+}
+
+// family is a metric with a value for each combination of label values.
+type family struct {
+	name, kind, help string
+	labels           []string
+	series           map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	count       uint64
+	sum         float64
+	buckets     []uint64
+}
+
+func newFamily(name, kind, help string, labels ...string) *family {
+	return &family{name: name, kind: kind, help: help, labels: labels, series: make(map[string]*series)}
+}
+
+// observe counts a value for the series with the label values. Counters
+// only count observations, and ignore the value.
+func (f *family) observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+
+	current, exists := f.series[key]
+	if !exists {
+		current = &series{labelValues: labelValues}
+		if f.kind == kindHistogram {
+			current.buckets = make([]uint64, len(defaultBuckets))
+		}
+		f.series[key] = current
+	}
+
+	current.count++
+	current.sum += value
+
+	for i, bound := range defaultBuckets {
+		if f.kind == kindHistogram && value <= bound {
+			current.buckets[i]++
+		}
+	}
+}
+
+// write writes the family in the Prometheus text format, with series sorted
+// by their label values.
+func (f *family) write(w io.Writer) {
+	if len(f.series) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+
+	for _, key := range keys {
+		current := f.series[key]
+		labels := f.formatLabels(current.labelValues)
+
+		if f.kind == kindCounter {
+			fmt.Fprintf(w, "%s%s %d\n", f.name, labels, current.count)
+			continue
+		}
+
+		for i, bound := range defaultBuckets {
+			le := f.formatLabels(current.labelValues, "le", formatFloat(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, le, current.buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.formatLabels(current.labelValues, "le", "+Inf"), current.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labels, formatFloat(current.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labels, current.count)
+	}
+}
+
+// formatLabels writes label values, followed by extra name and value pairs.
+func (f *family) formatLabels(values []string, extra ...string) string {
+	var pairs []string
+	for i, name := range f.labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(values[i])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], labelValueEscaper.Replace(extra[i+1])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type registryTestSuite struct {
+	suite.Suite
+
+	sut *Registry
+}
+
+func (r *registryTestSuite) SetupTest() {
+	r.sut = NewRegistry()
+}
+
+func (r *registryTestSuite) TestServeHTTP_Empty() {
+	ret := r.scrape()
+
+	r.Equal("text/plain; version=0.0.4; charset=utf-8", ret.Header().Get("Content-Type"))
+	r.Empty(ret.Body.String())
+}
+
+func (r *registryTestSuite) TestServeHTTP_Operations() {
+	r.sut.ObserveOperation("hello", CodeOK, 20*time.Millisecond)
+	r.sut.ObserveOperation("hello", "NOT_FOUND", 2*time.Second)
+
+	body := r.scrape().Body.String()
+
+	r.Contains(body, "# TYPE secretservice_graphql_operations_total counter\n")
+	r.Contains(body, `secretservice_graphql_operations_total{operation="hello",code="NOT_FOUND"} 1`+"\n")
+	r.Contains(body, `secretservice_graphql_operations_total{operation="hello",code="OK"} 1`+"\n")
+
+	r.Contains(body, "# TYPE secretservice_graphql_operation_duration_seconds histogram\n")
+	r.Contains(body, `secretservice_graphql_operation_duration_seconds_bucket{operation="hello",le="0.01"} 0`+"\n")
+	r.Contains(body, `secretservice_graphql_operation_duration_seconds_bucket{operation="hello",le="0.025"} 1`+"\n")
+	r.Contains(body, `secretservice_graphql_operation_duration_seconds_bucket{operation="hello",le="2.5"} 2`+"\n")
+	r.Contains(body, `secretservice_graphql_operation_duration_seconds_bucket{operation="hello",le="+Inf"} 2`+"\n")
+	r.Contains(body, `secretservice_graphql_operation_duration_seconds_sum{operation="hello"} 2.02`+"\n")
+	r.Contains(body, `secretservice_graphql_operation_duration_seconds_count{operation="hello"} 2`+"\n")
+
+	r.NotContains(body, "secretservice_backend")
+}
+
+func (r *registryTestSuite) TestServeHTTP_BackendCalls() {
+	r.sut.ObserveBackendCall("GetRelease", CodeOK, time.Millisecond)
+
+	body := r.scrape().Body.String()
+
+	r.Contains(body, `secretservice_backend_calls_total{method="GetRelease",code="OK"} 1`+"\n")
+	r.Contains(body, `secretservice_backend_call_duration_seconds_count{method="GetRelease"} 1`+"\n")
+}
+
+func (r *registryTestSuite) TestServeHTTP_EscapesLabelValues() {
+	r.sut.ObserveOperation("say \"hello\"\\\n", CodeOK, time.Millisecond)
+
+	r.Contains(r.scrape().Body.String(), `operation="say \"hello\"\\\n"`)
+}
+
+func (r *registryTestSuite) scrape() *httptest.ResponseRecorder {
+	ret := httptest.NewRecorder()
+	r.sut.ServeHTTP(ret, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return ret
+}
+
+func TestRegistry(t *testing.T) {
+	suite.Run(t, new(registryTestSuite))
+}
@@ -0,0 +1,36 @@
+package secretservice
+
+import "context"
+
+// RoleAdmin allows a Principal to edit the workspace of protected Scopes
+// directly, and to render write-only Variables of Releases.
+const RoleAdmin = "admin"
+
+type principalKey struct{}
+
+// Principal is the authenticated identity making a request.
+type Principal struct {
+	ID    string
+	Roles []string
+}
+
+// HasRole returns true if the Principal holds the role.
+func (p *Principal) HasRole(role string) bool {
+	for _, held := range p.Roles {
+		if held == role {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPrincipal returns a copy of the context carrying the Principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the Principal making the request, if known.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+)
+
+// DynamoDBLimiter is a Limiter shared by all instances of the service. It
+// counts requests in fixed windows of one Period, using items of a DynamoDB
+// table with a string partition key called "key". Items carry their expiry
+// as a Unix timestamp in the "expires" attribute, to be used as the TTL
+// attribute of the table.
+type DynamoDBLimiter struct {
+	api       dynamodbiface.DynamoDBAPI
+	tableName *string
+	now       func() time.Time
+}
+
+// NewDynamoDBLimiter returns an instance of a DynamoDBLimiter.
+func NewDynamoDBLimiter(api dynamodbiface.DynamoDBAPI, tableName string) *DynamoDBLimiter {
+	return &DynamoDBLimiter{api: api, tableName: aws.String(tableName), now: time.Now}
+}
+
+// Allow increments the counter of the current window under the key, unless
+// it has reached the Limit already.
+func (d *DynamoDBLimiter) Allow(ctx context.Context, key string, limit Limit) (time.Duration, error) {
+	if limit.Unlimited() {
+		return 0, nil
+	}
+
+	now := d.now()
+	window := now.Truncate(limit.Period)
+	next := window.Add(limit.Period)
+
+	_, err := d.api.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: d.tableName,
+		Key: map[string]*dynamodb.AttributeValue{
+			"key": {S: aws.String(fmt.Sprintf("%s#%d", key, window.Unix()))},
+		},
+		UpdateExpression:    aws.String("ADD #count :one SET #expires = :expires"),
+		ConditionExpression: aws.String("attribute_not_exists(#count) OR #count < :limit"),
+		ExpressionAttributeNames: map[string]*string{
+			"#count":   aws.String("count"),
+			"#expires": aws.String("expires"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":one":     {N: aws.String("1")},
+			":limit":   {N: aws.String(strconv.Itoa(limit.Count))},
+			":expires": {N: aws.String(strconv.FormatInt(next.Unix(), 10))},
+		},
+	})
+
+	if awsErr, ok := errors.Cause(err).(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return next.Sub(now), nil
+	}
+
+	return 0, errors.Wrap(err, "could not count request in DynamoDB")
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type mockDynamoDB struct {
+	mock.Mock
+	dynamodbiface.DynamoDBAPI
+}
+
+func (m *mockDynamoDB) UpdateItemWithContext(ctx aws.Context, input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	args := m.Called(ctx, input, opts)
+	return args.Get(0).(*dynamodb.UpdateItemOutput), args.Error(1)
+}
+
+type dynamoDBTestSuite struct {
+	suite.Suite
+
+	ctx   context.Context
+	api   *mockDynamoDB
+	limit Limit
+	sut   *DynamoDBLimiter
+}
+
+func (d *dynamoDBTestSuite) SetupTest() {
+	d.ctx = context.Background()
+	d.api = new(mockDynamoDB)
+	d.limit = Limit{Count: 10, Period: time.Minute}
+	d.sut = NewDynamoDBLimiter(d.api, "table")
+	d.sut.now = func() time.Time { return time.Unix(1564660845, 0) }
+}
+
+func (d *dynamoDBTestSuite) TestAllow_Allowed() {
+	d.withUpdateItem(nil)
+
+	ret, err := d.sut.Allow(d.ctx, "key", d.limit)
+
+	d.NoError(err)
+	d.Zero(ret)
+}
+
+func (d *dynamoDBTestSuite) TestAllow_LimitReached() {
+	d.withUpdateItem(awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "bacon", nil))
+
+	ret, err := d.sut.Allow(d.ctx, "key", d.limit)
+
+	d.NoError(err)
+	d.Equal(15*time.Second, ret)
+}
+
+func (d *dynamoDBTestSuite) TestAllow_Error() {
+	d.withUpdateItem(errors.New("bacon"))
+
+	_, err := d.sut.Allow(d.ctx, "key", d.limit)
+
+	d.EqualError(err, "could not count request in DynamoDB: bacon")
+}
+
+func (d *dynamoDBTestSuite) TestAllow_Unlimited() {
+	ret, err := d.sut.Allow(d.ctx, "key", Limit{})
+
+	d.NoError(err)
+	d.Zero(ret)
+	d.api.AssertNotCalled(d.T(), "UpdateItemWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (d *dynamoDBTestSuite) withUpdateItem(err error) {
+	d.api.On(
+		"UpdateItemWithContext",
+		d.ctx,
+		mock.MatchedBy(func(input *dynamodb.UpdateItemInput) bool {
+			return aws.StringValue(input.TableName) == "table" &&
+				aws.StringValue(input.Key["key"].S) == "key#1564660800" &&
+				aws.StringValue(input.ExpressionAttributeValues[":limit"].N) == "10" &&
+				aws.StringValue(input.ExpressionAttributeValues[":expires"].N) == "1564660860"
+		}),
+		[]request.Option(nil),
+	).Return(new(dynamodb.UpdateItemOutput), err)
+}
+
+func TestDynamoDBLimiter(t *testing.T) {
+	suite.Run(t, new(dynamoDBTestSuite))
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	period  time.Duration
+	updated time.Time
+}
+
+// MemoryLimiter is a Limiter using token buckets kept in memory. A bucket
+// holds up to Count tokens and is refilled at a rate of Count per Period.
+// It only counts requests made to the same process.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemoryLimiter returns an instance of a MemoryLimiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Allow takes a token from the bucket under the key.
+func (m *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (time.Duration, error) {
+	if limit.Unlimited() {
+		return 0, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	capacity := float64(limit.Count)
+	rate := capacity / limit.Period.Seconds()
+
+	current, exists := m.buckets[key]
+	if !exists {
+		m.prune(now)
+		current = &bucket{tokens: capacity, period: limit.Period, updated: now}
+		m.buckets[key] = current
+	}
+
+	current.tokens += now.Sub(current.updated).Seconds() * rate
+	if current.tokens > capacity {
+		current.tokens = capacity
+	}
+	current.updated = now
+
+	if current.tokens < 1 {
+		return time.Duration((1 - current.tokens) / rate * float64(time.Second)), nil
+	}
+
+	current.tokens--
+	return 0, nil
+}
+
+// prune removes buckets which have not been used for a full period, as they
+// would have been refilled by now anyway.
+func (m *MemoryLimiter) prune(now time.Time) {
+	for key, bucket := range m.buckets {
+		if now.Sub(bucket.updated) >= bucket.period {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type memoryTestSuite struct {
+	suite.Suite
+
+	ctx   context.Context
+	now   time.Time
+	limit Limit
+	sut   *MemoryLimiter
+}
+
+func (m *memoryTestSuite) SetupTest() {
+	m.ctx = context.Background()
+	m.now = time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+	m.limit = Limit{Count: 2, Period: time.Minute}
+	m.sut = NewMemoryLimiter()
+	m.sut.now = func() time.Time { return m.now }
+}
+
+func (m *memoryTestSuite) TestAllow_Burst() {
+	m.allowed("key")
+	m.allowed("key")
+
+	ret, err := m.sut.Allow(m.ctx, "key", m.limit)
+	m.NoError(err)
+	m.Equal(30*time.Second, ret)
+
+	// Other keys have their own buckets.
+	m.allowed("other")
+}
+
+func (m *memoryTestSuite) TestAllow_Refill() {
+	m.allowed("key")
+	m.allowed("key")
+
+	m.now = m.now.Add(30 * time.Second)
+	m.allowed("key")
+
+	ret, err := m.sut.Allow(m.ctx, "key", m.limit)
+	m.NoError(err)
+	m.Equal(30*time.Second, ret)
+}
+
+func (m *memoryTestSuite) TestAllow_Unlimited() {
+	for i := 0; i < 10; i++ {
+		ret, err := m.sut.Allow(m.ctx, "key", Limit{})
+		m.NoError(err)
+		m.Zero(ret)
+	}
+	m.Empty(m.sut.buckets)
+}
+
+func (m *memoryTestSuite) TestAllow_PrunesIdleBuckets() {
+	m.allowed("key")
+
+	m.now = m.now.Add(time.Minute)
+	m.allowed("other")
+
+	m.Len(m.sut.buckets, 1)
+	m.Contains(m.sut.buckets, "other")
+}
+
+func (m *memoryTestSuite) allowed(key string) {
+	ret, err := m.sut.Allow(m.ctx, key, m.limit)
+	m.Require().NoError(err)
+	m.Require().Zero(ret)
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	suite.Run(t, new(memoryTestSuite))
+}
@@ -0,0 +1,73 @@
+// Package ratelimit limits how often callers can make requests.
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var periods = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// Limit allows Count requests per Period. The zero Limit allows any number of
+// requests.
+type Limit struct {
+	Count  int
+	Period time.Duration
+}
+
+// ParseLimit reads a Limit written as a count per unit of time, e.g. "60/m".
+// Supported units are "s", "m" and "h". An empty string is the zero Limit.
+func ParseLimit(value string) (Limit, error) {
+	if value == "" {
+		return Limit{}, nil
+	}
+
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return Limit{}, errors.Errorf("limit %q is not in the count/unit format", value)
+	}
+
+	count, err := strconv.Atoi(parts[0])
+	if err != nil || count <= 0 {
+		return Limit{}, errors.Errorf("count of limit %q is not a positive integer", value)
+	}
+
+	period, ok := periods[parts[1]]
+	if !ok {
+		return Limit{}, errors.Errorf("unit of limit %q is not one of s, m or h", value)
+	}
+
+	return Limit{Count: count, Period: period}, nil
+}
+
+// Decode implements envconfig.Decoder.
+func (l *Limit) Decode(value string) error {
+	parsed, err := ParseLimit(value)
+	if err != nil {
+		return err
+	}
+
+	*l = parsed
+	return nil
+}
+
+// Unlimited tells whether the Limit allows any number of requests.
+func (l Limit) Unlimited() bool {
+	return l.Count <= 0 || l.Period <= 0
+}
+
+// Limiter counts requests against Limits.
+type Limiter interface {
+	// Allow counts a request made under a key. If the request exceeds the
+	// Limit, it returns how long to wait before retrying, and zero
+	// otherwise.
+	Allow(ctx context.Context, key string, limit Limit) (time.Duration, error)
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit_OK(t *testing.T) {
+	for value, expected := range map[string]Limit{
+		"":      {},
+		"10/s":  {Count: 10, Period: time.Second},
+		"60/m":  {Count: 60, Period: time.Minute},
+		"500/h": {Count: 500, Period: time.Hour},
+	} {
+		ret, err := ParseLimit(value)
+
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, ret, value)
+	}
+}
+
+func TestParseLimit_Invalid(t *testing.T) {
+	for value, expected := range map[string]string{
+		"60":      `limit "60" is not in the count/unit format`,
+		"0/m":     `count of limit "0/m" is not a positive integer`,
+		"bacon/m": `count of limit "bacon/m" is not a positive integer`,
+		"60/d":    `unit of limit "60/d" is not one of s, m or h`,
+	} {
+		_, err := ParseLimit(value)
+
+		assert.EqualError(t, err, expected, value)
+	}
+}
+
+func TestLimit_Decode(t *testing.T) {
+	var limit Limit
+
+	assert.NoError(t, limit.Decode("60/m"))
+	assert.Equal(t, Limit{Count: 60, Period: time.Minute}, limit)
+	assert.False(t, limit.Unlimited())
+
+	assert.Error(t, limit.Decode("bacon"))
+}
@@ -0,0 +1,37 @@
+package secretservice
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// NewestLiveRelease returns the newest Release in a Scope which has not been
+// archived, or nil if there is no such Release.
+func NewestLiveRelease(ctx context.Context, backend Backend, scopeName string) (*Release, error) {
+	var before *string
+
+	for {
+		ids, err := backend.ListReleases(ctx, scopeName, before)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list release IDs")
+		}
+
+		if len(ids) == 0 {
+			return nil, nil
+		}
+
+		for _, id := range ids {
+			release, err := backend.GetRelease(ctx, scopeName, id)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not get release %s", id)
+			}
+
+			if release.Live {
+				return release, nil
+			}
+		}
+
+		before = &ids[len(ids)-1]
+	}
+}
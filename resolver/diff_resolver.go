@@ -1,7 +1,12 @@
 package resolver
 
 import (
+	"encoding/json"
+	"sort"
+	"strings"
+
 	"github.com/marcinwyszynski/ssmvars"
+	"github.com/pkg/errors"
 )
 
 type diffResolver struct {
@@ -34,7 +39,8 @@ func (d *diffResolver) Added() []*variableResolver {
 func (d *diffResolver) Changed() []*changeResolver {
 	var ret []*changeResolver
 
-	for key, before := range d.oldVariables {
+	for _, key := range sortedKeys(d.oldVariables) {
+		before := d.oldVariables[key]
 		after, exists := d.newVariables[key]
 		if !exists {
 			continue
@@ -55,16 +61,122 @@ func (d *diffResolver) Deleted() []*variableResolver {
 	return setDifference(d.oldVariables, d.newVariables)
 }
 
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+type patchValue struct {
+	Value     *string `json:"value,omitempty"`
+	WriteOnly bool    `json:"writeOnly"`
+}
+
+// patch: String!
+func (d *diffResolver) Patch() (string, error) {
+	var ops []patchOperation
+
+	for _, variable := range d.Deleted() {
+		ops = append(ops, patchOperation{Op: "remove", Path: patchPath(variable.wraps.Name)})
+	}
+	for _, variable := range d.Added() {
+		ops = append(ops, patchOperation{
+			Op:    "add",
+			Path:  patchPath(variable.wraps.Name),
+			Value: patchValue{Value: variable.Value(), WriteOnly: variable.WriteOnly()},
+		})
+	}
+	for _, change := range d.Changed() {
+		after := change.After()
+		ops = append(ops, patchOperation{
+			Op:    "replace",
+			Path:  patchPath(after.wraps.Name),
+			Value: patchValue{Value: after.Value(), WriteOnly: after.WriteOnly()},
+		})
+	}
+
+	sort.SliceStable(ops, func(i, j int) bool {
+		return ops[i].Path < ops[j].Path
+	})
+
+	if ops == nil {
+		ops = []patchOperation{}
+	}
+
+	ret, err := json.Marshal(ops)
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal patch")
+	}
+
+	return string(ret), nil
+}
+
+type diffSummary struct {
+	added, changed, deleted int32
+}
+
+// summary: DiffSummary!
+func (d *diffResolver) Summary() *diffSummary {
+	return &diffSummary{
+		added:   int32(len(d.Added())),
+		changed: int32(len(d.Changed())),
+		deleted: int32(len(d.Deleted())),
+	}
+}
+
+// added: Int!
+func (s *diffSummary) Added() int32 {
+	return s.added
+}
+
+// changed: Int!
+func (s *diffSummary) Changed() int32 {
+	return s.changed
+}
+
+// deleted: Int!
+func (s *diffSummary) Deleted() int32 {
+	return s.deleted
+}
+
+// written returns new versions of all added and changed variables.
+func (d *diffResolver) written() []*ssmvars.Variable {
+	var ret []*ssmvars.Variable
+
+	for _, variable := range d.Added() {
+		ret = append(ret, variable.wraps)
+	}
+	for _, change := range d.Changed() {
+		ret = append(ret, change.after)
+	}
+
+	return ret
+}
+
+// patchPath returns a JSON Pointer (RFC 6901) to a top-level key.
+func patchPath(name string) string {
+	return "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
 // setDifference returns the list of variables (wrapped in a resolver) which are
-// in the first set, but not in the second one.
+// in the first set, but not in the second one, sorted by name.
 func setDifference(first, second map[string]*ssmvars.Variable) []*variableResolver {
 	var ret []*variableResolver
 
-	for key, variable := range first {
+	for _, key := range sortedKeys(first) {
 		if _, exists := second[key]; !exists {
-			ret = append(ret, &variableResolver{wraps: variable})
+			ret = append(ret, &variableResolver{wraps: first[key]})
 		}
+	}
+
+	return ret
+}
 
+func sortedKeys(variables map[string]*ssmvars.Variable) []string {
+	ret := make([]string, 0, len(variables))
+	for key := range variables {
+		ret = append(ret, key)
 	}
+	sort.Strings(ret)
 	return ret
 }
@@ -56,6 +56,51 @@ func (d *diffResolverTestSuite) TestChanged() {
 	changed := d.sut.Changed()
 
 	d.Len(changed, 2)
+	d.Equal(changedVariableValueNew, changed[0].after)
+	d.Equal(changedVariableWriteNew, changed[1].after)
+}
+
+func (d *diffResolverTestSuite) TestSorted() {
+	d.sut = newDiffResolver(nil, []*ssmvars.Variable{{Name: "C"}, {Name: "A"}, {Name: "B"}})
+
+	added := d.sut.Added()
+
+	d.Len(added, 3)
+	d.EqualValues("A", added[0].ID())
+	d.EqualValues("B", added[1].ID())
+	d.EqualValues("C", added[2].ID())
+}
+
+func (d *diffResolverTestSuite) TestPatch() {
+	d.sut.newVariables["SECRET/~"] = &ssmvars.Variable{Name: "SECRET/~", Value: "secret", WriteOnly: true}
+
+	patch, err := d.sut.Patch()
+
+	d.NoError(err)
+	d.JSONEq(`[
+		{"op": "replace", "path": "/CHANGED_VALUE", "value": {"value": "new", "writeOnly": false}},
+		{"op": "replace", "path": "/CHANGED_WRITE", "value": {"value": "", "writeOnly": false}},
+		{"op": "add", "path": "/NEW", "value": {"value": "", "writeOnly": false}},
+		{"op": "remove", "path": "/OLD"},
+		{"op": "add", "path": "/SECRET~1~0", "value": {"writeOnly": true}}
+	]`, patch)
+}
+
+func (d *diffResolverTestSuite) TestPatch_Empty() {
+	d.sut = newDiffResolver(nil, nil)
+
+	patch, err := d.sut.Patch()
+
+	d.NoError(err)
+	d.Equal("[]", patch)
+}
+
+func (d *diffResolverTestSuite) TestSummary() {
+	summary := d.sut.Summary()
+
+	d.EqualValues(1, summary.Added())
+	d.EqualValues(2, summary.Changed())
+	d.EqualValues(1, summary.Deleted())
 }
 
 func (d *diffResolverTestSuite) TestDeleted() {
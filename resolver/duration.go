@@ -0,0 +1,40 @@
+package resolver
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// duration is a custom GraphQL scalar representing a span of time, written
+// using Go duration syntax (e.g. "720h" or "1h30m").
+type duration struct {
+	time.Duration
+}
+
+// ImplementsGraphQLType maps duration to the Duration GraphQL scalar.
+func (duration) ImplementsGraphQLType(name string) bool {
+	return name == "Duration"
+}
+
+// UnmarshalGraphQL parses Duration input values.
+func (d *duration) UnmarshalGraphQL(input interface{}) error {
+	value, ok := input.(string)
+	if !ok {
+		return errors.Errorf("duration must be a string, got %T", input)
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.Wrap(err, "could not parse duration")
+	}
+
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalJSON serializes Duration output values.
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
@@ -0,0 +1,29 @@
+package resolver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+)
+
+// expiryWarnings returns warnings about variables whose values have already
+// expired.
+func expiryWarnings(variables []*ssmvars.Variable, metadata map[string]*secretservice.Metadata, now time.Time) []string {
+	var ret []string
+
+	for _, variable := range variables {
+		meta, exists := metadata[variable.Name]
+		if !exists || !meta.Expired(now) {
+			continue
+		}
+		ret = append(ret, fmt.Sprintf(
+			"variable %q expired at %s",
+			variable.Name,
+			meta.ExpiresAt.UTC().Format(time.RFC3339),
+		))
+	}
+
+	return ret
+}
@@ -0,0 +1,138 @@
+package resolver
+
+import (
+	"sort"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/ssmvars"
+)
+
+const (
+	mergeStrategyOurs           = "OURS"
+	mergeStrategyTheirs         = "THEIRS"
+	mergeStrategyFailOnConflict = "FAIL_ON_CONFLICT"
+)
+
+type mergeResolver struct {
+	applied   *diffResolver
+	conflicts []*conflictResolver
+	scope     *scopeResolver
+}
+
+// applied: Diff!
+func (m *mergeResolver) Applied() *diffResolver {
+	return m.applied
+}
+
+// conflicts: [Conflict!]!
+func (m *mergeResolver) Conflicts() []*conflictResolver {
+	return m.conflicts
+}
+
+// scope: Scope!
+func (m *mergeResolver) Scope() *scopeResolver {
+	return m.scope
+}
+
+type conflictResolver struct {
+	name               string
+	base, ours, theirs *ssmvars.Variable
+}
+
+// id: ID!
+func (c *conflictResolver) ID() graphql.ID {
+	return graphql.ID(c.name)
+}
+
+// base: Variable
+func (c *conflictResolver) Base() *variableResolver {
+	return optionalVariable(c.base)
+}
+
+// ours: Variable
+func (c *conflictResolver) Ours() *variableResolver {
+	return optionalVariable(c.ours)
+}
+
+// theirs: Variable
+func (c *conflictResolver) Theirs() *variableResolver {
+	return optionalVariable(c.theirs)
+}
+
+// threeWayMerge applies changes made between the base and incoming sets of
+// Variables to our set. A conflict is reported for every Variable changed
+// differently on both sides, and resolved according to the strategy. With the
+// FAIL_ON_CONFLICT strategy our set is returned unchanged if there are any
+// conflicts.
+func threeWayMerge(base, ours, incoming []*ssmvars.Variable, strategy string) ([]*ssmvars.Variable, []*conflictResolver) {
+	theirChanges := newDiffResolver(base, incoming)
+	ourChanges := newDiffResolver(base, ours)
+
+	var conflicts []*conflictResolver
+	set := make([]*ssmvars.Variable, 0)
+	removed := make(map[string]bool)
+
+	for _, name := range changedNames(theirChanges) {
+		baseVar := theirChanges.oldVariables[name]
+		theirVar := theirChanges.newVariables[name]
+		ourVar := ourChanges.newVariables[name]
+
+		if !sameVariable(ourVar, baseVar) && !sameVariable(ourVar, theirVar) {
+			conflicts = append(conflicts, &conflictResolver{
+				name:   name,
+				base:   baseVar,
+				ours:   ourVar,
+				theirs: theirVar,
+			})
+			if strategy != mergeStrategyTheirs {
+				continue
+			}
+		}
+
+		if theirVar == nil {
+			if ourVar != nil {
+				removed[name] = true
+			}
+		} else {
+			set = append(set, theirVar)
+		}
+	}
+
+	if strategy == mergeStrategyFailOnConflict && len(conflicts) > 0 {
+		return ours, conflicts
+	}
+
+	return mergeVariables(ours, set, removed), conflicts
+}
+
+// changedNames returns sorted names of all Variables affected by the diff.
+func changedNames(diff *diffResolver) []string {
+	var ret []string
+
+	for _, variable := range diff.Added() {
+		ret = append(ret, variable.wraps.Name)
+	}
+	for _, change := range diff.Changed() {
+		ret = append(ret, change.after.Name)
+	}
+	for _, variable := range diff.Deleted() {
+		ret = append(ret, variable.wraps.Name)
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
+func sameVariable(first, second *ssmvars.Variable) bool {
+	if first == nil || second == nil {
+		return first == second
+	}
+	return first.Value == second.Value && first.WriteOnly == second.WriteOnly
+}
+
+func optionalVariable(variable *ssmvars.Variable) *variableResolver {
+	if variable == nil {
+		return nil
+	}
+	return &variableResolver{wraps: variable}
+}
@@ -0,0 +1,114 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/suite"
+)
+
+type mergeTestSuite struct {
+	suite.Suite
+
+	base, ours, incoming []*ssmvars.Variable
+}
+
+func (m *mergeTestSuite) SetupTest() {
+	m.base = []*ssmvars.Variable{
+		{Name: "UNTOUCHED", Value: "base"},
+		{Name: "OURS_ONLY", Value: "base"},
+		{Name: "THEIRS_ONLY", Value: "base"},
+		{Name: "SAME_CHANGE", Value: "base"},
+		{Name: "CONFLICT", Value: "base"},
+		{Name: "THEIRS_DELETED", Value: "base"},
+	}
+	m.ours = []*ssmvars.Variable{
+		{Name: "UNTOUCHED", Value: "base"},
+		{Name: "OURS_ONLY", Value: "ours"},
+		{Name: "THEIRS_ONLY", Value: "base"},
+		{Name: "SAME_CHANGE", Value: "same"},
+		{Name: "CONFLICT", Value: "ours"},
+		{Name: "THEIRS_DELETED", Value: "base"},
+		{Name: "OURS_ADDED", Value: "ours"},
+	}
+	m.incoming = []*ssmvars.Variable{
+		{Name: "UNTOUCHED", Value: "base"},
+		{Name: "OURS_ONLY", Value: "base"},
+		{Name: "THEIRS_ONLY", Value: "theirs"},
+		{Name: "SAME_CHANGE", Value: "same"},
+		{Name: "CONFLICT", Value: "theirs"},
+		{Name: "THEIRS_ADDED", Value: "theirs"},
+	}
+}
+
+func (m *mergeTestSuite) TestOurs() {
+	merged, conflicts := threeWayMerge(m.base, m.ours, m.incoming, mergeStrategyOurs)
+
+	m.assertConflict(conflicts)
+	m.Equal(map[string]string{
+		"UNTOUCHED":    "base",
+		"OURS_ONLY":    "ours",
+		"THEIRS_ONLY":  "theirs",
+		"SAME_CHANGE":  "same",
+		"CONFLICT":     "ours",
+		"OURS_ADDED":   "ours",
+		"THEIRS_ADDED": "theirs",
+	}, values(merged))
+}
+
+func (m *mergeTestSuite) TestTheirs() {
+	merged, conflicts := threeWayMerge(m.base, m.ours, m.incoming, mergeStrategyTheirs)
+
+	m.assertConflict(conflicts)
+	m.Equal("theirs", values(merged)["CONFLICT"])
+	m.NotContains(values(merged), "THEIRS_DELETED")
+}
+
+func (m *mergeTestSuite) TestFailOnConflict() {
+	merged, conflicts := threeWayMerge(m.base, m.ours, m.incoming, mergeStrategyFailOnConflict)
+
+	m.assertConflict(conflicts)
+	m.Equal(m.ours, merged)
+}
+
+func (m *mergeTestSuite) TestFailOnConflict_NoConflicts() {
+	merged, conflicts := threeWayMerge(m.base, m.base, m.incoming, mergeStrategyFailOnConflict)
+
+	m.Empty(conflicts)
+	m.Equal(values(m.incoming), values(merged))
+}
+
+func (m *mergeTestSuite) TestConflict_Deleted() {
+	merged, conflicts := threeWayMerge(
+		[]*ssmvars.Variable{{Name: "A", Value: "base"}},
+		[]*ssmvars.Variable{{Name: "A", Value: "ours"}},
+		nil,
+		mergeStrategyTheirs,
+	)
+
+	m.Len(conflicts, 1)
+	m.Nil(conflicts[0].Theirs())
+	m.Empty(merged)
+}
+
+func (m *mergeTestSuite) assertConflict(conflicts []*conflictResolver) {
+	m.Len(conflicts, 1)
+
+	conflict := conflicts[0]
+	m.EqualValues("CONFLICT", conflict.ID())
+	m.Equal("base", *conflict.Base().Value())
+	m.Equal("ours", *conflict.Ours().Value())
+	m.Equal("theirs", *conflict.Theirs().Value())
+}
+
+func values(variables []*ssmvars.Variable) map[string]string {
+	ret := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		ret[variable.Name] = variable.Value
+	}
+	return ret
+}
+
+func TestMerge(t *testing.T) {
+	suite.Run(t, new(mergeTestSuite))
+}
@@ -17,26 +17,82 @@ func (m *mockBackend) ArchiveRelease(ctx context.Context, scopeName, releaseID s
 	return m.Called(ctx, scopeName, releaseID).Error(0)
 }
 
-func (m *mockBackend) CreateRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable) (*secretservice.Release, error) {
-	args := m.Called(ctx, scopeName, variables)
+func (m *mockBackend) CreateProposal(ctx context.Context, proposal *secretservice.Proposal) (*secretservice.Proposal, error) {
+	args := m.Called(ctx, proposal)
+	return args.Get(0).(*secretservice.Proposal), args.Error(1)
+}
+
+// ContentHash is deterministic, so it is not mocked. Hashes are unkeyed.
+func (m *mockBackend) ContentHash(variables []*ssmvars.Variable) string {
+	return secretservice.ContentHash(nil, variables)
+}
+
+func (m *mockBackend) CreateRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable, baseReleaseID string) (*secretservice.Release, error) {
+	args := m.Called(ctx, scopeName, variables, baseReleaseID)
 	return args.Get(0).(*secretservice.Release), args.Error(1)
 }
 
+func (m *mockBackend) DeleteMetadata(ctx context.Context, scopeName, variableName string) error {
+	return m.Called(ctx, scopeName, variableName).Error(0)
+}
+
+func (m *mockBackend) GetProposal(ctx context.Context, scopeName, proposalID string) (*secretservice.Proposal, error) {
+	args := m.Called(ctx, scopeName, proposalID)
+	return args.Get(0).(*secretservice.Proposal), args.Error(1)
+}
+
 func (m *mockBackend) GetRelease(ctx context.Context, scopeName, releaseID string) (*secretservice.Release, error) {
 	args := m.Called(ctx, scopeName, releaseID)
 	return args.Get(0).(*secretservice.Release), args.Error(1)
 }
 
+func (m *mockBackend) GetScheduledOperation(ctx context.Context, scopeName, operationID string) (*secretservice.ScheduledOperation, error) {
+	args := m.Called(ctx, scopeName, operationID)
+	return args.Get(0).(*secretservice.ScheduledOperation), args.Error(1)
+}
+
+func (m *mockBackend) ListMetadata(ctx context.Context, scopeName string) (map[string]*secretservice.Metadata, error) {
+	args := m.Called(ctx, scopeName)
+	return args.Get(0).(map[string]*secretservice.Metadata), args.Error(1)
+}
+
+func (m *mockBackend) ListProposals(ctx context.Context, scopeName string) ([]*secretservice.Proposal, error) {
+	args := m.Called(ctx, scopeName)
+	return args.Get(0).([]*secretservice.Proposal), args.Error(1)
+}
+
 func (m *mockBackend) ListReleases(ctx context.Context, scopeName string, before *string) ([]string, error) {
 	args := m.Called(ctx, scopeName, before)
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *mockBackend) ListScheduledOperations(ctx context.Context, scopeName string) ([]*secretservice.ScheduledOperation, error) {
+	args := m.Called(ctx, scopeName)
+	return args.Get(0).([]*secretservice.ScheduledOperation), args.Error(1)
+}
+
+func (m *mockBackend) ScheduleOperation(ctx context.Context, operation *secretservice.ScheduledOperation) (*secretservice.ScheduledOperation, error) {
+	args := m.Called(ctx, operation)
+	return args.Get(0).(*secretservice.ScheduledOperation), args.Error(1)
+}
+
 func (m *mockBackend) Scope(ctx context.Context, scopeName string) (*secretservice.Scope, error) {
 	args := m.Called(ctx, scopeName)
 	return args.Get(0).(*secretservice.Scope), args.Error(1)
 }
 
+func (m *mockBackend) SetMetadata(ctx context.Context, scopeName, variableName string, metadata *secretservice.Metadata) error {
+	return m.Called(ctx, scopeName, variableName, metadata).Error(0)
+}
+
+func (m *mockBackend) UpdateProposal(ctx context.Context, proposal *secretservice.Proposal) error {
+	return m.Called(ctx, proposal).Error(0)
+}
+
+func (m *mockBackend) UpdateScheduledOperation(ctx context.Context, operation *secretservice.ScheduledOperation) error {
+	return m.Called(ctx, operation).Error(0)
+}
+
 func (m *mockBackend) ListVariables(ctx context.Context, namespace string) ([]*ssmvars.Variable, error) {
 	args := m.Called(ctx, namespace)
 	return args.Get(0).([]*ssmvars.Variable), args.Error(1)
@@ -0,0 +1,87 @@
+package resolver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+)
+
+type previewResolver struct {
+	backend   secretservice.Backend
+	base      *secretservice.Release
+	metadata  map[string]*secretservice.Metadata
+	scope     *secretservice.Scope
+	variables []*ssmvars.Variable
+}
+
+// base: Release
+func (p *previewResolver) Base() *releaseResolver {
+	if p.base == nil {
+		return nil
+	}
+
+	ret := newReleaseResolver(p.backend, graphql.ID(p.base.ID), p.scope)
+	ret.wraps = p.base
+	return ret
+}
+
+// contentHash: String!
+func (p *previewResolver) ContentHash() string {
+	return p.backend.ContentHash(p.variables)
+}
+
+// diff: Diff!
+func (p *previewResolver) Diff() *diffResolver {
+	return newDiffResolver(p.baseVariables(), p.variables)
+}
+
+// errors: [String!]!
+func (p *previewResolver) Errors() []string {
+	ret := make([]string, 0)
+
+	for _, variable := range p.variables {
+		if err := secretservice.ValidateVariableName(variable.Name); err != nil {
+			ret = append(ret, err.Error())
+		}
+	}
+
+	return ret
+}
+
+// variables: [Variable!]!
+func (p *previewResolver) Variables() []*variableResolver {
+	ret := make([]*variableResolver, len(p.variables))
+	for i, variable := range p.variables {
+		ret[i] = &variableResolver{metadata: p.metadata[variable.Name], wraps: variable}
+	}
+	return ret
+}
+
+// warnings: [String!]!
+func (p *previewResolver) Warnings() []string {
+	ret := make([]string, 0)
+
+	for _, variable := range p.variables {
+		if variable.Value == "" {
+			ret = append(ret, fmt.Sprintf("variable %q has an empty value", variable.Name))
+		}
+	}
+
+	ret = append(ret, expiryWarnings(p.variables, p.metadata, time.Now())...)
+
+	for _, variable := range p.Diff().Deleted() {
+		ret = append(ret, fmt.Sprintf("variable %q will be removed", variable.wraps.Name))
+	}
+
+	return ret
+}
+
+func (p *previewResolver) baseVariables() []*ssmvars.Variable {
+	if p.base == nil {
+		return nil
+	}
+	return p.base.Variables
+}
@@ -0,0 +1,152 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/suite"
+)
+
+type previewResolverTestSuite struct {
+	suite.Suite
+
+	backend *mockBackend
+	ctx     context.Context
+
+	sut *previewResolver
+}
+
+func (p *previewResolverTestSuite) SetupTest() {
+	expiresAt := time.Date(2018, 11, 10, 23, 0, 0, 0, time.UTC)
+
+	p.backend = new(mockBackend)
+	p.ctx = context.Background()
+	p.sut = &previewResolver{
+		backend: p.backend,
+		base: &secretservice.Release{
+			ID: "releaseID",
+			Variables: []*ssmvars.Variable{
+				{Name: "KEPT", Value: "old"},
+				{Name: "REMOVED", Value: "value"},
+			},
+		},
+		metadata: map[string]*secretservice.Metadata{
+			"KEPT": {ExpiresAt: &expiresAt},
+		},
+		scope: &secretservice.Scope{Name: "scopeName"},
+		variables: []*ssmvars.Variable{
+			{Name: "1NVALID", Value: "value"},
+			{Name: "EMPTY"},
+			{Name: "KEPT", Value: "new"},
+		},
+	}
+}
+
+func (p *previewResolverTestSuite) TestBase() {
+	ret := p.sut.Base()
+
+	p.EqualValues("releaseID", ret.ID())
+	p.Equal(p.sut.base, ret.wraps)
+}
+
+func (p *previewResolverTestSuite) TestBase_None() {
+	p.sut.base = nil
+
+	p.Nil(p.sut.Base())
+	p.Len(p.sut.Diff().Added(), 3)
+}
+
+func (p *previewResolverTestSuite) TestContentHash() {
+	p.Equal(secretservice.ContentHash(nil, p.sut.variables), p.sut.ContentHash())
+}
+
+func (p *previewResolverTestSuite) TestDiff() {
+	diff := p.sut.Diff()
+
+	p.Len(diff.Added(), 2)
+	p.Len(diff.Changed(), 1)
+	p.Len(diff.Deleted(), 1)
+}
+
+func (p *previewResolverTestSuite) TestErrors() {
+	p.Equal([]string{
+		`variable name "1NVALID" is not a valid environment variable name`,
+	}, p.sut.Errors())
+}
+
+func (p *previewResolverTestSuite) TestVariables() {
+	ret := p.sut.Variables()
+
+	p.Len(ret, 3)
+	p.Nil(ret[0].metadata)
+	p.Equal(p.sut.metadata["KEPT"], ret[2].metadata)
+}
+
+func (p *previewResolverTestSuite) TestWarnings() {
+	p.Equal([]string{
+		`variable "EMPTY" has an empty value`,
+		`variable "KEPT" expired at 2018-11-10T23:00:00Z`,
+		`variable "REMOVED" will be removed`,
+	}, p.sut.Warnings())
+}
+
+func (p *previewResolverTestSuite) TestNewestLiveRelease_SecondPage() {
+	p.backend.On("ListReleases", p.ctx, "scopeName", (*string)(nil)).Return([]string{"newID"}, nil)
+	p.backend.On("ListReleases", p.ctx, "scopeName", aws.String("newID")).Return([]string{"oldID"}, nil)
+	p.backend.
+		On("GetRelease", p.ctx, "scopeName", "newID").
+		Return(&secretservice.Release{ID: "newID"}, nil)
+	p.backend.
+		On("GetRelease", p.ctx, "scopeName", "oldID").
+		Return(&secretservice.Release{ID: "oldID", Live: true}, nil)
+
+	ret, err := secretservice.NewestLiveRelease(p.ctx, p.backend, "scopeName")
+
+	p.NoError(err)
+	p.Equal("oldID", ret.ID)
+}
+
+func (p *previewResolverTestSuite) TestNewestLiveRelease_None() {
+	p.backend.On("ListReleases", p.ctx, "scopeName", (*string)(nil)).Return([]string{"newID"}, nil)
+	p.backend.On("ListReleases", p.ctx, "scopeName", aws.String("newID")).Return([]string(nil), nil)
+	p.backend.
+		On("GetRelease", p.ctx, "scopeName", "newID").
+		Return(&secretservice.Release{ID: "newID"}, nil)
+
+	ret, err := secretservice.NewestLiveRelease(p.ctx, p.backend, "scopeName")
+
+	p.NoError(err)
+	p.Nil(ret)
+}
+
+func (p *previewResolverTestSuite) TestNewestLiveRelease_ListError() {
+	p.backend.
+		On("ListReleases", p.ctx, "scopeName", (*string)(nil)).
+		Return([]string(nil), errors.New("bacon"))
+
+	ret, err := secretservice.NewestLiveRelease(p.ctx, p.backend, "scopeName")
+
+	p.Nil(ret)
+	p.EqualError(err, "could not list release IDs: bacon")
+}
+
+func (p *previewResolverTestSuite) TestNewestLiveRelease_GetError() {
+	p.backend.On("ListReleases", p.ctx, "scopeName", (*string)(nil)).Return([]string{"newID"}, nil)
+	p.backend.
+		On("GetRelease", p.ctx, "scopeName", "newID").
+		Return((*secretservice.Release)(nil), errors.New("bacon"))
+
+	ret, err := secretservice.NewestLiveRelease(p.ctx, p.backend, "scopeName")
+
+	p.Nil(ret)
+	p.EqualError(err, "could not get release newID: bacon")
+}
+
+func TestPreviewResolver(t *testing.T) {
+	suite.Run(t, new(previewResolverTestSuite))
+}
@@ -0,0 +1,159 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+)
+
+type proposalResolver struct {
+	backend secretservice.Backend
+	scope   *secretservice.Scope
+	wraps   *secretservice.Proposal
+}
+
+// id: ID!
+func (p *proposalResolver) ID() graphql.ID {
+	return graphql.ID(p.wraps.ID)
+}
+
+// approvals: [Approval!]!
+func (p *proposalResolver) Approvals() []*approvalResolver {
+	ret := make([]*approvalResolver, len(p.wraps.Approvals))
+	for i, approval := range p.wraps.Approvals {
+		ret[i] = &approvalResolver{wraps: approval}
+	}
+	return ret
+}
+
+// baseRelease: Release
+func (p *proposalResolver) BaseRelease() *releaseResolver {
+	if p.wraps.BaseReleaseID == "" {
+		return nil
+	}
+	return newReleaseResolver(p.backend, graphql.ID(p.wraps.BaseReleaseID), p.scope)
+}
+
+// diff: Diff!
+func (p *proposalResolver) Diff(ctx context.Context) (*diffResolver, error) {
+	if p.wraps.ProposalKind() == secretservice.ProposalChange {
+		current, err := p.backend.ListVariables(ctx, fmt.Sprintf("workspace/%s", p.scope.Name))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list variables")
+		}
+		return changeDiff(current, p.wraps), nil
+	}
+
+	if p.wraps.BaseReleaseID == "" {
+		return newDiffResolver(nil, p.wraps.Variables), nil
+	}
+
+	base, err := p.backend.GetRelease(ctx, p.scope.Name, p.wraps.BaseReleaseID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get base release")
+	}
+
+	return newDiffResolver(base.Variables, p.wraps.Variables), nil
+}
+
+// kind: ProposalKind!
+func (p *proposalResolver) Kind() string {
+	return p.wraps.ProposalKind()
+}
+
+// message: String!
+func (p *proposalResolver) Message() string {
+	return p.wraps.Message
+}
+
+// rejectedBy: String
+func (p *proposalResolver) RejectedBy() *string {
+	if p.wraps.RejectedBy == "" {
+		return nil
+	}
+	return &p.wraps.RejectedBy
+}
+
+// release: Release
+func (p *proposalResolver) Release() *releaseResolver {
+	if p.wraps.ReleaseID == "" {
+		return nil
+	}
+	return newReleaseResolver(p.backend, graphql.ID(p.wraps.ReleaseID), p.scope)
+}
+
+// remove: [ID!]!
+func (p *proposalResolver) Remove() []graphql.ID {
+	ret := make([]graphql.ID, len(p.wraps.Remove))
+	for i, name := range p.wraps.Remove {
+		ret[i] = graphql.ID(name)
+	}
+	return ret
+}
+
+// requestedAt: Time!
+func (p *proposalResolver) RequestedAt() graphql.Time {
+	return graphql.Time{Time: p.wraps.RequestedAt}
+}
+
+// requestedBy: String!
+func (p *proposalResolver) RequestedBy() string {
+	return p.wraps.RequestedBy
+}
+
+// settings: ProposedSettings
+func (p *proposalResolver) Settings() *proposedSettingsResolver {
+	if p.wraps.Settings == nil {
+		return nil
+	}
+	return &proposedSettingsResolver{wraps: p.wraps.Settings}
+}
+
+// status: ProposalStatus!
+func (p *proposalResolver) Status() string {
+	return p.wraps.Status
+}
+
+// variables: [Variable!]!
+func (p *proposalResolver) Variables() []*variableResolver {
+	ret := make([]*variableResolver, len(p.wraps.Variables))
+	for i, variable := range p.wraps.Variables {
+		ret[i] = &variableResolver{wraps: variable}
+	}
+	return ret
+}
+
+type approvalResolver struct {
+	wraps *secretservice.Approval
+}
+
+// approvedAt: Time!
+func (a *approvalResolver) ApprovedAt() graphql.Time {
+	return graphql.Time{Time: a.wraps.ApprovedAt}
+}
+
+// principal: String!
+func (a *approvalResolver) Principal() string {
+	return a.wraps.Principal
+}
+
+type proposedSettingsResolver struct {
+	wraps *secretservice.ScopeSettings
+}
+
+// protected: Boolean
+func (s *proposedSettingsResolver) Protected() *bool {
+	return s.wraps.Protected
+}
+
+// requiredApprovals: Int
+func (s *proposedSettingsResolver) RequiredApprovals() *int32 {
+	if s.wraps.RequiredApprovals == nil {
+		return nil
+	}
+	ret := int32(*s.wraps.RequiredApprovals)
+	return &ret
+}
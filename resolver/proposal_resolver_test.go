@@ -0,0 +1,152 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/suite"
+)
+
+type proposalResolverTestSuite struct {
+	suite.Suite
+
+	backend     *mockBackend
+	ctx         context.Context
+	requestedAt time.Time
+
+	sut *proposalResolver
+}
+
+func (p *proposalResolverTestSuite) SetupTest() {
+	p.backend = new(mockBackend)
+	p.ctx = context.Background()
+	p.requestedAt = time.Date(2018, 11, 10, 2, 0, 0, 0, time.UTC)
+	p.sut = &proposalResolver{
+		backend: p.backend,
+		scope:   &secretservice.Scope{Name: "scopeName"},
+		wraps: &secretservice.Proposal{
+			ID:            "proposalID",
+			ScopeName:     "scopeName",
+			Message:       "rotate keys",
+			RequestedBy:   "alice",
+			RequestedAt:   p.requestedAt,
+			BaseReleaseID: "baseID",
+			Variables: []*ssmvars.Variable{
+				{Name: "SECRET", Value: "new", WriteOnly: true},
+			},
+			Approvals: []*secretservice.Approval{
+				{Principal: "bob", ApprovedAt: p.requestedAt.Add(time.Hour)},
+			},
+			Status: secretservice.ProposalPending,
+		},
+	}
+}
+
+func (p *proposalResolverTestSuite) TestFields() {
+	p.EqualValues("proposalID", p.sut.ID())
+	p.Equal("rotate keys", p.sut.Message())
+	p.Equal("alice", p.sut.RequestedBy())
+	p.Equal(p.requestedAt, p.sut.RequestedAt().Time)
+	p.Equal("PENDING", p.sut.Status())
+	p.Nil(p.sut.RejectedBy())
+	p.Nil(p.sut.Release())
+	p.EqualValues("baseID", p.sut.BaseRelease().ID())
+	p.Equal("RELEASE", p.sut.Kind())
+	p.Empty(p.sut.Remove())
+	p.Nil(p.sut.Settings())
+}
+
+func (p *proposalResolverTestSuite) TestSettings() {
+	requiredApprovals := 2
+	p.sut.wraps.Settings = &secretservice.ScopeSettings{RequiredApprovals: &requiredApprovals}
+
+	ret := p.sut.Settings()
+
+	p.EqualValues(2, *ret.RequiredApprovals())
+	p.Nil(ret.Protected())
+}
+
+func (p *proposalResolverTestSuite) TestApprovals() {
+	ret := p.sut.Approvals()
+
+	p.Len(ret, 1)
+	p.Equal("bob", ret[0].Principal())
+	p.Equal(p.requestedAt.Add(time.Hour), ret[0].ApprovedAt().Time)
+}
+
+func (p *proposalResolverTestSuite) TestVariables() {
+	ret := p.sut.Variables()
+
+	p.Len(ret, 1)
+	p.Nil(ret[0].Value())
+}
+
+func (p *proposalResolverTestSuite) TestDiff_OK() {
+	p.backend.
+		On("GetRelease", p.ctx, "scopeName", "baseID").
+		Return(&secretservice.Release{Variables: []*ssmvars.Variable{{Name: "SECRET", Value: "old"}}}, nil)
+
+	ret, err := p.sut.Diff(p.ctx)
+
+	p.NoError(err)
+	p.Len(ret.Changed(), 1)
+}
+
+func (p *proposalResolverTestSuite) TestDiff_NoBase() {
+	p.sut.wraps.BaseReleaseID = ""
+
+	ret, err := p.sut.Diff(p.ctx)
+
+	p.NoError(err)
+	p.Len(ret.Added(), 1)
+	p.Nil(p.sut.BaseRelease())
+}
+
+func (p *proposalResolverTestSuite) TestDiff_BackendFailure() {
+	p.backend.
+		On("GetRelease", p.ctx, "scopeName", "baseID").
+		Return((*secretservice.Release)(nil), errors.New("bacon"))
+
+	ret, err := p.sut.Diff(p.ctx)
+
+	p.Nil(ret)
+	p.EqualError(err, "could not get base release: bacon")
+}
+
+func (p *proposalResolverTestSuite) TestDiff_Change() {
+	p.sut.wraps.Kind = secretservice.ProposalChange
+	p.sut.wraps.Remove = []string{"OLD"}
+
+	p.backend.
+		On("ListVariables", p.ctx, "workspace/scopeName").
+		Return([]*ssmvars.Variable{{Name: "SECRET", Value: "old"}, {Name: "OLD"}}, nil)
+
+	ret, err := p.sut.Diff(p.ctx)
+
+	p.NoError(err)
+	p.Len(ret.Changed(), 1)
+	p.Len(ret.Deleted(), 1)
+	p.Equal([]graphql.ID{"OLD"}, p.sut.Remove())
+}
+
+func (p *proposalResolverTestSuite) TestDiff_ChangeBackendFailure() {
+	p.sut.wraps.Kind = secretservice.ProposalChange
+
+	p.backend.
+		On("ListVariables", p.ctx, "workspace/scopeName").
+		Return(([]*ssmvars.Variable)(nil), errors.New("bacon"))
+
+	ret, err := p.sut.Diff(p.ctx)
+
+	p.Nil(ret)
+	p.EqualError(err, "could not list variables: bacon")
+}
+
+func TestProposalResolver(t *testing.T) {
+	suite.Run(t, new(proposalResolverTestSuite))
+}
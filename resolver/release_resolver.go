@@ -6,15 +6,17 @@ import (
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/secretservice/format"
 	"github.com/pkg/errors"
 )
 
 type releaseResolver struct {
-	backend secretservice.Backend
-	id      graphql.ID
-	mutex   *sync.Mutex
-	scope   *secretservice.Scope
-	wraps   *secretservice.Release
+	backend  secretservice.Backend
+	id       graphql.ID
+	mutex    *sync.Mutex
+	scope    *secretservice.Scope
+	warnings []string
+	wraps    *secretservice.Release
 }
 
 func newReleaseResolver(backend secretservice.Backend, id graphql.ID, scope *secretservice.Scope) *releaseResolver {
@@ -31,6 +33,28 @@ func (r *releaseResolver) ID() graphql.ID {
 	return graphql.ID(r.id)
 }
 
+// baseRelease: Release
+func (r *releaseResolver) BaseRelease(ctx context.Context) (*releaseResolver, error) {
+	if err := r.loadRelease(ctx); err != nil {
+		return nil, err
+	}
+
+	if r.wraps.BaseReleaseID == "" {
+		return nil, nil
+	}
+
+	return newReleaseResolver(r.backend, graphql.ID(r.wraps.BaseReleaseID), r.scope), nil
+}
+
+// contentHash: String!
+func (r *releaseResolver) ContentHash(ctx context.Context) (string, error) {
+	if err := r.loadRelease(ctx); err != nil {
+		return "", err
+	}
+
+	return r.wraps.ContentHash, nil
+}
+
 // diff(since: ID!) Diff!
 func (r *releaseResolver) Diff(ctx context.Context, args diffArgs) (*diffResolver, error) {
 	if err := r.loadRelease(ctx); err != nil {
@@ -54,6 +78,35 @@ func (r *releaseResolver) Live(ctx context.Context) (bool, error) {
 	return r.wraps.Live, nil
 }
 
+type renderArgs struct {
+	Format     string
+	Privileged *bool
+}
+
+// render(format: RenderFormat!, privileged: Boolean): String!
+func (r *releaseResolver) Render(ctx context.Context, args renderArgs) (string, error) {
+	privileged := args.Privileged != nil && *args.Privileged
+	if privileged {
+		if !isAdmin(ctx) {
+			return "", secretservice.Forbidden("rendering write-only variables requires the %s role", secretservice.RoleAdmin)
+		}
+	}
+
+	if err := r.loadRelease(ctx); err != nil {
+		return "", err
+	}
+
+	ret, err := format.Render(args.Format, r.wraps.Variables, format.Options{
+		IncludeWriteOnly: privileged,
+		Name:             r.scope.Name,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "could not render release")
+	}
+
+	return ret, nil
+}
+
 // timestamp: Int!
 func (r *releaseResolver) Timestamp() (int32, error) {
 	ret, err := r.wraps.Timestamp()
@@ -63,6 +116,23 @@ func (r *releaseResolver) Timestamp() (int32, error) {
 	return int32(ret), nil
 }
 
+// verified: Boolean!
+func (r *releaseResolver) Verified(ctx context.Context) (bool, error) {
+	if err := r.loadRelease(ctx); err != nil {
+		return false, err
+	}
+
+	return r.wraps.Verified, nil
+}
+
+// warnings: [String!]!
+func (r *releaseResolver) Warnings() []string {
+	if r.warnings == nil {
+		return []string{}
+	}
+	return r.warnings
+}
+
 // variables: [Variable!]!
 func (r *releaseResolver) Variables(ctx context.Context) ([]*variableResolver, error) {
 	if err := r.loadRelease(ctx); err != nil {
@@ -9,6 +9,7 @@ import (
 	"github.com/marcinwyszynski/secretservice"
 	"github.com/marcinwyszynski/ssmvars"
 	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -34,6 +35,51 @@ func (r *releaseResolverTestSuite) TestID() {
 	r.EqualValues("releaseID", r.sut.ID())
 }
 
+func (r *releaseResolverTestSuite) TestBaseRelease_OK() {
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return(&secretservice.Release{BaseReleaseID: "baseID"}, nil)
+
+	ret, err := r.sut.BaseRelease(r.ctx)
+
+	r.NoError(err)
+	r.EqualValues("baseID", ret.ID())
+	r.Equal(r.sut.scope, ret.scope)
+}
+
+func (r *releaseResolverTestSuite) TestBaseRelease_None() {
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return(&secretservice.Release{}, nil)
+
+	ret, err := r.sut.BaseRelease(r.ctx)
+
+	r.NoError(err)
+	r.Nil(ret)
+}
+
+func (r *releaseResolverTestSuite) TestContentHash_OK() {
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return(&secretservice.Release{ContentHash: "hash"}, nil)
+
+	ret, err := r.sut.ContentHash(r.ctx)
+
+	r.NoError(err)
+	r.Equal("hash", ret)
+}
+
+func (r *releaseResolverTestSuite) TestContentHash_BackendFailure() {
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return((*secretservice.Release)(nil), errors.New("bacon"))
+
+	ret, err := r.sut.ContentHash(r.ctx)
+
+	r.Empty(ret)
+	r.EqualError(err, "could not lazily retrieve release: bacon")
+}
+
 func (r *releaseResolverTestSuite) TestDiff_OK() {
 	oldVariable := &ssmvars.Variable{Name: "OLD"}
 	newVariable := &ssmvars.Variable{Name: "NEW"}
@@ -120,6 +166,67 @@ func (r *releaseResolverTestSuite) TestLive_BackendFailure() {
 	r.False(ret)
 }
 
+func (r *releaseResolverTestSuite) TestRender_OK() {
+	r.ctx = secretservice.WithPrincipal(r.ctx, &secretservice.Principal{
+		ID:    "alice",
+		Roles: []string{secretservice.RoleAdmin},
+	})
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return(&secretservice.Release{Variables: []*ssmvars.Variable{
+			{Name: "PUBLIC", Value: "public"},
+			{Name: "SECRET", Value: "secret", WriteOnly: true},
+		}}, nil)
+
+	ret, err := r.sut.Render(r.ctx, renderArgs{Format: "DOTENV"})
+	r.NoError(err)
+	r.Equal("PUBLIC=\"public\"\n", ret)
+
+	privileged := true
+	ret, err = r.sut.Render(r.ctx, renderArgs{Format: "DOTENV", Privileged: &privileged})
+	r.NoError(err)
+	r.Equal("PUBLIC=\"public\"\nSECRET=\"secret\"\n", ret)
+}
+
+func (r *releaseResolverTestSuite) TestRender_PrivilegedForbidden() {
+	privileged := true
+
+	for _, ctx := range []context.Context{
+		r.ctx,
+		secretservice.WithPrincipal(r.ctx, &secretservice.Principal{ID: "bob"}),
+	} {
+		ret, err := r.sut.Render(ctx, renderArgs{Format: "DOTENV", Privileged: &privileged})
+
+		r.Empty(ret)
+		r.EqualError(err, "rendering write-only variables requires the admin role")
+		r.Equal(secretservice.CodeForbidden, secretservice.ErrorCode(err))
+	}
+
+	r.backend.AssertNotCalled(r.T(), "GetRelease", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (r *releaseResolverTestSuite) TestRender_UnsupportedFormat() {
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return(new(secretservice.Release), nil)
+
+	ret, err := r.sut.Render(r.ctx, renderArgs{Format: "BACON"})
+
+	r.Empty(ret)
+	r.EqualError(err, `could not render release: unsupported format "BACON"`)
+}
+
+func (r *releaseResolverTestSuite) TestRender_BackendFailure() {
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return((*secretservice.Release)(nil), errors.New("bacon"))
+
+	ret, err := r.sut.Render(r.ctx, renderArgs{Format: "DOTENV"})
+
+	r.Empty(ret)
+	r.EqualError(err, "could not lazily retrieve release: bacon")
+}
+
 func (r *releaseResolverTestSuite) TestTimestamp_OK() {
 	r.sut.wraps = &secretservice.Release{
 		ID: ulid.MustNew(ulid.MaxTime()-ulid.Now(), nil).String(),
@@ -140,6 +247,28 @@ func (r *releaseResolverTestSuite) TestTimestamp_FailedToParse() {
 	r.EqualValues(-1, timestamp)
 }
 
+func (r *releaseResolverTestSuite) TestVerified_OK() {
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return(&secretservice.Release{Verified: true}, nil)
+
+	ret, err := r.sut.Verified(r.ctx)
+
+	r.NoError(err)
+	r.True(ret)
+}
+
+func (r *releaseResolverTestSuite) TestVerified_BackendFailure() {
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return((*secretservice.Release)(nil), errors.New("bacon"))
+
+	ret, err := r.sut.Verified(r.ctx)
+
+	r.EqualError(err, "could not lazily retrieve release: bacon")
+	r.False(ret)
+}
+
 func (r *releaseResolverTestSuite) TestVariables_OK() {
 	variable := &ssmvars.Variable{Name: "BACON"}
 
@@ -4,13 +4,23 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"sort"
+	"time"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/graph-gophers/graphql-go"
 	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/secretservice/format"
+	"github.com/marcinwyszynski/secretservice/generator"
 	"github.com/marcinwyszynski/ssmvars"
 	"github.com/pkg/errors"
 )
 
+const (
+	importModeMerge   = "MERGE"
+	importModeReplace = "REPLACE"
+)
+
 type rootResolver struct {
 	wraps secretservice.Backend
 }
@@ -34,14 +44,118 @@ func (r *rootResolver) Scope(ctx context.Context, args scopeArgs) (*scopeResolve
 	return &scopeResolver{backend: r.wraps, wraps: scope}, nil
 }
 
+type diffTargetInput struct {
+	ScopeID   graphql.ID
+	ReleaseID *graphql.ID
+}
+
+type compareArgs struct {
+	Left, Right diffTargetInput
+}
+
+// compare(left: DiffTarget!, right: DiffTarget!): Diff!
+func (r *rootResolver) Compare(ctx context.Context, args compareArgs) (*diffResolver, error) {
+	left, err := r.diffTarget(ctx, args.Left)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve left side")
+	}
+
+	right, err := r.diffTarget(ctx, args.Right)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve right side")
+	}
+
+	return newDiffResolver(left, right), nil
+}
+
+// diffTarget returns Variables from a Release if its ID is given, or from
+// the current workspace otherwise.
+func (r *rootResolver) diffTarget(ctx context.Context, target diffTargetInput) ([]*ssmvars.Variable, error) {
+	scope, err := r.wraps.Scope(ctx, string(target.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	if target.ReleaseID == nil {
+		variables, err := r.wraps.ListVariables(ctx, fmt.Sprintf("workspace/%s", scope.Name))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list variables")
+		}
+		return variables, nil
+	}
+
+	release, err := r.wraps.GetRelease(ctx, scope.Name, string(*target.ReleaseID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get release")
+	}
+
+	return release.Variables, nil
+}
+
+// previewRelease(scopeId: ID!): ReleasePreview!
+func (r *rootResolver) PreviewRelease(ctx context.Context, args scopeArgs) (*previewResolver, error) {
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	variables, err := r.wraps.ListVariables(ctx, fmt.Sprintf("workspace/%s", scope.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variables")
+	}
+
+	metadata, err := r.wraps.ListMetadata(ctx, scope.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variable metadata")
+	}
+
+	base, err := secretservice.NewestLiveRelease(ctx, r.wraps, scope.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find the newest live release")
+	}
+
+	return &previewResolver{
+		backend:   r.wraps,
+		base:      base,
+		metadata:  metadata,
+		scope:     scope,
+		variables: variables,
+	}, nil
+}
+
+// scheduledOperations(scopeId: ID!): [ScheduledOperation!]!
+func (r *rootResolver) ScheduledOperations(ctx context.Context, args scopeArgs) ([]*scheduledOperationResolver, error) {
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	operations, err := r.wraps.ListScheduledOperations(ctx, scope.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list scheduled operations")
+	}
+
+	ret := make([]*scheduledOperationResolver, len(operations))
+	for i, operation := range operations {
+		ret[i] = &scheduledOperationResolver{backend: r.wraps, scope: scope, wraps: operation}
+	}
+	return ret, nil
+}
+
 type createScopeArgs struct {
-	Name, KMSKeyID string
+	Name, KMSKeyID    string
+	RequiredApprovals *int32
+	ClientMutationID  *string
 }
 
-// createScope(name: String!, kmsKeyId: String!): Scope!
+// createScope(name: String!, kmsKeyId: String!, requiredApprovals: Int, clientMutationId: String): Scope!
 func (r *rootResolver) CreateScope(ctx context.Context, args createScopeArgs) (*scopeResolver, error) {
 	scopeName := args.Name
-	keyID := args.KMSKeyID
+	scope := &secretservice.Scope{Name: scopeName, KMSKeyID: args.KMSKeyID}
+
+	if err := applyScopeSettings(scope, scopeSettingsInput{RequiredApprovals: args.RequiredApprovals}.toSettings()); err != nil {
+		return nil, err
+	}
 
 	scopeKeys, err := r.wraps.ListVariables(ctx, "scopes")
 	if err != nil {
@@ -49,22 +163,106 @@ func (r *rootResolver) CreateScope(ctx context.Context, args createScopeArgs) (*
 	}
 	for _, scope := range scopeKeys {
 		if scope.Name == scopeName {
-			return nil, errors.Errorf("scope %q already exists", scopeName)
+			return nil, secretservice.AlreadyExists("scope %q already exists", scopeName)
 		}
 	}
 
-	_, err = r.wraps.CreateVariable(ctx, "scopes", &ssmvars.Variable{Name: scopeName, Value: keyID})
+	_, err = r.wraps.CreateVariable(ctx, "scopes", &ssmvars.Variable{Name: scopeName, Value: scope.Encode()})
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create scope")
 	}
 
-	scope := &secretservice.Scope{Name: scopeName, KMSKeyID: keyID}
 	return &scopeResolver{backend: r.wraps, wraps: scope}, nil
 }
 
+type scopeSettingsInput struct {
+	RequiredApprovals *int32
+	Protected         *bool
+}
+
+func (s scopeSettingsInput) toSettings() *secretservice.ScopeSettings {
+	ret := &secretservice.ScopeSettings{Protected: s.Protected}
+	if s.RequiredApprovals != nil {
+		requiredApprovals := int(*s.RequiredApprovals)
+		ret.RequiredApprovals = &requiredApprovals
+	}
+	return ret
+}
+
+type configureScopeArgs struct {
+	ScopeID  graphql.ID
+	Settings scopeSettingsInput
+}
+
+// configureScope(scopeId: ID!, settings: ScopeSettings!): Scope!
+func (r *rootResolver) ConfigureScope(ctx context.Context, args configureScopeArgs) (*scopeResolver, error) {
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	// Settings decide how changes to the Scope are reviewed, so changing them
+	// directly is reserved for those who could bypass the review anyway, even
+	// if the Scope is not protected yet.
+	if !isAdmin(ctx) {
+		return nil, secretservice.Forbidden("changing settings of scope %q requires the %s role or an approved change request", scope.Name, secretservice.RoleAdmin)
+	}
+
+	if err := r.updateScope(ctx, scope, args.Settings.toSettings()); err != nil {
+		return nil, err
+	}
+
+	return &scopeResolver{backend: r.wraps, wraps: scope}, nil
+}
+
+// updateScope changes the settings of the Scope and stores it.
+func (r *rootResolver) updateScope(ctx context.Context, scope *secretservice.Scope, settings *secretservice.ScopeSettings) error {
+	if err := applyScopeSettings(scope, settings); err != nil {
+		return err
+	}
+
+	_, err := r.wraps.CreateVariable(ctx, "scopes", &ssmvars.Variable{Name: scope.Name, Value: scope.Encode()})
+	return errors.Wrap(err, "could not update scope")
+}
+
+// applyScopeSettings changes the settings which are provided in the input,
+// leaving the rest intact.
+func applyScopeSettings(scope *secretservice.Scope, settings *secretservice.ScopeSettings) error {
+	if settings.RequiredApprovals != nil {
+		if *settings.RequiredApprovals < 0 {
+			return secretservice.Validation("requiredApprovals can not be negative")
+		}
+		scope.RequiredApprovals = *settings.RequiredApprovals
+	}
+	if settings.Protected != nil {
+		scope.Protected = *settings.Protected
+	}
+	return nil
+}
+
+// checkWritable returns an error if the workspace of a protected Scope is
+// edited directly by a Principal without the admin role. Everyone else has to
+// go through an approved change request.
+func checkWritable(ctx context.Context, scope *secretservice.Scope) error {
+	if !scope.Protected || isAdmin(ctx) {
+		return nil
+	}
+
+	return secretservice.Forbidden("scope %q is protected, changes require the %s role or an approved change request", scope.Name, secretservice.RoleAdmin)
+}
+
+// isAdmin returns true if the request is made by a Principal with the admin
+// role.
+func isAdmin(ctx context.Context) bool {
+	principal, ok := secretservice.PrincipalFromContext(ctx)
+	return ok && principal.HasRole(secretservice.RoleAdmin)
+}
+
 type variableInput struct {
 	Name, Value string
 	WriteOnly   bool
+	ExpiresAt   *graphql.Time
+	RotateEvery *duration
 }
 
 func (v variableInput) toSSM() *ssmvars.Variable {
@@ -75,6 +273,17 @@ func (v variableInput) toSSM() *ssmvars.Variable {
 	}
 }
 
+func (v variableInput) metadata(now time.Time) *secretservice.Metadata {
+	ret := &secretservice.Metadata{UpdatedAt: now}
+	if v.ExpiresAt != nil {
+		ret.ExpiresAt = &v.ExpiresAt.Time
+	}
+	if v.RotateEvery != nil {
+		ret.RotateEvery = &v.RotateEvery.Duration
+	}
+	return ret
+}
+
 type addVariableArgs struct {
 	ScopeID  graphql.ID
 	Variable variableInput
@@ -87,6 +296,10 @@ func (r *rootResolver) AddVariable(ctx context.Context, args addVariableArgs) (*
 		return nil, errors.Wrap(err, "could not retrieve scope")
 	}
 
+	if err := checkWritable(ctx, scope); err != nil {
+		return nil, err
+	}
+
 	variable, err := r.wraps.CreateVariable(
 		ctx,
 		path.Join("workspace", scope.Name),
@@ -96,7 +309,278 @@ func (r *rootResolver) AddVariable(ctx context.Context, args addVariableArgs) (*
 		return nil, errors.Wrap(err, "could not create variable")
 	}
 
-	return &variableResolver{wraps: variable}, nil
+	metadata := args.Variable.metadata(time.Now())
+	if err := r.wraps.SetMetadata(ctx, scope.Name, variable.Name, metadata); err != nil {
+		return nil, errors.Wrap(err, "could not set variable metadata")
+	}
+
+	return &variableResolver{metadata: metadata, wraps: variable}, nil
+}
+
+type secretSpecInput struct {
+	Kind    string
+	Length  *int32
+	Charset *string
+}
+
+func (s secretSpecInput) toSpec() generator.Spec {
+	ret := generator.Spec{Kind: s.Kind}
+	if s.Length != nil {
+		ret.Length = int(*s.Length)
+	}
+	if s.Charset != nil {
+		ret.Charset = *s.Charset
+	}
+	return ret
+}
+
+type generateVariableArgs struct {
+	ScopeID graphql.ID
+	Name    string
+	Spec    secretSpecInput
+}
+
+// generateVariable(scopeId: ID!, name: String!, spec: SecretSpec!): Variable!
+func (r *rootResolver) GenerateVariable(ctx context.Context, args generateVariableArgs) (*variableResolver, error) {
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	if err := checkWritable(ctx, scope); err != nil {
+		return nil, err
+	}
+
+	if err := secretservice.ValidateVariableName(args.Name); err != nil {
+		return nil, err
+	}
+
+	value, err := generator.Generate(args.Spec.toSpec())
+	if err != nil {
+		return nil, secretservice.Validation("could not generate value: %v", err)
+	}
+
+	existing, err := r.wraps.ListMetadata(ctx, scope.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variable metadata")
+	}
+
+	variable, err := r.wraps.CreateVariable(
+		ctx,
+		path.Join("workspace", scope.Name),
+		&ssmvars.Variable{Name: args.Name, Value: value, WriteOnly: true},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create variable")
+	}
+
+	// A regenerated Variable keeps its expiry and rotation settings, but its
+	// rotation deadline starts over.
+	metadata := new(secretservice.Metadata)
+	if old, exists := existing[variable.Name]; exists {
+		*metadata = *old
+	}
+	metadata.UpdatedAt = time.Now()
+
+	if err := r.wraps.SetMetadata(ctx, scope.Name, variable.Name, metadata); err != nil {
+		return nil, errors.Wrap(err, "could not set variable metadata")
+	}
+
+	return &variableResolver{metadata: metadata, wraps: variable}, nil
+}
+
+type importVariablesArgs struct {
+	ScopeID   graphql.ID
+	Format    string
+	Document  string
+	Mode      string
+	WriteOnly *[]string
+	DryRun    *bool
+}
+
+// importVariables(scopeId: ID!, format: ImportFormat!, document: String!, mode: ImportMode!, writeOnly: [String!], dryRun: Boolean): Diff!
+func (r *rootResolver) ImportVariables(ctx context.Context, args importVariablesArgs) (*diffResolver, error) {
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	imported, err := format.Parse(args.Format, args.Document)
+	if err != nil {
+		return nil, secretservice.Validation("could not parse document: %v", err)
+	}
+
+	current, err := r.wraps.ListVariables(ctx, fmt.Sprintf("workspace/%s", scope.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variables")
+	}
+
+	variables, err := importedWorkspace(current, imported, args)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := newDiffResolver(current, variables)
+	if args.DryRun != nil && *args.DryRun {
+		return diff, nil
+	}
+
+	if err := checkWritable(ctx, scope); err != nil {
+		return nil, err
+	}
+
+	existing, err := r.wraps.ListMetadata(ctx, scope.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variable metadata")
+	}
+
+	metadata := preservedMetadata(existing, diff, time.Now())
+	if err := applyDiff(ctx, r.wraps, scope.Name, diff, metadata); err != nil {
+		return nil, errors.Wrap(err, "could not apply import")
+	}
+
+	return diff, nil
+}
+
+// importedWorkspace validates imported variables and returns the full content
+// of the workspace after the import. Variables which are currently write-only
+// remain write-only.
+func importedWorkspace(current, imported []*ssmvars.Variable, args importVariablesArgs) ([]*ssmvars.Variable, error) {
+	writeOnly := make(map[string]bool)
+	if args.WriteOnly != nil {
+		for _, name := range *args.WriteOnly {
+			writeOnly[name] = true
+		}
+	}
+
+	existing := make(map[string]*ssmvars.Variable, len(current))
+	for _, variable := range current {
+		existing[variable.Name] = variable
+	}
+
+	importedNames := make(map[string]bool, len(imported))
+
+	for _, variable := range imported {
+		if err := secretservice.ValidateVariableName(variable.Name); err != nil {
+			return nil, err
+		}
+		importedNames[variable.Name] = true
+
+		old, exists := existing[variable.Name]
+		variable.WriteOnly = writeOnly[variable.Name] || (exists && old.WriteOnly)
+	}
+
+	for name := range writeOnly {
+		if !importedNames[name] {
+			return nil, secretservice.Validation("write-only variable %q is not in the document", name)
+		}
+	}
+
+	switch args.Mode {
+	case importModeMerge:
+		return mergeVariables(current, imported, nil), nil
+	case importModeReplace:
+		return imported, nil
+	default:
+		return nil, secretservice.Validation("unsupported import mode %q", args.Mode)
+	}
+}
+
+type applyChangesArgs struct {
+	ScopeID          graphql.ID
+	Set              *[]variableInput
+	Remove           *[]graphql.ID
+	ExpectedRevision *string
+}
+
+// applyChanges(scopeId: ID!, set: [VariableInput!], remove: [ID!], expectedRevision: String): Diff!
+func (r *rootResolver) ApplyChanges(ctx context.Context, args applyChangesArgs) (*diffResolver, error) {
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	if err := checkWritable(ctx, scope); err != nil {
+		return nil, err
+	}
+
+	current, err := r.wraps.ListVariables(ctx, fmt.Sprintf("workspace/%s", scope.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variables")
+	}
+
+	if args.ExpectedRevision != nil {
+		if revision := r.wraps.ContentHash(current); revision != *args.ExpectedRevision {
+			return nil, secretservice.Conflict("workspace is at revision %q, expected %q", revision, *args.ExpectedRevision)
+		}
+	}
+
+	var set []variableInput
+	if args.Set != nil {
+		set = *args.Set
+	}
+
+	var remove []graphql.ID
+	if args.Remove != nil {
+		remove = *args.Remove
+	}
+
+	variables, removed, err := validateChanges(current, set, remove)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing map[string]*secretservice.Metadata
+	if len(set) > 0 {
+		if existing, err = r.wraps.ListMetadata(ctx, scope.Name); err != nil {
+			return nil, errors.Wrap(err, "could not list variable metadata")
+		}
+	}
+
+	diff := newDiffResolver(current, mergeVariables(current, variables, removed))
+	metadata := changedMetadata(existing, set, diff, time.Now())
+	if err := applyDiff(ctx, r.wraps, scope.Name, diff, metadata); err != nil {
+		return nil, errors.Wrap(err, "could not apply changes")
+	}
+
+	return diff, nil
+}
+
+// validateChanges checks a batch of changes against the current workspace
+// before any of them is applied.
+func validateChanges(current []*ssmvars.Variable, set []variableInput, remove []graphql.ID) ([]*ssmvars.Variable, map[string]bool, error) {
+	existing := make(map[string]bool, len(current))
+	for _, variable := range current {
+		existing[variable.Name] = true
+	}
+
+	variables := make([]*ssmvars.Variable, 0, len(set))
+	seen := make(map[string]bool, len(set))
+
+	for _, input := range set {
+		if err := secretservice.ValidateVariableName(input.Name); err != nil {
+			return nil, nil, err
+		}
+		if seen[input.Name] {
+			return nil, nil, secretservice.Validation("variable %q is set more than once", input.Name)
+		}
+		seen[input.Name] = true
+		variables = append(variables, input.toSSM())
+	}
+
+	removed := make(map[string]bool, len(remove))
+	for _, id := range remove {
+		name := string(id)
+		if seen[name] {
+			return nil, nil, secretservice.Validation("variable %q is both set and removed", name)
+		}
+		if !existing[name] {
+			return nil, nil, secretservice.Validation("variable %q does not exist", name)
+		}
+		removed[name] = true
+	}
+
+	return variables, removed, nil
 }
 
 type removeVariableArgs struct {
@@ -106,16 +590,38 @@ type removeVariableArgs struct {
 
 // removeVariable(scopeId: ID!, id: ID!): Variable!
 func (r *rootResolver) RemoveVariable(ctx context.Context, args removeVariableArgs) (*variableResolver, error) {
-	variable, err := r.wraps.DeleteVariable(ctx, fmt.Sprintf("workspace/%s", args.ScopeID), string(args.ID))
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	if err := checkWritable(ctx, scope); err != nil {
+		return nil, err
+	}
+
+	variable, err := r.wraps.DeleteVariable(ctx, fmt.Sprintf("workspace/%s", scope.Name), string(args.ID))
 	if err != nil {
 		return nil, errors.Wrap(err, "could not remove variable")
 	}
 
+	if err := r.wraps.DeleteMetadata(ctx, scope.Name, string(args.ID)); err != nil {
+		return nil, errors.Wrap(err, "could not remove variable metadata")
+	}
+
 	return &variableResolver{wraps: variable}, nil
 }
 
-// createRelease(scopeId: ID!): Release!
-func (r *rootResolver) CreateRelease(ctx context.Context, args scopeArgs) (*releaseResolver, error) {
+type createReleaseArgs struct {
+	ScopeID     graphql.ID
+	IfChanged   *bool
+	Include     *[]graphql.ID
+	BaseRelease *graphql.ID
+
+	ClientMutationID *string
+}
+
+// createRelease(scopeId: ID!, ifChanged: Boolean, include: [ID!], baseRelease: ID, clientMutationId: String): Release!
+func (r *rootResolver) CreateRelease(ctx context.Context, args createReleaseArgs) (*releaseResolver, error) {
 	scopeName := string(args.ScopeID)
 
 	scope, err := r.wraps.Scope(ctx, scopeName)
@@ -123,17 +629,589 @@ func (r *rootResolver) CreateRelease(ctx context.Context, args scopeArgs) (*rele
 		return nil, errors.Wrap(err, "could not retrieve scope")
 	}
 
+	if scope.RequiredApprovals > 0 {
+		return nil, secretservice.Forbidden("releases in scope %q require approval, use requestRelease instead", scope.Name)
+	}
+
 	variables, err := r.wraps.ListVariables(ctx, fmt.Sprintf("workspace/%s", scope.Name))
 	if err != nil {
 		return nil, errors.Wrap(err, "could not list variables")
 	}
 
-	release, err := r.wraps.CreateRelease(ctx, scopeName, variables)
+	metadata, err := r.wraps.ListMetadata(ctx, scope.Name)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not create a release")
+		return nil, errors.Wrap(err, "could not list variable metadata")
+	}
+
+	var baseReleaseID string
+	if args.Include != nil {
+		variables, baseReleaseID, err = r.partialRelease(ctx, scope.Name, variables, *args.Include, args.BaseRelease)
+		if err != nil {
+			return nil, err
+		}
+	} else if args.BaseRelease != nil {
+		return nil, secretservice.Validation("baseRelease can only be used together with include")
 	}
 
-	return newReleaseResolver(r.wraps, graphql.ID(release.ID), scope), nil
+	var release *secretservice.Release
+	if args.IfChanged != nil && *args.IfChanged {
+		if release, err = r.unchangedRelease(ctx, scope.Name, variables); err != nil {
+			return nil, err
+		}
+	}
+
+	if release == nil {
+		if release, err = r.wraps.CreateRelease(ctx, scopeName, variables, baseReleaseID); err != nil {
+			return nil, errors.Wrap(err, "could not create a release")
+		}
+	}
+
+	ret := newReleaseResolver(r.wraps, graphql.ID(release.ID), scope)
+	ret.wraps = release
+	ret.warnings = expiryWarnings(variables, metadata, time.Now())
+	return ret, nil
+}
+
+// partialRelease returns Variables of the base Release with only the selected
+// workspace Variables applied on top, along with the ID of the base Release.
+// If no base Release is given, the newest live one is used.
+func (r *rootResolver) partialRelease(ctx context.Context, scopeName string, workspace []*ssmvars.Variable, include []graphql.ID, baseReleaseID *graphql.ID) ([]*ssmvars.Variable, string, error) {
+	var base *secretservice.Release
+	var err error
+
+	if baseReleaseID != nil {
+		if base, err = r.wraps.GetRelease(ctx, scopeName, string(*baseReleaseID)); err != nil {
+			return nil, "", errors.Wrap(err, "could not get base release")
+		}
+	} else if base, err = secretservice.NewestLiveRelease(ctx, r.wraps, scopeName); err != nil {
+		return nil, "", errors.Wrap(err, "could not find the newest live release")
+	}
+
+	available := make(map[string]*ssmvars.Variable, len(workspace))
+	for _, variable := range workspace {
+		available[variable.Name] = variable
+	}
+
+	selected := make([]*ssmvars.Variable, 0, len(include))
+	for _, id := range include {
+		variable, exists := available[string(id)]
+		if !exists {
+			return nil, "", secretservice.Validation("variable %q is not in the workspace", id)
+		}
+		selected = append(selected, variable)
+	}
+
+	if base == nil {
+		return selected, "", nil
+	}
+
+	variables := mergeVariables(base.Variables, selected, nil)
+	sort.Slice(variables, func(i, j int) bool {
+		return variables[i].Name < variables[j].Name
+	})
+
+	return variables, base.ID, nil
+}
+
+// unchangedRelease returns the newest live Release if its content is the
+// same as that of the workspace, or nil otherwise.
+func (r *rootResolver) unchangedRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable) (*secretservice.Release, error) {
+	latest, err := secretservice.NewestLiveRelease(ctx, r.wraps, scopeName)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find the newest live release")
+	}
+
+	// The stored hash of the Release may have been computed with a previous
+	// key, so it is computed again.
+	if latest == nil || r.wraps.ContentHash(latest.Variables) != r.wraps.ContentHash(variables) {
+		return nil, nil
+	}
+
+	return latest, nil
+}
+
+type scheduleOperationArgs struct {
+	ScopeID   graphql.ID
+	Kind      string
+	RunAt     graphql.Time
+	ReleaseID *graphql.ID
+
+	ClientMutationID *string
+}
+
+// scheduleOperation(scopeId: ID!, kind: OperationKind!, runAt: Time!, releaseId: ID, clientMutationId: String): ScheduledOperation!
+func (r *rootResolver) ScheduleOperation(ctx context.Context, args scheduleOperationArgs) (*scheduledOperationResolver, error) {
+	operation := &secretservice.ScheduledOperation{
+		ScopeName: string(args.ScopeID),
+		Kind:      args.Kind,
+		RunAt:     args.RunAt.Time,
+	}
+
+	switch args.Kind {
+	case secretservice.OperationArchiveRelease:
+		if args.ReleaseID == nil {
+			return nil, secretservice.Validation("releaseId is required to archive a release")
+		}
+		operation.ReleaseID = string(*args.ReleaseID)
+	case secretservice.OperationCreateRelease:
+		if args.ReleaseID != nil {
+			return nil, secretservice.Validation("releaseId can not be used to create a release")
+		}
+	default:
+		return nil, secretservice.Validation("unsupported operation kind %q", args.Kind)
+	}
+
+	if !operation.RunAt.After(time.Now()) {
+		return nil, secretservice.Validation("operations can only be scheduled in the future")
+	}
+
+	scope, err := r.wraps.Scope(ctx, operation.ScopeName)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	if operation.Kind == secretservice.OperationCreateRelease && scope.RequiredApprovals > 0 {
+		return nil, secretservice.Forbidden("releases in scope %q require approval and can not be scheduled", scope.Name)
+	}
+
+	if operation.Kind == secretservice.OperationArchiveRelease {
+		if _, err := r.wraps.GetRelease(ctx, scope.Name, operation.ReleaseID); err != nil {
+			return nil, errors.Wrap(err, "could not get release")
+		}
+	}
+
+	scheduled, err := r.wraps.ScheduleOperation(ctx, operation)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not schedule operation")
+	}
+
+	return &scheduledOperationResolver{backend: r.wraps, scope: scope, wraps: scheduled}, nil
+}
+
+type cancelScheduledOperationArgs struct {
+	ScopeID graphql.ID
+	ID      graphql.ID
+}
+
+// cancelScheduledOperation(scopeId: ID!, id: ID!): ScheduledOperation!
+func (r *rootResolver) CancelScheduledOperation(ctx context.Context, args cancelScheduledOperationArgs) (*scheduledOperationResolver, error) {
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	operation, err := r.wraps.GetScheduledOperation(ctx, scope.Name, string(args.ID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get scheduled operation")
+	}
+
+	if operation.Status != secretservice.OperationPending {
+		return nil, secretservice.Conflict("operation %s is %s and can not be cancelled", operation.ID, operation.Status)
+	}
+
+	operation.Status = secretservice.OperationCancelled
+	if err := r.wraps.UpdateScheduledOperation(ctx, operation); err != nil {
+		return nil, errors.Wrap(err, "could not cancel scheduled operation")
+	}
+
+	return &scheduledOperationResolver{backend: r.wraps, scope: scope, wraps: operation}, nil
+}
+
+// proposals(scopeId: ID!): [Proposal!]!
+func (r *rootResolver) Proposals(ctx context.Context, args scopeArgs) ([]*proposalResolver, error) {
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	proposals, err := r.wraps.ListProposals(ctx, scope.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list proposals")
+	}
+
+	ret := make([]*proposalResolver, len(proposals))
+	for i, proposal := range proposals {
+		ret[i] = &proposalResolver{backend: r.wraps, scope: scope, wraps: proposal}
+	}
+	return ret, nil
+}
+
+type requestReleaseArgs struct {
+	ScopeID graphql.ID
+	Message string
+
+	ClientMutationID *string
+}
+
+// requestRelease(scopeId: ID!, message: String!, clientMutationId: String): Proposal!
+func (r *rootResolver) RequestRelease(ctx context.Context, args requestReleaseArgs) (*proposalResolver, error) {
+	principal, ok := secretservice.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, secretservice.Forbidden("requesting a release requires an authenticated principal")
+	}
+
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	variables, err := r.wraps.ListVariables(ctx, fmt.Sprintf("workspace/%s", scope.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variables")
+	}
+
+	base, err := secretservice.NewestLiveRelease(ctx, r.wraps, scope.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find the newest live release")
+	}
+
+	proposal := &secretservice.Proposal{
+		ScopeName:   scope.Name,
+		Message:     args.Message,
+		Kind:        secretservice.ProposalRelease,
+		RequestedBy: principal.ID,
+		RequestedAt: time.Now(),
+		Variables:   variables,
+		Approvals:   []*secretservice.Approval{},
+	}
+	if base != nil {
+		proposal.BaseReleaseID = base.ID
+	}
+
+	if proposal, err = r.wraps.CreateProposal(ctx, proposal); err != nil {
+		return nil, errors.Wrap(err, "could not create proposal")
+	}
+
+	// Scopes which do not require approvals release right away.
+	if scope.RequiredApprovals == 0 {
+		if err := r.saveProposal(ctx, scope, proposal); err != nil {
+			return nil, err
+		}
+	}
+
+	return &proposalResolver{backend: r.wraps, scope: scope, wraps: proposal}, nil
+}
+
+type requestChangeArgs struct {
+	ScopeID  graphql.ID
+	Message  string
+	Set      *[]variableInput
+	Remove   *[]graphql.ID
+	Settings *scopeSettingsInput
+
+	ClientMutationID *string
+}
+
+// requestChange(scopeId: ID!, message: String!, set: [VariableInput!], remove: [ID!], settings: ScopeSettings, clientMutationId: String): Proposal!
+func (r *rootResolver) RequestChange(ctx context.Context, args requestChangeArgs) (*proposalResolver, error) {
+	principal, ok := secretservice.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, secretservice.Forbidden("requesting a change requires an authenticated principal")
+	}
+
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	current, err := r.wraps.ListVariables(ctx, fmt.Sprintf("workspace/%s", scope.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variables")
+	}
+
+	var set []variableInput
+	if args.Set != nil {
+		set = *args.Set
+	}
+
+	var remove []graphql.ID
+	if args.Remove != nil {
+		remove = *args.Remove
+	}
+
+	variables, _, err := validateChanges(current, set, remove)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	proposal := &secretservice.Proposal{
+		ScopeName:   scope.Name,
+		Kind:        secretservice.ProposalChange,
+		Message:     args.Message,
+		RequestedBy: principal.ID,
+		RequestedAt: now,
+		Variables:   variables,
+		Metadata:    make(map[string]*secretservice.Metadata, len(set)),
+		Approvals:   []*secretservice.Approval{},
+		Status:      secretservice.ProposalPending,
+	}
+	for _, input := range set {
+		proposal.Metadata[input.Name] = input.metadata(now)
+	}
+	for _, id := range remove {
+		proposal.Remove = append(proposal.Remove, string(id))
+	}
+	if args.Settings != nil {
+		proposal.Settings = args.Settings.toSettings()
+
+		// The settings are validated against a copy, so that invalid ones are
+		// rejected right away rather than when the Proposal is approved.
+		validated := *scope
+		if err := applyScopeSettings(&validated, proposal.Settings); err != nil {
+			return nil, err
+		}
+	}
+
+	if proposal, err = r.wraps.CreateProposal(ctx, proposal); err != nil {
+		return nil, errors.Wrap(err, "could not create proposal")
+	}
+
+	return &proposalResolver{backend: r.wraps, scope: scope, wraps: proposal}, nil
+}
+
+type reviewProposalArgs struct {
+	ScopeID graphql.ID
+	ID      graphql.ID
+}
+
+// approveRelease(scopeId: ID!, id: ID!): Proposal!
+func (r *rootResolver) ApproveRelease(ctx context.Context, args reviewProposalArgs) (*proposalResolver, error) {
+	return r.approveProposal(ctx, args, secretservice.ProposalRelease)
+}
+
+// approveChange(scopeId: ID!, id: ID!): Proposal!
+func (r *rootResolver) ApproveChange(ctx context.Context, args reviewProposalArgs) (*proposalResolver, error) {
+	return r.approveProposal(ctx, args, secretservice.ProposalChange)
+}
+
+func (r *rootResolver) approveProposal(ctx context.Context, args reviewProposalArgs, kind string) (*proposalResolver, error) {
+	principal, scope, proposal, err := r.pendingProposal(ctx, args, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if proposal.RequestedBy == principal.ID {
+		return nil, secretservice.Forbidden("proposals can not be approved by their requester")
+	}
+
+	if proposal.ApprovedBy(principal.ID) {
+		return nil, secretservice.Conflict("proposal %s is already approved by %s", proposal.ID, principal.ID)
+	}
+
+	proposal.Approvals = append(proposal.Approvals, &secretservice.Approval{
+		Principal:  principal.ID,
+		ApprovedAt: time.Now(),
+	})
+
+	if err := r.saveProposal(ctx, scope, proposal); err != nil {
+		return nil, err
+	}
+
+	return &proposalResolver{backend: r.wraps, scope: scope, wraps: proposal}, nil
+}
+
+// rejectRelease(scopeId: ID!, id: ID!): Proposal!
+func (r *rootResolver) RejectRelease(ctx context.Context, args reviewProposalArgs) (*proposalResolver, error) {
+	return r.rejectProposal(ctx, args, secretservice.ProposalRelease)
+}
+
+// rejectChange(scopeId: ID!, id: ID!): Proposal!
+func (r *rootResolver) RejectChange(ctx context.Context, args reviewProposalArgs) (*proposalResolver, error) {
+	return r.rejectProposal(ctx, args, secretservice.ProposalChange)
+}
+
+func (r *rootResolver) rejectProposal(ctx context.Context, args reviewProposalArgs, kind string) (*proposalResolver, error) {
+	principal, scope, proposal, err := r.pendingProposal(ctx, args, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	proposal.Status = secretservice.ProposalRejected
+	proposal.RejectedBy = principal.ID
+
+	if err := r.wraps.UpdateProposal(ctx, proposal); err != nil {
+		return nil, errors.Wrap(err, "could not update proposal")
+	}
+
+	return &proposalResolver{backend: r.wraps, scope: scope, wraps: proposal}, nil
+}
+
+// pendingProposal retrieves a Proposal which can be reviewed by the Principal
+// making the request.
+func (r *rootResolver) pendingProposal(ctx context.Context, args reviewProposalArgs, kind string) (*secretservice.Principal, *secretservice.Scope, *secretservice.Proposal, error) {
+	principal, ok := secretservice.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, nil, nil, secretservice.Forbidden("reviewing a proposal requires an authenticated principal")
+	}
+
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	proposal, err := r.wraps.GetProposal(ctx, scope.Name, string(args.ID))
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "could not get proposal")
+	}
+
+	if proposal.ProposalKind() != kind {
+		return nil, nil, nil, secretservice.Validation("proposal %s is not a %s proposal", proposal.ID, kind)
+	}
+
+	if proposal.Status != secretservice.ProposalPending {
+		return nil, nil, nil, secretservice.Conflict("proposal %s is %s and can not be reviewed", proposal.ID, proposal.Status)
+	}
+
+	return principal, scope, proposal, nil
+}
+
+// saveProposal stores the Proposal and carries it out once it has the number
+// of approvals it requires. The Proposal is stored as APPROVED before it is
+// carried out, so that concurrent reviews fail with a conflict instead of
+// carrying it out again. If carrying it out fails, the Proposal goes back to
+// PENDING without the last approval, so that it can be approved again.
+func (r *rootResolver) saveProposal(ctx context.Context, scope *secretservice.Scope, proposal *secretservice.Proposal) error {
+	accepted := len(proposal.Approvals) >= requiredApprovals(scope, proposal)
+	if accepted {
+		proposal.Status = secretservice.ProposalApproved
+	}
+
+	if err := r.wraps.UpdateProposal(ctx, proposal); err != nil {
+		return errors.Wrap(err, "could not update proposal")
+	}
+
+	if !accepted {
+		return nil
+	}
+
+	if err := r.acceptProposal(ctx, scope, proposal); err != nil {
+		proposal.Status = secretservice.ProposalPending
+		if n := len(proposal.Approvals); n > 0 {
+			proposal.Approvals = proposal.Approvals[:n-1]
+		}
+		if revertErr := r.wraps.UpdateProposal(ctx, proposal); revertErr != nil {
+			log.WithField("proposal", proposal.ID).Errorf("Could not revert proposal: %v", revertErr)
+		}
+		return err
+	}
+
+	if proposal.ReleaseID == "" {
+		return nil
+	}
+
+	return errors.Wrap(r.wraps.UpdateProposal(ctx, proposal), "could not record the release of the proposal")
+}
+
+// acceptProposal turns a RELEASE Proposal into a Release, or applies the
+// changes of a CHANGE Proposal to the current workspace and the settings of
+// the Scope.
+func (r *rootResolver) acceptProposal(ctx context.Context, scope *secretservice.Scope, proposal *secretservice.Proposal) error {
+	if proposal.ProposalKind() == secretservice.ProposalRelease {
+		release, err := r.wraps.CreateRelease(ctx, scope.Name, proposal.Variables, "")
+		if err != nil {
+			return errors.Wrap(err, "could not create a release")
+		}
+
+		proposal.ReleaseID = release.ID
+		return nil
+	}
+
+	current, err := r.wraps.ListVariables(ctx, fmt.Sprintf("workspace/%s", scope.Name))
+	if err != nil {
+		return errors.Wrap(err, "could not list variables")
+	}
+
+	now := time.Now()
+	metadata := make(map[string]*secretservice.Metadata, len(proposal.Metadata))
+	for name, meta := range proposal.Metadata {
+		updated := *meta
+		updated.UpdatedAt = now
+		metadata[name] = &updated
+	}
+
+	if err := applyDiff(ctx, r.wraps, scope.Name, changeDiff(current, proposal), metadata); err != nil {
+		return errors.Wrap(err, "could not apply changes")
+	}
+
+	if proposal.Settings == nil {
+		return nil
+	}
+
+	return r.updateScope(ctx, scope, proposal.Settings)
+}
+
+// requiredApprovals returns the number of approvals the Proposal needs. Changes
+// always need at least one, otherwise requesting them would bypass the
+// protection of the Scope.
+func requiredApprovals(scope *secretservice.Scope, proposal *secretservice.Proposal) int {
+	if proposal.ProposalKind() == secretservice.ProposalChange && scope.RequiredApprovals < 1 {
+		return 1
+	}
+	return scope.RequiredApprovals
+}
+
+type mergeArgs struct {
+	ScopeID, Base, Incoming graphql.ID
+	Strategy                string
+}
+
+// merge(scopeId: ID!, base: ID!, incoming: ID!, strategy: MergeStrategy!): MergeResult!
+func (r *rootResolver) Merge(ctx context.Context, args mergeArgs) (*mergeResolver, error) {
+	switch args.Strategy {
+	case mergeStrategyOurs, mergeStrategyTheirs, mergeStrategyFailOnConflict:
+	default:
+		return nil, secretservice.Validation("unsupported merge strategy %q", args.Strategy)
+	}
+
+	scope, err := r.wraps.Scope(ctx, string(args.ScopeID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	if err := checkWritable(ctx, scope); err != nil {
+		return nil, err
+	}
+
+	base, err := r.wraps.GetRelease(ctx, scope.Name, string(args.Base))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get base release")
+	}
+
+	incoming, err := r.wraps.GetRelease(ctx, scope.Name, string(args.Incoming))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get incoming release")
+	}
+
+	ours, err := r.wraps.ListVariables(ctx, fmt.Sprintf("workspace/%s", scope.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variables")
+	}
+
+	merged, conflicts := threeWayMerge(base.Variables, ours, incoming.Variables, args.Strategy)
+	diff := newDiffResolver(ours, merged)
+
+	if len(diff.written()) > 0 || len(diff.Deleted()) > 0 {
+		existing, err := r.wraps.ListMetadata(ctx, scope.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list variable metadata")
+		}
+
+		metadata := preservedMetadata(existing, diff, time.Now())
+		if err := applyDiff(ctx, r.wraps, scope.Name, diff, metadata); err != nil {
+			return nil, errors.Wrap(err, "could not apply merge")
+		}
+	}
+
+	if conflicts == nil {
+		conflicts = []*conflictResolver{}
+	}
+
+	return &mergeResolver{
+		applied:   diff,
+		conflicts: conflicts,
+		scope:     &scopeResolver{backend: r.wraps, wraps: scope},
+	}, nil
 }
 
 type mutateReleaseArgs struct {
@@ -163,11 +1241,20 @@ func (r *rootResolver) Reset(ctx context.Context, args mutateReleaseArgs) (*scop
 		return nil, errors.Wrap(err, "could not retrieve scope")
 	}
 
+	if err := checkWritable(ctx, scope); err != nil {
+		return nil, err
+	}
+
 	release, err := r.wraps.GetRelease(ctx, scopeName, string(args.ReleaseID))
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get release")
 	}
 
+	metadata, err := r.wraps.ListMetadata(ctx, scopeName)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variable metadata")
+	}
+
 	namespace := fmt.Sprintf("workspace/%s", scopeName)
 
 	if err := r.wraps.Reset(ctx, namespace); err != nil {
@@ -178,6 +1265,14 @@ func (r *rootResolver) Reset(ctx context.Context, args mutateReleaseArgs) (*scop
 		if _, err := r.wraps.CreateVariable(ctx, namespace, variable); err != nil {
 			return nil, errors.Wrap(err, "could not create variable")
 		}
+		delete(metadata, variable.Name)
+	}
+
+	// Metadata of Variables which are not part of the Release is left over.
+	for name := range metadata {
+		if err := r.wraps.DeleteMetadata(ctx, scopeName, name); err != nil {
+			return nil, errors.Wrap(err, "could not remove variable metadata")
+		}
 	}
 
 	return &scopeResolver{backend: r.wraps, wraps: scope}, nil
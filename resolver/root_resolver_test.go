@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/graph-gophers/graphql-go"
 	"github.com/marcinwyszynski/secretservice"
 	"github.com/marcinwyszynski/ssmvars"
 	"github.com/stretchr/testify/mock"
@@ -44,161 +46,1659 @@ func (r *rootResolverTestSuite) TestScope_BackendFailure() {
 	r.EqualError(err, "could not retrieve scope: bacon")
 }
 
+func (r *rootResolverTestSuite) TestCompare_OK() {
+	releaseID := graphql.ID("releaseID")
+
+	r.withScope(nil)
+	r.backend.On("Scope", r.ctx, "otherScope").Return(&secretservice.Scope{Name: "otherScope"}, nil)
+	r.withGetRelease(&ssmvars.Variable{Name: "SECRET", Value: "old", WriteOnly: true}, nil)
+	r.withListVariables("workspace/otherScope", nil, &ssmvars.Variable{Name: "SECRET", Value: "new", WriteOnly: true})
+
+	ret, err := r.sut.Compare(r.ctx, compareArgs{
+		Left:  diffTargetInput{ScopeID: "scopeName", ReleaseID: &releaseID},
+		Right: diffTargetInput{ScopeID: "otherScope"},
+	})
+
+	r.NoError(err)
+	r.Empty(ret.Added())
+	r.Empty(ret.Deleted())
+
+	changed := ret.Changed()
+	r.Len(changed, 1)
+	r.Nil(changed[0].Before().Value())
+	r.Nil(changed[0].After().Value())
+}
+
+func (r *rootResolverTestSuite) TestCompare_LeftError() {
+	r.withScope(errors.New("bacon"))
+
+	ret, err := r.sut.Compare(r.ctx, compareArgs{
+		Left:  diffTargetInput{ScopeID: "scopeName"},
+		Right: diffTargetInput{ScopeID: "scopeName"},
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not retrieve left side: could not retrieve scope: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCompare_RightError() {
+	releaseID := graphql.ID("releaseID")
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+	r.withGetRelease(&ssmvars.Variable{}, errors.New("bacon"))
+
+	ret, err := r.sut.Compare(r.ctx, compareArgs{
+		Left:  diffTargetInput{ScopeID: "scopeName"},
+		Right: diffTargetInput{ScopeID: "scopeName", ReleaseID: &releaseID},
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not retrieve right side: could not get release: bacon")
+}
+
 func (r *rootResolverTestSuite) TestCreateScope_OK() {
 	r.withListVariables("scopes", nil)
 	r.withCreateVariable("scopes", &ssmvars.Variable{Name: "scopeName", Value: "kmsKeyID"}, nil)
 
-	ret, err := r.sut.CreateScope(r.ctx, createScopeArgs{
-		Name:     "scopeName",
-		KMSKeyID: "kmsKeyID",
-	})
+	ret, err := r.sut.CreateScope(r.ctx, createScopeArgs{
+		Name:     "scopeName",
+		KMSKeyID: "kmsKeyID",
+	})
+
+	r.NoError(err)
+	r.EqualValues("scopeName", ret.ID())
+	r.Equal("kmsKeyID", ret.KMSKeyID())
+	r.Equal(r.backend, ret.backend)
+}
+
+func (r *rootResolverTestSuite) TestCreateScope_AlreadyExists() {
+	r.withListVariables("scopes", nil, &ssmvars.Variable{Name: "scopeName"})
+
+	ret, err := r.sut.CreateScope(r.ctx, createScopeArgs{
+		Name:     "scopeName",
+		KMSKeyID: "kmsKeyID",
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, `scope "scopeName" already exists`)
+}
+
+func (r *rootResolverTestSuite) TestCreateScope_BackendFailure() {
+	r.withListVariables("scopes", nil)
+	r.withCreateVariable(
+		"scopes",
+		&ssmvars.Variable{Name: "scopeName", Value: "kmsKeyID"},
+		errors.New("bacon"),
+	)
+
+	ret, err := r.sut.CreateScope(r.ctx, createScopeArgs{
+		Name:     "scopeName",
+		KMSKeyID: "kmsKeyID",
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not create scope: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateScope_RequiredApprovals() {
+	requiredApprovals := int32(2)
+
+	r.withListVariables("scopes", nil)
+	r.withCreateVariable("scopes", &ssmvars.Variable{
+		Name:  "scopeName",
+		Value: `{"kmsKeyId":"kmsKeyID","requiredApprovals":2}`,
+	}, nil)
+
+	ret, err := r.sut.CreateScope(r.ctx, createScopeArgs{
+		Name:              "scopeName",
+		KMSKeyID:          "kmsKeyID",
+		RequiredApprovals: &requiredApprovals,
+	})
+
+	r.NoError(err)
+	r.EqualValues(2, ret.RequiredApprovals())
+}
+
+func (r *rootResolverTestSuite) TestCreateScope_NegativeApprovals() {
+	requiredApprovals := int32(-1)
+
+	ret, err := r.sut.CreateScope(r.ctx, createScopeArgs{
+		Name:              "scopeName",
+		KMSKeyID:          "kmsKeyID",
+		RequiredApprovals: &requiredApprovals,
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "requiredApprovals can not be negative")
+}
+
+func (r *rootResolverTestSuite) TestConfigureScope_OK() {
+	r.asAdmin("carol")
+
+	requiredApprovals := int32(1)
+
+	r.withScope(nil)
+	r.withCreateVariable("scopes", &ssmvars.Variable{
+		Name:  "scopeName",
+		Value: `{"kmsKeyId":"kmsKeyID","requiredApprovals":1}`,
+	}, nil)
+
+	ret, err := r.sut.ConfigureScope(r.ctx, configureScopeArgs{
+		ScopeID:  "scopeName",
+		Settings: scopeSettingsInput{RequiredApprovals: &requiredApprovals},
+	})
+
+	r.NoError(err)
+	r.EqualValues(1, ret.RequiredApprovals())
+	r.Equal("kmsKeyID", ret.KMSKeyID())
+}
+
+func (r *rootResolverTestSuite) TestConfigureScope_BackendFailure() {
+	r.asAdmin("carol")
+
+	r.withScope(nil)
+	r.withCreateVariable("scopes", &ssmvars.Variable{Name: "scopeName", Value: "kmsKeyID"}, errors.New("bacon"))
+
+	ret, err := r.sut.ConfigureScope(r.ctx, configureScopeArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not update scope: bacon")
+}
+
+func (r *rootResolverTestSuite) TestConfigureScope_NotAdmin() {
+	r.as("bob")
+
+	requiredApprovals := int32(0)
+
+	r.withScopeApprovals(2)
+
+	ret, err := r.sut.ConfigureScope(r.ctx, configureScopeArgs{
+		ScopeID:  "scopeName",
+		Settings: scopeSettingsInput{RequiredApprovals: &requiredApprovals},
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, `changing settings of scope "scopeName" requires the admin role or an approved change request`)
+	r.backend.AssertNotCalled(r.T(), "CreateVariable", r.ctx, "scopes", mock.Anything)
+}
+
+func (r *rootResolverTestSuite) TestAddVariable_OK() {
+	r.withScope(nil)
+
+	r.withCreateVariable(
+		"workspace/scopeName",
+		&ssmvars.Variable{Name: "name", Value: "value", WriteOnly: true},
+		nil,
+	)
+	r.withSetMetadata(nil)
+
+	ret, err := r.addVariable()
+
+	r.NoError(err)
+
+	r.EqualValues("name", ret.ID())
+	r.Nil(ret.Value())
+	r.Equal(time.Hour, ret.RotateEvery().Duration)
+	r.Nil(ret.ExpiresAt())
+}
+
+func (r *rootResolverTestSuite) TestAddVariable_Protected() {
+	r.as("bob")
+
+	r.withProtectedScope(0)
+
+	ret, err := r.addVariable()
+
+	r.Nil(ret)
+	r.EqualError(err, `scope "scopeName" is protected, changes require the admin role or an approved change request`)
+}
+
+func (r *rootResolverTestSuite) TestAddVariable_ProtectedAdmin() {
+	r.asAdmin("bob")
+
+	r.withProtectedScope(0)
+	r.withCreateVariable(
+		"workspace/scopeName",
+		&ssmvars.Variable{Name: "name", Value: "value", WriteOnly: true},
+		nil,
+	)
+	r.withSetMetadata(nil)
+
+	ret, err := r.addVariable()
+
+	r.NoError(err)
+	r.EqualValues("name", ret.ID())
+}
+
+func (r *rootResolverTestSuite) TestAddVariable_ScopeFailure() {
+	r.withScope(errors.New("bacon"))
+
+	ret, err := r.addVariable()
+
+	r.Nil(ret)
+	r.EqualError(err, "could not retrieve scope: bacon")
+}
+
+func (r *rootResolverTestSuite) TestAddVariable_AddFailure() {
+	r.withScope(nil)
+
+	r.withCreateVariable(
+		"workspace/scopeName",
+		&ssmvars.Variable{Name: "name", Value: "value", WriteOnly: true},
+		errors.New("bacon"),
+	)
+
+	ret, err := r.addVariable()
+
+	r.Nil(ret)
+	r.EqualError(err, "could not create variable: bacon")
+}
+
+func (r *rootResolverTestSuite) TestAddVariable_MetadataFailure() {
+	r.withScope(nil)
+
+	r.withCreateVariable(
+		"workspace/scopeName",
+		&ssmvars.Variable{Name: "name", Value: "value", WriteOnly: true},
+		nil,
+	)
+	r.withSetMetadata(errors.New("bacon"))
+
+	ret, err := r.addVariable()
+
+	r.Nil(ret)
+	r.EqualError(err, "could not set variable metadata: bacon")
+}
+
+func (r *rootResolverTestSuite) TestGenerateVariable_OK() {
+	r.withScope(nil)
+
+	rotateEvery := time.Hour
+	previous := &secretservice.Metadata{RotateEvery: &rotateEvery, UpdatedAt: time.Now().Add(-2 * time.Hour)}
+	r.withListMetadata(nil, map[string]*secretservice.Metadata{"name": previous})
+	r.withSetMetadata(nil)
+
+	var stored *ssmvars.Variable
+	r.backend.On(
+		"CreateVariable",
+		r.ctx,
+		"workspace/scopeName",
+		mock.MatchedBy(func(arg interface{}) bool {
+			stored = arg.(*ssmvars.Variable)
+			return true
+		}),
+	).Return(&ssmvars.Variable{Name: "name", WriteOnly: true}, nil)
+
+	ret, err := r.generateVariable("HEX")
+
+	r.NoError(err)
+	r.EqualValues("name", ret.ID())
+	r.Nil(ret.Value())
+
+	r.Equal("name", stored.Name)
+	r.Len(stored.Value, 16)
+	r.True(stored.WriteOnly)
+
+	// The Variable was due for rotation, and is not anymore.
+	previousDeadline, _ := previous.Deadline()
+	deadline, ok := ret.metadata.Deadline()
+	r.True(ok)
+	r.True(deadline.After(time.Now()))
+	r.True(deadline.After(previousDeadline))
+}
+
+func (r *rootResolverTestSuite) TestGenerateVariable_ListMetadataFailure() {
+	r.withScope(nil)
+	r.withListMetadata(errors.New("bacon"), nil)
+
+	ret, err := r.generateVariable("HEX")
+
+	r.Nil(ret)
+	r.EqualError(err, "could not list variable metadata: bacon")
+	r.backend.AssertNotCalled(r.T(), "CreateVariable", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (r *rootResolverTestSuite) TestGenerateVariable_SetMetadataFailure() {
+	r.withScope(nil)
+	r.withListMetadata(nil, nil)
+	r.backend.
+		On("CreateVariable", r.ctx, "workspace/scopeName", mock.Anything).
+		Return(&ssmvars.Variable{Name: "name", WriteOnly: true}, nil)
+	r.backend.On("SetMetadata", r.ctx, "scopeName", "name", mock.Anything).Return(errors.New("bacon"))
+
+	ret, err := r.generateVariable("HEX")
+
+	r.Nil(ret)
+	r.EqualError(err, "could not set variable metadata: bacon")
+}
+
+func (r *rootResolverTestSuite) TestGenerateVariable_ScopeFailure() {
+	r.withScope(errors.New("bacon"))
+
+	ret, err := r.generateVariable("HEX")
+
+	r.Nil(ret)
+	r.EqualError(err, "could not retrieve scope: bacon")
+}
+
+func (r *rootResolverTestSuite) TestGenerateVariable_InvalidName() {
+	r.withScope(nil)
+
+	ret, err := r.sut.GenerateVariable(r.ctx, generateVariableArgs{
+		ScopeID: "scopeName",
+		Name:    "a-b",
+		Spec:    secretSpecInput{Kind: "HEX"},
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, `variable name "a-b" is not a valid environment variable name`)
+	r.backend.AssertNotCalled(r.T(), "CreateVariable", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (r *rootResolverTestSuite) TestGenerateVariable_GenerateFailure() {
+	r.withScope(nil)
+
+	ret, err := r.generateVariable("BACON")
+
+	r.Nil(ret)
+	r.EqualError(err, `could not generate value: unsupported secret kind "BACON"`)
+}
+
+func (r *rootResolverTestSuite) TestGenerateVariable_CreateFailure() {
+	r.withScope(nil)
+	r.withListMetadata(nil, nil)
+	r.backend.
+		On("CreateVariable", r.ctx, "workspace/scopeName", mock.Anything).
+		Return((*ssmvars.Variable)(nil), errors.New("bacon"))
+
+	ret, err := r.generateVariable("HEX")
+
+	r.Nil(ret)
+	r.EqualError(err, "could not create variable: bacon")
+}
+
+func (r *rootResolverTestSuite) TestImportVariables_Merge() {
+	r.withScope(nil)
+	r.withListVariables(
+		"workspace/scopeName",
+		nil,
+		&ssmvars.Variable{Name: "KEPT", Value: "kept"},
+		&ssmvars.Variable{Name: "SECRET", Value: "old", WriteOnly: true},
+	)
+	r.withListMetadata(nil, nil)
+	r.withWriteVariable(&ssmvars.Variable{Name: "NEW", Value: "new", WriteOnly: true})
+	r.withWriteVariable(&ssmvars.Variable{Name: "SECRET", Value: "new", WriteOnly: true})
+	r.backend.On("SetMetadata", r.ctx, "scopeName", "NEW", mock.Anything).Return(nil)
+	r.backend.On("SetMetadata", r.ctx, "scopeName", "SECRET", mock.Anything).Return(nil)
+
+	ret, err := r.importVariables("MERGE", "NEW=new\nSECRET=new\n", false, "NEW")
+
+	r.NoError(err)
+	r.Len(ret.Added(), 1)
+	r.Len(ret.Changed(), 1)
+	r.Empty(ret.Deleted())
+	r.backend.AssertNumberOfCalls(r.T(), "CreateVariable", 2)
+}
+
+func (r *rootResolverTestSuite) TestImportVariables_ReplaceDryRun() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, &ssmvars.Variable{Name: "OLD", Value: "old"})
+
+	ret, err := r.importVariables("REPLACE", "NEW=new", true)
+
+	r.NoError(err)
+	r.Len(ret.Added(), 1)
+	r.Len(ret.Deleted(), 1)
+	r.backend.AssertNotCalled(r.T(), "CreateVariable", mock.Anything, mock.Anything, mock.Anything)
+	r.backend.AssertNotCalled(r.T(), "DeleteVariable", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (r *rootResolverTestSuite) TestImportVariables_Replace() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, &ssmvars.Variable{Name: "OLD", Value: "old"})
+	r.withListMetadata(nil, nil)
+	r.withCreateVariable("workspace/scopeName", &ssmvars.Variable{Name: "NEW", Value: "new"}, nil)
+	r.backend.On("DeleteVariable", r.ctx, "workspace/scopeName", "OLD").Return(&ssmvars.Variable{}, nil)
+	r.backend.On("DeleteMetadata", r.ctx, "scopeName", "OLD").Return(nil)
+	r.backend.On("SetMetadata", r.ctx, "scopeName", "NEW", mock.Anything).Return(nil)
+
+	ret, err := r.importVariables("REPLACE", "NEW=new", false)
+
+	r.NoError(err)
+	r.Len(ret.Added(), 1)
+	r.Len(ret.Deleted(), 1)
+	r.backend.AssertCalled(r.T(), "DeleteMetadata", r.ctx, "scopeName", "OLD")
+}
+
+func (r *rootResolverTestSuite) TestImportVariables_ParseError() {
+	r.withScope(nil)
+
+	ret, err := r.importVariables("MERGE", "BACON", false)
+
+	r.Nil(ret)
+	r.EqualError(err, "could not parse document: line 1: missing '='")
+}
+
+func (r *rootResolverTestSuite) TestImportVariables_InvalidName() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+
+	ret, err := r.importVariables("MERGE", "1BACON=tasty", false)
+
+	r.Nil(ret)
+	r.EqualError(err, `variable name "1BACON" is not a valid environment variable name`)
+}
+
+func (r *rootResolverTestSuite) TestImportVariables_UnknownWriteOnly() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+
+	ret, err := r.importVariables("MERGE", "BACON=tasty", false, "HAM")
+
+	r.Nil(ret)
+	r.EqualError(err, `write-only variable "HAM" is not in the document`)
+}
+
+func (r *rootResolverTestSuite) TestImportVariables_ApplyError() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+	r.withListMetadata(nil, nil)
+	r.withCreateVariable("workspace/scopeName", &ssmvars.Variable{Name: "BACON", Value: "tasty"}, errors.New("bacon"))
+
+	ret, err := r.importVariables("MERGE", "BACON=tasty", false)
+
+	r.Nil(ret)
+	r.EqualError(err, `could not apply import: could not write variable "BACON": bacon`)
+}
+
+func (r *rootResolverTestSuite) TestApplyChanges_OK() {
+	current := []*ssmvars.Variable{
+		{Name: "CHANGED", Value: "old"},
+		{Name: "REMOVED", Value: "removed"},
+		{Name: "UNCHANGED", Value: "unchanged"},
+	}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, current...)
+	r.withListMetadata(nil, nil)
+	r.withWriteVariable(&ssmvars.Variable{Name: "CHANGED", Value: "new"})
+	r.withWriteVariable(&ssmvars.Variable{Name: "ADDED", Value: "added", WriteOnly: true})
+	r.backend.On("SetMetadata", r.ctx, "scopeName", "CHANGED", mock.Anything).Return(nil)
+	r.backend.On("SetMetadata", r.ctx, "scopeName", "ADDED", mock.Anything).Return(nil)
+	r.backend.On("DeleteVariable", r.ctx, "workspace/scopeName", "REMOVED").Return(&ssmvars.Variable{}, nil)
+	r.backend.On("DeleteMetadata", r.ctx, "scopeName", "REMOVED").Return(nil)
+
+	revision := secretservice.ContentHash(nil, current)
+	ret, err := r.applyChanges(&revision, []variableInput{
+		{Name: "CHANGED", Value: "new"},
+		{Name: "ADDED", Value: "added", WriteOnly: true},
+		{Name: "UNCHANGED", Value: "unchanged"},
+	}, "REMOVED")
+
+	r.NoError(err)
+	r.Len(ret.Added(), 1)
+	r.Len(ret.Changed(), 1)
+	r.Len(ret.Deleted(), 1)
+	r.backend.AssertNumberOfCalls(r.T(), "CreateVariable", 2)
+	r.backend.AssertNotCalled(r.T(), "SetMetadata", r.ctx, "scopeName", "UNCHANGED", mock.Anything)
+}
+
+func (r *rootResolverTestSuite) TestApplyChanges_MetadataOnly() {
+	updatedAt := time.Date(2018, 10, 10, 23, 0, 0, 0, time.UTC)
+	rotateEvery := time.Hour
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, &ssmvars.Variable{Name: "UNCHANGED", Value: "unchanged"})
+	r.withListMetadata(nil, map[string]*secretservice.Metadata{
+		"UNCHANGED": {UpdatedAt: updatedAt},
+	})
+	r.backend.On("SetMetadata", r.ctx, "scopeName", "UNCHANGED", &secretservice.Metadata{
+		RotateEvery: &rotateEvery,
+		UpdatedAt:   updatedAt,
+	}).Return(nil)
+
+	ret, err := r.applyChanges(nil, []variableInput{{
+		Name:        "UNCHANGED",
+		Value:       "unchanged",
+		RotateEvery: &duration{Duration: rotateEvery},
+	}})
+
+	r.NoError(err)
+	r.Empty(ret.Changed())
+	r.backend.AssertNotCalled(r.T(), "CreateVariable", mock.Anything, mock.Anything, mock.Anything)
+	r.backend.AssertNumberOfCalls(r.T(), "SetMetadata", 1)
+}
+
+func (r *rootResolverTestSuite) TestApplyChanges_ListMetadataError() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+	r.withListMetadata(errors.New("bacon"), nil)
+
+	ret, err := r.applyChanges(nil, []variableInput{{Name: "ADDED", Value: "added"}})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not list variable metadata: bacon")
+}
+
+func (r *rootResolverTestSuite) TestApplyChanges_RevisionMismatch() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+
+	revision := "bacon"
+	ret, err := r.applyChanges(&revision, nil)
+
+	r.Nil(ret)
+	r.EqualError(err, `workspace is at revision "`+secretservice.ContentHash(nil, nil)+`", expected "bacon"`)
+}
+
+func (r *rootResolverTestSuite) TestApplyChanges_ValidationErrors() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, &ssmvars.Variable{Name: "EXISTING"})
+
+	for _, testCase := range []struct {
+		set    []variableInput
+		remove []graphql.ID
+		err    string
+	}{
+		{
+			set: []variableInput{{Name: "in-valid"}},
+			err: `variable name "in-valid" is not a valid environment variable name`,
+		},
+		{
+			set: []variableInput{{Name: "TWICE"}, {Name: "TWICE"}},
+			err: `variable "TWICE" is set more than once`,
+		},
+		{
+			set:    []variableInput{{Name: "EXISTING"}},
+			remove: []graphql.ID{"EXISTING"},
+			err:    `variable "EXISTING" is both set and removed`,
+		},
+		{
+			remove: []graphql.ID{"MISSING"},
+			err:    `variable "MISSING" does not exist`,
+		},
+	} {
+		ret, err := r.applyChanges(nil, testCase.set, testCase.remove...)
+
+		r.Nil(ret)
+		r.EqualError(err, testCase.err)
+	}
+
+	r.backend.AssertNotCalled(r.T(), "CreateVariable", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (r *rootResolverTestSuite) TestApplyChanges_ApplyError() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, &ssmvars.Variable{Name: "REMOVED"})
+	r.backend.
+		On("DeleteVariable", r.ctx, "workspace/scopeName", "REMOVED").
+		Return((*ssmvars.Variable)(nil), errors.New("bacon"))
+
+	ret, err := r.applyChanges(nil, nil, "REMOVED")
+
+	r.Nil(ret)
+	r.EqualError(err, `could not apply changes: could not remove variable "REMOVED": bacon`)
+}
+
+func (r *rootResolverTestSuite) TestRemoveVariable_OK() {
+	variable := &ssmvars.Variable{}
+	r.withScope(nil)
+	r.withDeleteVariable(variable, nil)
+	r.withDeleteMetadata("variable", nil)
+
+	ret, err := r.sut.RemoveVariable(r.ctx, removeVariableArgs{
+		ScopeID: "scopeName",
+		ID:      "variable",
+	})
+
+	r.NoError(err)
+	r.Equal(variable, ret.wraps)
+	r.backend.AssertCalled(r.T(), "DeleteMetadata", r.ctx, "scopeName", "variable")
+}
+
+func (r *rootResolverTestSuite) TestRemoveVariable_Protected() {
+	r.withProtectedScope(0)
+
+	ret, err := r.sut.RemoveVariable(r.ctx, removeVariableArgs{
+		ScopeID: "scopeName",
+		ID:      "variable",
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, `scope "scopeName" is protected, changes require the admin role or an approved change request`)
+}
+
+func (r *rootResolverTestSuite) TestRemoveVariable_BackendFailure() {
+	r.withScope(nil)
+	r.withDeleteVariable((*ssmvars.Variable)(nil), errors.New("bacon"))
+
+	ret, err := r.sut.RemoveVariable(r.ctx, removeVariableArgs{
+		ScopeID: "scopeName",
+		ID:      "variable",
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not remove variable: bacon")
+}
+
+func (r *rootResolverTestSuite) TestRemoveVariable_DeleteMetadataFailure() {
+	r.withScope(nil)
+	r.withDeleteVariable(&ssmvars.Variable{}, nil)
+	r.withDeleteMetadata("variable", errors.New("bacon"))
+
+	ret, err := r.sut.RemoveVariable(r.ctx, removeVariableArgs{
+		ScopeID: "scopeName",
+		ID:      "variable",
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not remove variable metadata: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_OK() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, nil)
+	r.withCreateRelease(nil, variable)
+
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{ScopeID: "scopeName"})
+
+	r.NoError(err)
+	r.EqualValues("releaseID", ret.ID())
+	r.Equal(r.backend, ret.backend)
+	r.NotNil(ret.scope)
+	r.Empty(ret.Warnings())
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_ExpiredWarning() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+	expiresAt := time.Date(2018, 11, 10, 23, 0, 0, 0, time.UTC)
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, map[string]*secretservice.Metadata{
+		"VARIABLE": {ExpiresAt: &expiresAt},
+	})
+	r.withCreateRelease(nil, variable)
+
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{ScopeID: "scopeName"})
+
+	r.NoError(err)
+	r.Equal([]string{`variable "VARIABLE" expired at 2018-11-10T23:00:00Z`}, ret.Warnings())
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_MetadataError() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(errors.New("bacon"), nil)
+
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not list variable metadata: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_ScopeError() {
+	r.withScope(errors.New("bacon"))
+
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not retrieve scope: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_ListError() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", errors.New("bacon"))
+
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not list variables: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_CreateError() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, nil)
+	r.withCreateRelease(errors.New("bacon"), variable)
+
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not create a release: bacon")
+}
+
+func (r *rootResolverTestSuite) TestPreviewRelease_OK() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, nil)
+	r.withListReleases(nil, "releaseID")
+	r.backend.On("GetRelease", r.ctx, "scopeName", "releaseID").Return(&secretservice.Release{
+		ID:   "releaseID",
+		Live: true,
+	}, nil)
+
+	ret, err := r.sut.PreviewRelease(r.ctx, scopeArgs{ScopeID: "scopeName"})
+
+	r.NoError(err)
+	r.EqualValues("releaseID", ret.Base().ID())
+	r.Equal([]*ssmvars.Variable{variable}, ret.variables)
+	r.backend.AssertNotCalled(r.T(), "CreateRelease", r.ctx, "scopeName", []*ssmvars.Variable{variable}, "")
+}
+
+func (r *rootResolverTestSuite) TestPreviewRelease_ScopeError() {
+	r.withScope(errors.New("bacon"))
+
+	ret, err := r.sut.PreviewRelease(r.ctx, scopeArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not retrieve scope: bacon")
+}
+
+func (r *rootResolverTestSuite) TestPreviewRelease_MetadataError() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+	r.withListMetadata(errors.New("bacon"), nil)
+
+	ret, err := r.sut.PreviewRelease(r.ctx, scopeArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not list variable metadata: bacon")
+}
+
+func (r *rootResolverTestSuite) TestPreviewRelease_ReleaseError() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+	r.withListMetadata(nil, nil)
+	r.withListReleases(errors.New("bacon"))
+
+	ret, err := r.sut.PreviewRelease(r.ctx, scopeArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not find the newest live release: could not list release IDs: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_IfChangedUnchanged() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, nil)
+	r.withListReleases(nil, "latestID", "olderID")
+	r.backend.On("GetRelease", r.ctx, "scopeName", "latestID").Return(&secretservice.Release{
+		ID:          "latestID",
+		ScopeName:   "scopeName",
+		Live:        true,
+		ContentHash: "computed with a previous key",
+		Variables:   []*ssmvars.Variable{variable},
+	}, nil)
+
+	ret, err := r.sut.CreateRelease(r.ctx, r.ifChanged())
+
+	r.NoError(err)
+	r.EqualValues("latestID", ret.ID())
+	r.backend.AssertNotCalled(r.T(), "CreateRelease", r.ctx, "scopeName", []*ssmvars.Variable{variable}, "")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_IfChangedChanged() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, nil)
+	r.withListReleases(nil, "latestID")
+	r.backend.On("GetRelease", r.ctx, "scopeName", "latestID").Return(&secretservice.Release{
+		ID:          "latestID",
+		ScopeName:   "scopeName",
+		Live:        true,
+		ContentHash: "stale",
+	}, nil)
+	r.withCreateRelease(nil, variable)
+
+	ret, err := r.sut.CreateRelease(r.ctx, r.ifChanged())
+
+	r.NoError(err)
+	r.EqualValues("releaseID", ret.ID())
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_IfChangedArchived() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, nil)
+	r.withListReleases(nil, "archivedID", "liveID")
+	r.backend.On("GetRelease", r.ctx, "scopeName", "archivedID").Return(&secretservice.Release{
+		ID:          "archivedID",
+		ScopeName:   "scopeName",
+		ContentHash: secretservice.ContentHash(nil, []*ssmvars.Variable{variable}),
+	}, nil)
+	r.backend.On("GetRelease", r.ctx, "scopeName", "liveID").Return(&secretservice.Release{
+		ID:          "liveID",
+		ScopeName:   "scopeName",
+		Live:        true,
+		ContentHash: "stale",
+	}, nil)
+	r.withCreateRelease(nil, variable)
+
+	ret, err := r.sut.CreateRelease(r.ctx, r.ifChanged())
+
+	r.NoError(err)
+	r.EqualValues("releaseID", ret.ID())
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_IfChangedNoReleases() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, nil)
+	r.withListReleases(nil)
+	r.withCreateRelease(nil, variable)
+
+	ret, err := r.sut.CreateRelease(r.ctx, r.ifChanged())
+
+	r.NoError(err)
+	r.EqualValues("releaseID", ret.ID())
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_IfChangedListError() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, nil)
+	r.withListReleases(errors.New("bacon"))
+
+	ret, err := r.sut.CreateRelease(r.ctx, r.ifChanged())
+
+	r.Nil(ret)
+	r.EqualError(err, "could not find the newest live release: could not list release IDs: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_IfChangedGetError() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListMetadata(nil, nil)
+	r.withListReleases(nil, "latestID")
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "latestID").
+		Return((*secretservice.Release)(nil), errors.New("bacon"))
+
+	ret, err := r.sut.CreateRelease(r.ctx, r.ifChanged())
+
+	r.Nil(ret)
+	r.EqualError(err, "could not find the newest live release: could not get release latestID: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_PartialWithBase() {
+	ready := &ssmvars.Variable{Name: "READY", Value: "new"}
+	pending := &ssmvars.Variable{Name: "PENDING", Value: "new"}
+	unchanged := &ssmvars.Variable{Name: "UNCHANGED", Value: "old"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, pending, ready, unchanged)
+	r.withListMetadata(nil, nil)
+	r.withGetReleaseID("baseID", &ssmvars.Variable{Name: "READY", Value: "old"}, unchanged)
+
+	expected := []*ssmvars.Variable{ready, unchanged}
+	r.backend.
+		On("CreateRelease", r.ctx, "scopeName", expected, "baseID").
+		Return(&secretservice.Release{ID: "releaseID", BaseReleaseID: "baseID", Variables: expected}, nil)
+
+	baseRelease := graphql.ID("baseID")
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{
+		ScopeID:     "scopeName",
+		Include:     &[]graphql.ID{"READY"},
+		BaseRelease: &baseRelease,
+	})
+
+	r.NoError(err)
+	r.EqualValues("releaseID", ret.ID())
+
+	base, err := ret.BaseRelease(r.ctx)
+	r.NoError(err)
+	r.EqualValues("baseID", base.ID())
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_PartialNewestLive() {
+	ready := &ssmvars.Variable{Name: "READY", Value: "new"}
+	old := &ssmvars.Variable{Name: "OLD", Value: "old"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, ready)
+	r.withListMetadata(nil, nil)
+	r.withListReleases(nil, "liveID")
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "liveID").
+		Return(&secretservice.Release{ID: "liveID", Live: true, Variables: []*ssmvars.Variable{old}}, nil)
+
+	expected := []*ssmvars.Variable{old, ready}
+	r.backend.
+		On("CreateRelease", r.ctx, "scopeName", expected, "liveID").
+		Return(&secretservice.Release{ID: "releaseID"}, nil)
+
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{
+		ScopeID: "scopeName",
+		Include: &[]graphql.ID{"READY"},
+	})
+
+	r.NoError(err)
+	r.EqualValues("releaseID", ret.ID())
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_PartialNoBase() {
+	ready := &ssmvars.Variable{Name: "READY", Value: "new"}
+
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil, ready, &ssmvars.Variable{Name: "PENDING"})
+	r.withListMetadata(nil, nil)
+	r.withListReleases(nil)
+	r.withCreateRelease(nil, ready)
+
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{
+		ScopeID: "scopeName",
+		Include: &[]graphql.ID{"READY"},
+	})
+
+	r.NoError(err)
+	r.EqualValues("releaseID", ret.ID())
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_PartialMissingVariable() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+	r.withListMetadata(nil, nil)
+	r.withGetReleaseID("baseID")
+
+	baseRelease := graphql.ID("baseID")
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{
+		ScopeID:     "scopeName",
+		Include:     &[]graphql.ID{"MISSING"},
+		BaseRelease: &baseRelease,
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, `variable "MISSING" is not in the workspace`)
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_PartialBaseError() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+	r.withListMetadata(nil, nil)
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "baseID").
+		Return((*secretservice.Release)(nil), errors.New("bacon"))
+
+	baseRelease := graphql.ID("baseID")
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{
+		ScopeID:     "scopeName",
+		Include:     &[]graphql.ID{"READY"},
+		BaseRelease: &baseRelease,
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not get base release: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_BaseWithoutInclude() {
+	r.withScope(nil)
+	r.withListVariables("workspace/scopeName", nil)
+	r.withListMetadata(nil, nil)
+
+	baseRelease := graphql.ID("baseID")
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{
+		ScopeID:     "scopeName",
+		BaseRelease: &baseRelease,
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "baseRelease can only be used together with include")
+}
+
+func (r *rootResolverTestSuite) TestScheduledOperations_OK() {
+	r.withScope(nil)
+	r.backend.
+		On("ListScheduledOperations", r.ctx, "scopeName").
+		Return([]*secretservice.ScheduledOperation{{ID: "operationID"}}, nil)
+
+	ret, err := r.sut.ScheduledOperations(r.ctx, scopeArgs{ScopeID: "scopeName"})
+
+	r.NoError(err)
+	r.Len(ret, 1)
+	r.EqualValues("operationID", ret[0].ID())
+	r.NotNil(ret[0].scope)
+}
+
+func (r *rootResolverTestSuite) TestScheduledOperations_ListError() {
+	r.withScope(nil)
+	r.backend.
+		On("ListScheduledOperations", r.ctx, "scopeName").
+		Return([]*secretservice.ScheduledOperation(nil), errors.New("bacon"))
+
+	ret, err := r.sut.ScheduledOperations(r.ctx, scopeArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not list scheduled operations: bacon")
+}
+
+func (r *rootResolverTestSuite) TestScheduleOperation_CreateRelease() {
+	runAt := time.Now().Add(time.Hour)
+
+	r.withScope(nil)
+	r.withScheduleOperation(secretservice.OperationCreateRelease, "", nil)
+
+	ret, err := r.sut.ScheduleOperation(r.ctx, scheduleOperationArgs{
+		ScopeID: "scopeName",
+		Kind:    secretservice.OperationCreateRelease,
+		RunAt:   graphql.Time{Time: runAt},
+	})
+
+	r.NoError(err)
+	r.EqualValues("operationID", ret.ID())
+}
+
+func (r *rootResolverTestSuite) TestScheduleOperation_ArchiveRelease() {
+	releaseID := graphql.ID("releaseID")
+
+	r.withScope(nil)
+	r.withGetRelease(nil, nil)
+	r.withScheduleOperation(secretservice.OperationArchiveRelease, "releaseID", nil)
+
+	ret, err := r.sut.ScheduleOperation(r.ctx, scheduleOperationArgs{
+		ScopeID:   "scopeName",
+		Kind:      secretservice.OperationArchiveRelease,
+		RunAt:     graphql.Time{Time: time.Now().Add(time.Hour)},
+		ReleaseID: &releaseID,
+	})
+
+	r.NoError(err)
+	r.EqualValues("operationID", ret.ID())
+}
+
+func (r *rootResolverTestSuite) TestScheduleOperation_ArchiveWithoutRelease() {
+	ret, err := r.sut.ScheduleOperation(r.ctx, scheduleOperationArgs{
+		ScopeID: "scopeName",
+		Kind:    secretservice.OperationArchiveRelease,
+		RunAt:   graphql.Time{Time: time.Now().Add(time.Hour)},
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "releaseId is required to archive a release")
+}
+
+func (r *rootResolverTestSuite) TestScheduleOperation_CreateWithRelease() {
+	releaseID := graphql.ID("releaseID")
+
+	ret, err := r.sut.ScheduleOperation(r.ctx, scheduleOperationArgs{
+		ScopeID:   "scopeName",
+		Kind:      secretservice.OperationCreateRelease,
+		RunAt:     graphql.Time{Time: time.Now().Add(time.Hour)},
+		ReleaseID: &releaseID,
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "releaseId can not be used to create a release")
+}
+
+func (r *rootResolverTestSuite) TestScheduleOperation_InThePast() {
+	ret, err := r.sut.ScheduleOperation(r.ctx, scheduleOperationArgs{
+		ScopeID: "scopeName",
+		Kind:    secretservice.OperationCreateRelease,
+		RunAt:   graphql.Time{Time: time.Now().Add(-time.Hour)},
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "operations can only be scheduled in the future")
+}
+
+func (r *rootResolverTestSuite) TestScheduleOperation_ReleaseError() {
+	releaseID := graphql.ID("releaseID")
+
+	r.withScope(nil)
+	r.withGetRelease(nil, errors.New("bacon"))
+
+	ret, err := r.sut.ScheduleOperation(r.ctx, scheduleOperationArgs{
+		ScopeID:   "scopeName",
+		Kind:      secretservice.OperationArchiveRelease,
+		RunAt:     graphql.Time{Time: time.Now().Add(time.Hour)},
+		ReleaseID: &releaseID,
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not get release: bacon")
+}
+
+func (r *rootResolverTestSuite) TestScheduleOperation_ScheduleError() {
+	r.withScope(nil)
+	r.withScheduleOperation(secretservice.OperationCreateRelease, "", errors.New("bacon"))
+
+	ret, err := r.sut.ScheduleOperation(r.ctx, scheduleOperationArgs{
+		ScopeID: "scopeName",
+		Kind:    secretservice.OperationCreateRelease,
+		RunAt:   graphql.Time{Time: time.Now().Add(time.Hour)},
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not schedule operation: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCancelScheduledOperation_OK() {
+	r.withScope(nil)
+	r.withGetScheduledOperation(secretservice.OperationPending)
+	r.backend.
+		On("UpdateScheduledOperation", r.ctx, mock.MatchedBy(func(operation *secretservice.ScheduledOperation) bool {
+			return operation.Status == secretservice.OperationCancelled
+		})).
+		Return(nil)
+
+	ret, err := r.sut.CancelScheduledOperation(r.ctx, cancelScheduledOperationArgs{
+		ScopeID: "scopeName",
+		ID:      "operationID",
+	})
+
+	r.NoError(err)
+	r.Equal(secretservice.OperationCancelled, ret.Status())
+}
+
+func (r *rootResolverTestSuite) TestCancelScheduledOperation_NotPending() {
+	r.withScope(nil)
+	r.withGetScheduledOperation(secretservice.OperationDone)
+
+	ret, err := r.sut.CancelScheduledOperation(r.ctx, cancelScheduledOperationArgs{
+		ScopeID: "scopeName",
+		ID:      "operationID",
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "operation operationID is DONE and can not be cancelled")
+}
+
+func (r *rootResolverTestSuite) TestCancelScheduledOperation_UpdateError() {
+	r.withScope(nil)
+	r.withGetScheduledOperation(secretservice.OperationPending)
+	r.backend.On("UpdateScheduledOperation", r.ctx, mock.Anything).Return(errors.New("bacon"))
+
+	ret, err := r.sut.CancelScheduledOperation(r.ctx, cancelScheduledOperationArgs{
+		ScopeID: "scopeName",
+		ID:      "operationID",
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not cancel scheduled operation: bacon")
+}
+
+func (r *rootResolverTestSuite) TestCreateRelease_RequiresApproval() {
+	r.withScopeApprovals(1)
+
+	ret, err := r.sut.CreateRelease(r.ctx, createReleaseArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, `releases in scope "scopeName" require approval, use requestRelease instead`)
+}
+
+func (r *rootResolverTestSuite) TestProposals_OK() {
+	r.withScope(nil)
+	r.backend.
+		On("ListProposals", r.ctx, "scopeName").
+		Return([]*secretservice.Proposal{{ID: "proposalID"}}, nil)
+
+	ret, err := r.sut.Proposals(r.ctx, scopeArgs{ScopeID: "scopeName"})
+
+	r.NoError(err)
+	r.Len(ret, 1)
+	r.EqualValues("proposalID", ret[0].ID())
+}
+
+func (r *rootResolverTestSuite) TestProposals_ListError() {
+	r.withScope(nil)
+	r.backend.
+		On("ListProposals", r.ctx, "scopeName").
+		Return([]*secretservice.Proposal(nil), errors.New("bacon"))
+
+	ret, err := r.sut.Proposals(r.ctx, scopeArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "could not list proposals: bacon")
+}
+
+func (r *rootResolverTestSuite) TestRequestRelease_Pending() {
+	r.as("alice")
+
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScopeApprovals(1)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListReleases(nil, "releaseID")
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "releaseID").
+		Return(&secretservice.Release{ID: "releaseID", Live: true}, nil)
+	r.backend.On(
+		"CreateProposal",
+		r.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			proposal, ok := arg.(*secretservice.Proposal)
+			r.True(ok)
+
+			r.Equal("scopeName", proposal.ScopeName)
+			r.Equal("alice", proposal.RequestedBy)
+			r.Equal("rotate keys", proposal.Message)
+			r.Equal("releaseID", proposal.BaseReleaseID)
+			r.Equal([]*ssmvars.Variable{variable}, proposal.Variables)
+
+			return true
+		}),
+	).Return(&secretservice.Proposal{ID: "proposalID", Status: secretservice.ProposalPending}, nil)
+
+	ret, err := r.sut.RequestRelease(r.ctx, requestReleaseArgs{
+		ScopeID: "scopeName",
+		Message: "rotate keys",
+	})
+
+	r.NoError(err)
+	r.EqualValues("proposalID", ret.ID())
+	r.Equal(secretservice.ProposalPending, ret.Status())
+}
+
+func (r *rootResolverTestSuite) TestRequestRelease_NoApprovalsRequired() {
+	r.as("alice")
+
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScopeApprovals(0)
+	r.withListVariables("workspace/scopeName", nil, variable)
+	r.withListReleases(nil)
+	r.backend.
+		On("CreateProposal", r.ctx, mock.Anything).
+		Return(&secretservice.Proposal{
+			ID:        "proposalID",
+			ScopeName: "scopeName",
+			Variables: []*ssmvars.Variable{variable},
+			Status:    secretservice.ProposalPending,
+		}, nil)
+	r.withCreateRelease(nil, variable)
+	r.withUpdateProposal(secretservice.ProposalApproved, nil)
+
+	ret, err := r.sut.RequestRelease(r.ctx, requestReleaseArgs{ScopeID: "scopeName"})
+
+	r.NoError(err)
+	r.Equal(secretservice.ProposalApproved, ret.Status())
+	r.EqualValues("releaseID", ret.Release().ID())
+}
+
+func (r *rootResolverTestSuite) TestRequestRelease_Unauthenticated() {
+	ret, err := r.sut.RequestRelease(r.ctx, requestReleaseArgs{ScopeID: "scopeName"})
+
+	r.Nil(ret)
+	r.EqualError(err, "requesting a release requires an authenticated principal")
+}
+
+func (r *rootResolverTestSuite) TestApproveRelease_NeedsMoreApprovals() {
+	r.as("bob")
+
+	r.withScopeApprovals(2)
+	r.withGetProposal(secretservice.ProposalPending)
+	r.withUpdateProposal(secretservice.ProposalPending, nil)
+
+	ret, err := r.sut.ApproveRelease(r.ctx, r.reviewArgs())
+
+	r.NoError(err)
+	r.Equal(secretservice.ProposalPending, ret.Status())
+	r.Len(ret.Approvals(), 1)
+	r.Equal("bob", ret.Approvals()[0].Principal())
+	r.Nil(ret.Release())
+}
+
+func (r *rootResolverTestSuite) TestApproveRelease_Released() {
+	r.as("bob")
+
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScopeApprovals(1)
+	r.withGetProposal(secretservice.ProposalPending, variable)
+	r.withCreateRelease(nil, variable)
+	r.withUpdateProposal(secretservice.ProposalApproved, nil)
+
+	ret, err := r.sut.ApproveRelease(r.ctx, r.reviewArgs())
+
+	r.NoError(err)
+	r.Equal(secretservice.ProposalApproved, ret.Status())
+	r.EqualValues("releaseID", ret.Release().ID())
+}
+
+func (r *rootResolverTestSuite) TestApproveRelease_Requester() {
+	r.as("alice")
+
+	r.withScopeApprovals(1)
+	r.withGetProposal(secretservice.ProposalPending)
+
+	ret, err := r.sut.ApproveRelease(r.ctx, r.reviewArgs())
+
+	r.Nil(ret)
+	r.EqualError(err, "proposals can not be approved by their requester")
+}
+
+func (r *rootResolverTestSuite) TestApproveRelease_AlreadyApproved() {
+	r.as("bob")
+
+	r.withScopeApprovals(2)
+	r.backend.
+		On("GetProposal", r.ctx, "scopeName", "proposalID").
+		Return(&secretservice.Proposal{
+			ID:          "proposalID",
+			RequestedBy: "alice",
+			Approvals:   []*secretservice.Approval{{Principal: "bob"}},
+			Status:      secretservice.ProposalPending,
+		}, nil)
+
+	ret, err := r.sut.ApproveRelease(r.ctx, r.reviewArgs())
+
+	r.Nil(ret)
+	r.EqualError(err, "proposal proposalID is already approved by bob")
+}
+
+func (r *rootResolverTestSuite) TestApproveRelease_NotPending() {
+	r.as("bob")
+
+	r.withScopeApprovals(1)
+	r.withGetProposal(secretservice.ProposalRejected)
+
+	ret, err := r.sut.ApproveRelease(r.ctx, r.reviewArgs())
+
+	r.Nil(ret)
+	r.EqualError(err, "proposal proposalID is REJECTED and can not be reviewed")
+}
+
+func (r *rootResolverTestSuite) TestApproveRelease_Unauthenticated() {
+	ret, err := r.sut.ApproveRelease(r.ctx, r.reviewArgs())
 
-	r.NoError(err)
-	r.EqualValues("scopeName", ret.ID())
-	r.Equal("kmsKeyID", ret.KMSKeyID())
-	r.Equal(r.backend, ret.backend)
+	r.Nil(ret)
+	r.EqualError(err, "reviewing a proposal requires an authenticated principal")
 }
 
-func (r *rootResolverTestSuite) TestCreateScope_AlreadyExists() {
-	r.withListVariables("scopes", nil, &ssmvars.Variable{Name: "scopeName"})
+func (r *rootResolverTestSuite) TestApproveRelease_CreateError() {
+	r.as("bob")
 
-	ret, err := r.sut.CreateScope(r.ctx, createScopeArgs{
-		Name:     "scopeName",
-		KMSKeyID: "kmsKeyID",
-	})
+	r.withScopeApprovals(1)
+	r.withGetProposal(secretservice.ProposalPending)
+	r.withUpdateProposal(secretservice.ProposalApproved, nil)
+	r.withCreateRelease(errors.New("bacon"))
+	r.backend.On(
+		"UpdateProposal",
+		r.ctx,
+		mock.MatchedBy(func(proposal *secretservice.Proposal) bool {
+			return proposal.Status == secretservice.ProposalPending && len(proposal.Approvals) == 0
+		}),
+	).Return(nil)
+
+	ret, err := r.sut.ApproveRelease(r.ctx, r.reviewArgs())
 
 	r.Nil(ret)
-	r.EqualError(err, `scope "scopeName" already exists`)
+	r.EqualError(err, "could not create a release: bacon")
+	r.backend.AssertExpectations(r.T())
 }
 
-func (r *rootResolverTestSuite) TestCreateScope_BackendFailure() {
-	r.withListVariables("scopes", nil)
-	r.withCreateVariable(
-		"scopes",
-		&ssmvars.Variable{Name: "scopeName", Value: "kmsKeyID"},
-		errors.New("bacon"),
-	)
+func (r *rootResolverTestSuite) TestApproveRelease_Conflict() {
+	r.as("bob")
 
-	ret, err := r.sut.CreateScope(r.ctx, createScopeArgs{
-		Name:     "scopeName",
-		KMSKeyID: "kmsKeyID",
-	})
+	r.withScopeApprovals(1)
+	r.withGetProposal(secretservice.ProposalPending)
+	r.withUpdateProposal(secretservice.ProposalApproved, secretservice.Conflict("bacon"))
+
+	ret, err := r.sut.ApproveRelease(r.ctx, r.reviewArgs())
 
 	r.Nil(ret)
-	r.EqualError(err, "could not create scope: bacon")
+	r.EqualError(err, "could not update proposal: bacon")
+	r.backend.AssertNotCalled(r.T(), "CreateRelease", r.ctx, mock.Anything, mock.Anything, mock.Anything)
 }
 
-func (r *rootResolverTestSuite) TestAddVariable_OK() {
-	r.withScope(nil)
+func (r *rootResolverTestSuite) TestRejectRelease_OK() {
+	r.as("bob")
 
-	r.withCreateVariable(
-		"workspace/scopeName",
-		&ssmvars.Variable{Name: "name", Value: "value", WriteOnly: true},
-		nil,
-	)
+	r.withScopeApprovals(1)
+	r.withGetProposal(secretservice.ProposalPending)
+	r.withUpdateProposal(secretservice.ProposalRejected, nil)
 
-	ret, err := r.addVariable()
+	ret, err := r.sut.RejectRelease(r.ctx, r.reviewArgs())
 
 	r.NoError(err)
-
-	r.EqualValues("name", ret.ID())
-	r.Nil(ret.Value())
+	r.Equal(secretservice.ProposalRejected, ret.Status())
+	r.Equal("bob", *ret.RejectedBy())
 }
 
-func (r *rootResolverTestSuite) TestAddVariable_ScopeFailure() {
-	r.withScope(errors.New("bacon"))
+func (r *rootResolverTestSuite) TestRejectRelease_UpdateError() {
+	r.as("bob")
 
-	ret, err := r.addVariable()
+	r.withScopeApprovals(1)
+	r.withGetProposal(secretservice.ProposalPending)
+	r.withUpdateProposal(secretservice.ProposalRejected, errors.New("bacon"))
+
+	ret, err := r.sut.RejectRelease(r.ctx, r.reviewArgs())
 
 	r.Nil(ret)
-	r.EqualError(err, "could not retrieve scope: bacon")
+	r.EqualError(err, "could not update proposal: bacon")
 }
 
-func (r *rootResolverTestSuite) TestAddVariable_AddFailure() {
-	r.withScope(nil)
+func (r *rootResolverTestSuite) TestApproveRelease_WrongKind() {
+	r.as("bob")
 
-	r.withCreateVariable(
-		"workspace/scopeName",
-		&ssmvars.Variable{Name: "name", Value: "value", WriteOnly: true},
-		errors.New("bacon"),
-	)
+	r.withScopeApprovals(1)
+	r.withGetChangeProposal(nil)
 
-	ret, err := r.addVariable()
+	ret, err := r.sut.ApproveRelease(r.ctx, r.reviewArgs())
 
 	r.Nil(ret)
-	r.EqualError(err, "could not create variable: bacon")
+	r.EqualError(err, "proposal proposalID is not a RELEASE proposal")
 }
 
-func (r *rootResolverTestSuite) TestRemoveVariable_OK() {
-	variable := &ssmvars.Variable{}
-	r.withDeleteVariable(variable, nil)
+func (r *rootResolverTestSuite) TestRequestChange_OK() {
+	r.as("alice")
 
-	ret, err := r.sut.RemoveVariable(r.ctx, removeVariableArgs{
+	r.withProtectedScope(0)
+	r.withListVariables("workspace/scopeName", nil, &ssmvars.Variable{Name: "OLD", Value: "old"})
+	r.backend.On(
+		"CreateProposal",
+		r.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			proposal, ok := arg.(*secretservice.Proposal)
+			r.True(ok)
+
+			r.Equal(secretservice.ProposalChange, proposal.Kind)
+			r.Equal("alice", proposal.RequestedBy)
+			r.Equal(secretservice.ProposalPending, proposal.Status)
+			r.Equal([]*ssmvars.Variable{{Name: "NEW", Value: "new"}}, proposal.Variables)
+			r.Equal([]string{"OLD"}, proposal.Remove)
+			r.Contains(proposal.Metadata, "NEW")
+
+			return true
+		}),
+	).Return(&secretservice.Proposal{
+		ID:     "proposalID",
+		Kind:   secretservice.ProposalChange,
+		Status: secretservice.ProposalPending,
+	}, nil)
+
+	ret, err := r.sut.RequestChange(r.ctx, requestChangeArgs{
 		ScopeID: "scopeName",
-		ID:      "variable",
+		Message: "swap variables",
+		Set:     &[]variableInput{{Name: "NEW", Value: "new"}},
+		Remove:  &[]graphql.ID{"OLD"},
 	})
 
 	r.NoError(err)
-	r.Equal(variable, ret.wraps)
+	r.EqualValues("proposalID", ret.ID())
+	r.Equal(secretservice.ProposalChange, ret.Kind())
+	r.Equal(secretservice.ProposalPending, ret.Status())
 }
 
-func (r *rootResolverTestSuite) TestRemoveVariable_BackendFailure() {
-	r.withDeleteVariable((*ssmvars.Variable)(nil), errors.New("bacon"))
+func (r *rootResolverTestSuite) TestRequestChange_Settings() {
+	r.as("alice")
 
-	ret, err := r.sut.RemoveVariable(r.ctx, removeVariableArgs{
+	protected := false
+
+	r.withProtectedScope(0)
+	r.withListVariables("workspace/scopeName", nil)
+	r.backend.On(
+		"CreateProposal",
+		r.ctx,
+		mock.MatchedBy(func(proposal *secretservice.Proposal) bool {
+			return proposal.Settings != nil &&
+				proposal.Settings.Protected != nil &&
+				!*proposal.Settings.Protected &&
+				proposal.Settings.RequiredApprovals == nil
+		}),
+	).Return(&secretservice.Proposal{ID: "proposalID", Kind: secretservice.ProposalChange}, nil)
+
+	ret, err := r.sut.RequestChange(r.ctx, requestChangeArgs{
+		ScopeID:  "scopeName",
+		Message:  "unprotect",
+		Settings: &scopeSettingsInput{Protected: &protected},
+	})
+
+	r.NoError(err)
+	r.EqualValues("proposalID", ret.ID())
+}
+
+func (r *rootResolverTestSuite) TestRequestChange_InvalidSettings() {
+	r.as("alice")
+
+	requiredApprovals := int32(-1)
+
+	r.withProtectedScope(0)
+	r.withListVariables("workspace/scopeName", nil)
+
+	ret, err := r.sut.RequestChange(r.ctx, requestChangeArgs{
+		ScopeID:  "scopeName",
+		Settings: &scopeSettingsInput{RequiredApprovals: &requiredApprovals},
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, "requiredApprovals can not be negative")
+}
+
+func (r *rootResolverTestSuite) TestRequestChange_InvalidChanges() {
+	r.as("alice")
+
+	r.withProtectedScope(0)
+	r.withListVariables("workspace/scopeName", nil)
+
+	ret, err := r.sut.RequestChange(r.ctx, requestChangeArgs{
 		ScopeID: "scopeName",
-		ID:      "variable",
+		Remove:  &[]graphql.ID{"MISSING"},
 	})
 
 	r.Nil(ret)
-	r.EqualError(err, "could not remove variable: bacon")
+	r.EqualError(err, `variable "MISSING" does not exist`)
 }
 
-func (r *rootResolverTestSuite) TestCreateRelease_OK() {
-	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+func (r *rootResolverTestSuite) TestRequestChange_Unauthenticated() {
+	ret, err := r.sut.RequestChange(r.ctx, requestChangeArgs{ScopeID: "scopeName"})
 
-	r.withScope(nil)
-	r.withListVariables("workspace/scopeName", nil, variable)
-	r.withCreateRelease(nil, variable)
+	r.Nil(ret)
+	r.EqualError(err, "requesting a change requires an authenticated principal")
+}
+
+func (r *rootResolverTestSuite) TestApproveChange_Applied() {
+	r.as("bob")
+
+	r.withProtectedScope(0)
+	r.withGetChangeProposal([]string{"OLD"}, &ssmvars.Variable{Name: "name", Value: "value"})
+	r.withListVariables("workspace/scopeName", nil, &ssmvars.Variable{Name: "OLD", Value: "old"})
+	r.withCreateVariable("workspace/scopeName", &ssmvars.Variable{Name: "name", Value: "value"}, nil)
+	r.withSetMetadata(nil)
+	r.backend.
+		On("DeleteVariable", r.ctx, "workspace/scopeName", "OLD").
+		Return(&ssmvars.Variable{Name: "OLD"}, nil)
+	r.backend.On("DeleteMetadata", r.ctx, "scopeName", "OLD").Return(nil)
+	r.withUpdateProposal(secretservice.ProposalApproved, nil)
 
-	ret, err := r.sut.CreateRelease(r.ctx, scopeArgs{ScopeID: "scopeName"})
+	ret, err := r.sut.ApproveChange(r.ctx, r.reviewArgs())
 
 	r.NoError(err)
-	r.EqualValues("releaseID", ret.ID())
-	r.Equal(r.backend, ret.backend)
-	r.NotNil(ret.scope)
+	r.Equal(secretservice.ProposalApproved, ret.Status())
+	r.Nil(ret.Release())
 }
 
-func (r *rootResolverTestSuite) TestCreateRelease_ScopeError() {
-	r.withScope(errors.New("bacon"))
+func (r *rootResolverTestSuite) TestApproveChange_Settings() {
+	r.as("bob")
 
-	ret, err := r.sut.CreateRelease(r.ctx, scopeArgs{ScopeID: "scopeName"})
+	protected := false
 
-	r.Nil(ret)
-	r.EqualError(err, "could not retrieve scope: bacon")
+	r.withProtectedScope(0)
+	r.backend.
+		On("GetProposal", r.ctx, "scopeName", "proposalID").
+		Return(&secretservice.Proposal{
+			ID:          "proposalID",
+			ScopeName:   "scopeName",
+			Kind:        secretservice.ProposalChange,
+			RequestedBy: "alice",
+			Approvals:   []*secretservice.Approval{},
+			Status:      secretservice.ProposalPending,
+			Settings:    &secretservice.ScopeSettings{Protected: &protected},
+		}, nil)
+	r.withUpdateProposal(secretservice.ProposalApproved, nil)
+	r.withListVariables("workspace/scopeName", nil)
+	r.withCreateVariable("scopes", &ssmvars.Variable{Name: "scopeName", Value: "kmsKeyID"}, nil)
+
+	ret, err := r.sut.ApproveChange(r.ctx, r.reviewArgs())
+
+	r.NoError(err)
+	r.Equal(secretservice.ProposalApproved, ret.Status())
+	r.backend.AssertExpectations(r.T())
 }
 
-func (r *rootResolverTestSuite) TestCreateRelease_ListError() {
-	r.withScope(nil)
+func (r *rootResolverTestSuite) TestApproveChange_NeedsMoreApprovals() {
+	r.as("bob")
+
+	r.withProtectedScope(2)
+	r.withGetChangeProposal(nil, &ssmvars.Variable{Name: "name", Value: "value"})
+	r.withUpdateProposal(secretservice.ProposalPending, nil)
+
+	ret, err := r.sut.ApproveChange(r.ctx, r.reviewArgs())
+
+	r.NoError(err)
+	r.Equal(secretservice.ProposalPending, ret.Status())
+}
+
+func (r *rootResolverTestSuite) TestApproveChange_ApplyError() {
+	r.as("bob")
+
+	r.withProtectedScope(0)
+	r.withGetChangeProposal(nil, &ssmvars.Variable{Name: "name", Value: "value"})
+	r.withUpdateProposal(secretservice.ProposalApproved, nil)
 	r.withListVariables("workspace/scopeName", errors.New("bacon"))
+	r.withUpdateProposal(secretservice.ProposalPending, nil)
 
-	ret, err := r.sut.CreateRelease(r.ctx, scopeArgs{ScopeID: "scopeName"})
+	ret, err := r.sut.ApproveChange(r.ctx, r.reviewArgs())
 
 	r.Nil(ret)
 	r.EqualError(err, "could not list variables: bacon")
 }
 
-func (r *rootResolverTestSuite) TestCreateRelease_CreateError() {
-	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+func (r *rootResolverTestSuite) TestRejectChange_OK() {
+	r.as("bob")
 
-	r.withScope(nil)
-	r.withListVariables("workspace/scopeName", nil, variable)
-	r.withCreateRelease(errors.New("bacon"), variable)
+	r.withProtectedScope(0)
+	r.withGetChangeProposal(nil)
+	r.withUpdateProposal(secretservice.ProposalRejected, nil)
 
-	ret, err := r.sut.CreateRelease(r.ctx, scopeArgs{ScopeID: "scopeName"})
+	ret, err := r.sut.RejectChange(r.ctx, r.reviewArgs())
 
-	r.Nil(ret)
-	r.EqualError(err, "could not create a release: bacon")
+	r.NoError(err)
+	r.Equal(secretservice.ProposalRejected, ret.Status())
+	r.Equal("bob", *ret.RejectedBy())
 }
 
 func (r *rootResolverTestSuite) TestArchiveRelease_OK() {
@@ -241,13 +1741,68 @@ func (r *rootResolverTestSuite) TestArchiveRelease_ArchiveError() {
 	r.EqualError(err, "could not archive release: bacon")
 }
 
+func (r *rootResolverTestSuite) TestMerge_OK() {
+	r.withScope(nil)
+	r.withGetReleaseID("base", &ssmvars.Variable{Name: "VARIABLE", Value: "base"})
+	r.withGetReleaseID("incoming", &ssmvars.Variable{Name: "VARIABLE", Value: "incoming"})
+	r.withListVariables("workspace/scopeName", nil, &ssmvars.Variable{Name: "VARIABLE", Value: "base"})
+	r.withListMetadata(nil, nil)
+	r.withWriteVariable(&ssmvars.Variable{Name: "VARIABLE", Value: "incoming"})
+	r.backend.On("SetMetadata", r.ctx, "scopeName", "VARIABLE", mock.Anything).Return(nil)
+
+	ret, err := r.merge("OURS")
+
+	r.NoError(err)
+	r.Empty(ret.Conflicts())
+	r.Len(ret.Applied().Changed(), 1)
+	r.EqualValues("scopeName", ret.Scope().ID())
+}
+
+func (r *rootResolverTestSuite) TestMerge_Conflict() {
+	r.withScope(nil)
+	r.withGetReleaseID("base", &ssmvars.Variable{Name: "VARIABLE", Value: "base"})
+	r.withGetReleaseID("incoming", &ssmvars.Variable{Name: "VARIABLE", Value: "incoming"})
+	r.withListVariables("workspace/scopeName", nil, &ssmvars.Variable{Name: "VARIABLE", Value: "ours"})
+
+	ret, err := r.merge("FAIL_ON_CONFLICT")
+
+	r.NoError(err)
+	r.Len(ret.Conflicts(), 1)
+	r.Empty(ret.Applied().Changed())
+	r.backend.AssertNotCalled(r.T(), "CreateVariable", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (r *rootResolverTestSuite) TestMerge_InvalidStrategy() {
+	ret, err := r.merge("BACON")
+
+	r.Nil(ret)
+	r.EqualError(err, `unsupported merge strategy "BACON"`)
+}
+
+func (r *rootResolverTestSuite) TestMerge_GetReleaseError() {
+	r.withScope(nil)
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "base").
+		Return((*secretservice.Release)(nil), errors.New("bacon"))
+
+	ret, err := r.merge("OURS")
+
+	r.Nil(ret)
+	r.EqualError(err, "could not get base release: bacon")
+}
+
 func (r *rootResolverTestSuite) TestReset_OK() {
 	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
 
 	r.withScope(nil)
 	r.withGetRelease(variable, nil)
+	r.withListMetadata(nil, map[string]*secretservice.Metadata{
+		"VARIABLE": new(secretservice.Metadata),
+		"STALE":    new(secretservice.Metadata),
+	})
 	r.backend.On("Reset", r.ctx, "workspace/scopeName").Return(nil)
 	r.withCreateVariable("workspace/scopeName", variable, nil)
+	r.withDeleteMetadata("STALE", nil)
 
 	ret, err := r.sut.Reset(r.ctx, mutateReleaseArgs{
 		ScopeID:   "scopeName",
@@ -257,6 +1812,21 @@ func (r *rootResolverTestSuite) TestReset_OK() {
 	r.NoError(err)
 	r.Equal(r.backend, ret.backend)
 	r.NotNil(ret.wraps)
+	r.backend.AssertNotCalled(r.T(), "DeleteMetadata", r.ctx, "scopeName", "VARIABLE")
+}
+
+func (r *rootResolverTestSuite) TestReset_Protected() {
+	r.as("bob")
+
+	r.withProtectedScope(0)
+
+	ret, err := r.sut.Reset(r.ctx, mutateReleaseArgs{
+		ScopeID:   "scopeName",
+		ReleaseID: "releaseID",
+	})
+
+	r.Nil(ret)
+	r.EqualError(err, `scope "scopeName" is protected, changes require the admin role or an approved change request`)
 }
 
 func (r *rootResolverTestSuite) TestReset_ScopeError() {
@@ -291,6 +1861,7 @@ func (r *rootResolverTestSuite) TestReset_ResetError() {
 
 	r.withScope(nil)
 	r.withGetRelease(variable, nil)
+	r.withListMetadata(nil, nil)
 	r.backend.On("Reset", r.ctx, "workspace/scopeName").Return(errors.New("bacon"))
 
 	ret, err := r.sut.Reset(r.ctx, mutateReleaseArgs{
@@ -307,6 +1878,7 @@ func (r *rootResolverTestSuite) TestReset_CreateVariableError() {
 
 	r.withScope(nil)
 	r.withGetRelease(variable, nil)
+	r.withListMetadata(nil, nil)
 	r.backend.On("Reset", r.ctx, "workspace/scopeName").Return(nil)
 	r.withCreateVariable("workspace/scopeName", variable, errors.New("bacon"))
 
@@ -323,13 +1895,58 @@ func (r *rootResolverTestSuite) addVariable() (*variableResolver, error) {
 	return r.sut.AddVariable(r.ctx, addVariableArgs{
 		ScopeID: "scopeName",
 		Variable: variableInput{
-			Name:      "name",
-			Value:     "value",
-			WriteOnly: true,
+			Name:        "name",
+			Value:       "value",
+			WriteOnly:   true,
+			RotateEvery: &duration{Duration: time.Hour},
 		},
 	})
 }
 
+func (r *rootResolverTestSuite) generateVariable(kind string) (*variableResolver, error) {
+	length := int32(8)
+
+	return r.sut.GenerateVariable(r.ctx, generateVariableArgs{
+		ScopeID: "scopeName",
+		Name:    "name",
+		Spec:    secretSpecInput{Kind: kind, Length: &length},
+	})
+}
+
+func (r *rootResolverTestSuite) importVariables(mode, document string, dryRun bool, writeOnly ...string) (*diffResolver, error) {
+	return r.sut.ImportVariables(r.ctx, importVariablesArgs{
+		ScopeID:   "scopeName",
+		Format:    "DOTENV",
+		Document:  document,
+		Mode:      mode,
+		WriteOnly: &writeOnly,
+		DryRun:    &dryRun,
+	})
+}
+
+func (r *rootResolverTestSuite) applyChanges(expectedRevision *string, set []variableInput, remove ...graphql.ID) (*diffResolver, error) {
+	return r.sut.ApplyChanges(r.ctx, applyChangesArgs{
+		ScopeID:          "scopeName",
+		Set:              &set,
+		Remove:           &remove,
+		ExpectedRevision: expectedRevision,
+	})
+}
+
+func (r *rootResolverTestSuite) merge(strategy string) (*mergeResolver, error) {
+	return r.sut.Merge(r.ctx, mergeArgs{
+		ScopeID:  "scopeName",
+		Base:     "base",
+		Incoming: "incoming",
+		Strategy: strategy,
+	})
+}
+
+func (r *rootResolverTestSuite) ifChanged() createReleaseArgs {
+	ifChanged := true
+	return createReleaseArgs{ScopeID: "scopeName", IfChanged: &ifChanged}
+}
+
 func (r *rootResolverTestSuite) withArchiveRelease(err error) {
 	r.backend.On("ArchiveRelease", r.ctx, "scopeName", "releaseID").Return(err)
 }
@@ -345,7 +1962,7 @@ func (r *rootResolverTestSuite) withCreateRelease(err error, variables ...*ssmva
 		}
 	}
 
-	r.backend.On("CreateRelease", r.ctx, "scopeName", variables).Return(ret, err)
+	r.backend.On("CreateRelease", r.ctx, "scopeName", variables, "").Return(ret, err)
 }
 
 func (r *rootResolverTestSuite) withCreateVariable(namespace string, variable *ssmvars.Variable, err error) {
@@ -366,12 +1983,28 @@ func (r *rootResolverTestSuite) withCreateVariable(namespace string, variable *s
 	).Return(variable, err)
 }
 
+func (r *rootResolverTestSuite) withWriteVariable(variable *ssmvars.Variable) {
+	r.backend.On(
+		"CreateVariable",
+		r.ctx,
+		"workspace/scopeName",
+		mock.MatchedBy(func(arg interface{}) bool {
+			input, ok := arg.(*ssmvars.Variable)
+			return ok && *input == *variable
+		}),
+	).Return(variable, nil)
+}
+
 func (r *rootResolverTestSuite) withDeleteVariable(variable *ssmvars.Variable, err error) {
 	r.backend.
 		On("DeleteVariable", r.ctx, "workspace/scopeName", "variable").
 		Return(variable, err)
 }
 
+func (r *rootResolverTestSuite) withDeleteMetadata(variableName string, err error) {
+	r.backend.On("DeleteMetadata", r.ctx, "scopeName", variableName).Return(err)
+}
+
 func (r *rootResolverTestSuite) withGetRelease(variable *ssmvars.Variable, err error) {
 	r.backend.On("GetRelease", r.ctx, "scopeName", "releaseID").Return(&secretservice.Release{
 		ID:        "releaseID",
@@ -380,10 +2013,149 @@ func (r *rootResolverTestSuite) withGetRelease(variable *ssmvars.Variable, err e
 	}, err)
 }
 
+func (r *rootResolverTestSuite) withGetReleaseID(releaseID string, variables ...*ssmvars.Variable) {
+	r.backend.On("GetRelease", r.ctx, "scopeName", releaseID).Return(&secretservice.Release{
+		ID:        releaseID,
+		ScopeName: "scopeName",
+		Variables: variables,
+	}, nil)
+}
+
+func (r *rootResolverTestSuite) withListReleases(err error, ids ...string) {
+	r.backend.On("ListReleases", r.ctx, "scopeName", (*string)(nil)).Return(ids, err)
+}
+
+func (r *rootResolverTestSuite) withGetScheduledOperation(status string) {
+	r.backend.
+		On("GetScheduledOperation", r.ctx, "scopeName", "operationID").
+		Return(&secretservice.ScheduledOperation{ID: "operationID", ScopeName: "scopeName", Status: status}, nil)
+}
+
+func (r *rootResolverTestSuite) withScheduleOperation(kind, releaseID string, err error) {
+	r.backend.On(
+		"ScheduleOperation",
+		r.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			operation, ok := arg.(*secretservice.ScheduledOperation)
+			r.True(ok)
+
+			r.Equal("scopeName", operation.ScopeName)
+			r.Equal(kind, operation.Kind)
+			r.Equal(releaseID, operation.ReleaseID)
+
+			return true
+		}),
+	).Return(&secretservice.ScheduledOperation{ID: "operationID", Kind: kind}, err)
+}
+
+func (r *rootResolverTestSuite) withListMetadata(err error, metadata map[string]*secretservice.Metadata) {
+	r.backend.On("ListMetadata", r.ctx, "scopeName").Return(metadata, err)
+}
+
 func (r *rootResolverTestSuite) withListVariables(prefix string, err error, variables ...*ssmvars.Variable) {
 	r.backend.On("ListVariables", r.ctx, prefix).Return(variables, err)
 }
 
+func (r *rootResolverTestSuite) withSetMetadata(err error) {
+	r.backend.On(
+		"SetMetadata",
+		r.ctx,
+		"scopeName",
+		"name",
+		mock.MatchedBy(func(arg interface{}) bool {
+			metadata, ok := arg.(*secretservice.Metadata)
+			r.True(ok)
+
+			r.Nil(metadata.ExpiresAt)
+			r.Equal(time.Hour, *metadata.RotateEvery)
+			r.WithinDuration(time.Now(), metadata.UpdatedAt, time.Minute)
+
+			return true
+		}),
+	).Return(err)
+}
+
+// as makes requests on behalf of a Principal. It needs to be called before
+// setting up mocks, since they expect a particular context.
+func (r *rootResolverTestSuite) as(principalID string) {
+	r.ctx = secretservice.WithPrincipal(r.ctx, &secretservice.Principal{ID: principalID})
+}
+
+func (r *rootResolverTestSuite) asAdmin(principalID string) {
+	r.ctx = secretservice.WithPrincipal(r.ctx, &secretservice.Principal{
+		ID:    principalID,
+		Roles: []string{secretservice.RoleAdmin},
+	})
+}
+
+func (r *rootResolverTestSuite) reviewArgs() reviewProposalArgs {
+	return reviewProposalArgs{ScopeID: "scopeName", ID: "proposalID"}
+}
+
+func (r *rootResolverTestSuite) withGetProposal(status string, variables ...*ssmvars.Variable) {
+	r.backend.
+		On("GetProposal", r.ctx, "scopeName", "proposalID").
+		Return(&secretservice.Proposal{
+			ID:          "proposalID",
+			ScopeName:   "scopeName",
+			RequestedBy: "alice",
+			Variables:   variables,
+			Approvals:   []*secretservice.Approval{},
+			Status:      status,
+		}, nil)
+}
+
+func (r *rootResolverTestSuite) withGetChangeProposal(remove []string, variables ...*ssmvars.Variable) {
+	rotateEvery := time.Hour
+
+	metadata := make(map[string]*secretservice.Metadata, len(variables))
+	for _, variable := range variables {
+		metadata[variable.Name] = &secretservice.Metadata{RotateEvery: &rotateEvery}
+	}
+
+	r.backend.
+		On("GetProposal", r.ctx, "scopeName", "proposalID").
+		Return(&secretservice.Proposal{
+			ID:          "proposalID",
+			ScopeName:   "scopeName",
+			Kind:        secretservice.ProposalChange,
+			RequestedBy: "alice",
+			Variables:   variables,
+			Remove:      remove,
+			Metadata:    metadata,
+			Approvals:   []*secretservice.Approval{},
+			Status:      secretservice.ProposalPending,
+		}, nil)
+}
+
+func (r *rootResolverTestSuite) withUpdateProposal(status string, err error) {
+	r.backend.On(
+		"UpdateProposal",
+		r.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			proposal, ok := arg.(*secretservice.Proposal)
+			return ok && proposal.Status == status
+		}),
+	).Return(err)
+}
+
+func (r *rootResolverTestSuite) withScopeApprovals(requiredApprovals int) {
+	r.backend.On("Scope", r.ctx, "scopeName").Return(&secretservice.Scope{
+		Name:              "scopeName",
+		KMSKeyID:          "kmsKeyID",
+		RequiredApprovals: requiredApprovals,
+	}, nil)
+}
+
+func (r *rootResolverTestSuite) withProtectedScope(requiredApprovals int) {
+	r.backend.On("Scope", r.ctx, "scopeName").Return(&secretservice.Scope{
+		Name:              "scopeName",
+		KMSKeyID:          "kmsKeyID",
+		RequiredApprovals: requiredApprovals,
+		Protected:         true,
+	}, nil)
+}
+
 func (r *rootResolverTestSuite) withScope(err error) {
 	ret := &secretservice.Scope{}
 
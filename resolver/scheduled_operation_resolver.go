@@ -0,0 +1,48 @@
+package resolver
+
+import (
+	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
+)
+
+type scheduledOperationResolver struct {
+	backend secretservice.Backend
+	scope   *secretservice.Scope
+	wraps   *secretservice.ScheduledOperation
+}
+
+// id: ID!
+func (s *scheduledOperationResolver) ID() graphql.ID {
+	return graphql.ID(s.wraps.ID)
+}
+
+// error: String
+func (s *scheduledOperationResolver) Error() *string {
+	if s.wraps.Error == "" {
+		return nil
+	}
+	return &s.wraps.Error
+}
+
+// kind: OperationKind!
+func (s *scheduledOperationResolver) Kind() string {
+	return s.wraps.Kind
+}
+
+// release: Release
+func (s *scheduledOperationResolver) Release() *releaseResolver {
+	if s.wraps.ReleaseID == "" {
+		return nil
+	}
+	return newReleaseResolver(s.backend, graphql.ID(s.wraps.ReleaseID), s.scope)
+}
+
+// runAt: Time!
+func (s *scheduledOperationResolver) RunAt() graphql.Time {
+	return graphql.Time{Time: s.wraps.RunAt}
+}
+
+// status: OperationStatus!
+func (s *scheduledOperationResolver) Status() string {
+	return s.wraps.Status
+}
@@ -0,0 +1,75 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/stretchr/testify/suite"
+)
+
+type scheduledOperationResolverTestSuite struct {
+	suite.Suite
+
+	backend *mockBackend
+	runAt   time.Time
+
+	sut *scheduledOperationResolver
+}
+
+func (s *scheduledOperationResolverTestSuite) SetupTest() {
+	s.backend = new(mockBackend)
+	s.runAt = time.Date(2018, 11, 10, 2, 0, 0, 0, time.UTC)
+	s.sut = &scheduledOperationResolver{
+		backend: s.backend,
+		scope:   &secretservice.Scope{Name: "scopeName"},
+		wraps: &secretservice.ScheduledOperation{
+			ID:        "operationID",
+			ScopeName: "scopeName",
+			Kind:      secretservice.OperationArchiveRelease,
+			ReleaseID: "releaseID",
+			RunAt:     s.runAt,
+			Status:    secretservice.OperationPending,
+		},
+	}
+}
+
+func (s *scheduledOperationResolverTestSuite) TestID() {
+	s.EqualValues("operationID", s.sut.ID())
+}
+
+func (s *scheduledOperationResolverTestSuite) TestError() {
+	s.Nil(s.sut.Error())
+
+	s.sut.wraps.Error = "bacon"
+	s.Equal("bacon", *s.sut.Error())
+}
+
+func (s *scheduledOperationResolverTestSuite) TestKind() {
+	s.Equal("ARCHIVE_RELEASE", s.sut.Kind())
+}
+
+func (s *scheduledOperationResolverTestSuite) TestRelease() {
+	ret := s.sut.Release()
+
+	s.EqualValues("releaseID", ret.ID())
+	s.Equal(s.sut.scope, ret.scope)
+}
+
+func (s *scheduledOperationResolverTestSuite) TestRelease_None() {
+	s.sut.wraps.ReleaseID = ""
+
+	s.Nil(s.sut.Release())
+}
+
+func (s *scheduledOperationResolverTestSuite) TestRunAt() {
+	s.Equal(s.runAt, s.sut.RunAt().Time)
+}
+
+func (s *scheduledOperationResolverTestSuite) TestStatus() {
+	s.Equal("PENDING", s.sut.Status())
+}
+
+func TestScheduledOperationResolver(t *testing.T) {
+	suite.Run(t, new(scheduledOperationResolverTestSuite))
+}
@@ -3,6 +3,8 @@ package resolver
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	graphql "github.com/graph-gophers/graphql-go"
@@ -40,15 +42,68 @@ func (s *scopeResolver) Diff(ctx context.Context, args diffArgs) (*diffResolver,
 	return newDiffResolver(release.Variables, newVariables), nil
 }
 
+type expiringVariablesArgs struct {
+	Within duration
+}
+
+// expiringVariables(within: Duration!): [Variable!]!
+func (s *scopeResolver) ExpiringVariables(ctx context.Context, args expiringVariablesArgs) ([]*variableResolver, error) {
+	variables, err := s.Variables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(args.Within.Duration)
+	deadlines := make(map[string]time.Time)
+
+	ret := []*variableResolver{}
+	for _, variable := range variables {
+		if variable.metadata == nil {
+			continue
+		}
+		deadline, ok := variable.metadata.Deadline()
+		if !ok || deadline.After(cutoff) {
+			continue
+		}
+		deadlines[variable.wraps.Name] = deadline
+		ret = append(ret, variable)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return deadlines[ret[i].wraps.Name].Before(deadlines[ret[j].wraps.Name])
+	})
+
+	return ret, nil
+}
+
 // kmsKeyId: String!
 func (s *scopeResolver) KMSKeyID() string {
 	return s.wraps.KMSKeyID
 }
 
+// requiredApprovals: Int!
+func (s *scopeResolver) RequiredApprovals() int32 {
+	return int32(s.wraps.RequiredApprovals)
+}
+
+// revision: String!
+func (s *scopeResolver) Revision(ctx context.Context) (string, error) {
+	variables, err := s.workspace(ctx)
+	if err != nil {
+		return "", err
+	}
+	return s.backend.ContentHash(variables), nil
+}
+
 type releaseArgs struct {
 	ID graphql.ID
 }
 
+// protected: Boolean!
+func (s *scopeResolver) Protected() bool {
+	return s.wraps.Protected
+}
+
 // release(id: ID!): Release!
 func (s *scopeResolver) Release(args releaseArgs) *releaseResolver {
 	return newReleaseResolver(s.backend, args.ID, s.wraps)
@@ -85,10 +140,15 @@ func (s *scopeResolver) Variables(ctx context.Context) ([]*variableResolver, err
 		return nil, err
 	}
 
+	metadata, err := s.backend.ListMetadata(ctx, s.wraps.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get variable metadata")
+	}
+
 	num := len(variables)
 	ret := make([]*variableResolver, num, num)
 	for i, variable := range variables {
-		ret[i] = &variableResolver{wraps: variable}
+		ret[i] = &variableResolver{metadata: metadata[variable.Name], wraps: variable}
 	}
 	return ret, nil
 }
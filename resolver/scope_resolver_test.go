@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/graph-gophers/graphql-go"
@@ -86,10 +87,53 @@ func (s *scopeResolverTestSuite) TestDiff_S3Failure() {
 	s.EqualError(err, "could not retrieve old release: bacon")
 }
 
+func (s *scopeResolverTestSuite) TestExpiringVariables_OK() {
+	later := time.Now().Add(time.Hour)
+	soon := time.Now().Add(time.Minute)
+	tooLate := time.Now().Add(48 * time.Hour)
+
+	s.backend.
+		On("ListVariables", s.ctx, "workspace/scopeName").
+		Return([]*ssmvars.Variable{{Name: "LATER"}, {Name: "SOON"}, {Name: "TOO_LATE"}, {Name: "NONE"}}, nil)
+
+	s.backend.
+		On("ListMetadata", s.ctx, "scopeName").
+		Return(map[string]*secretservice.Metadata{
+			"LATER":    {ExpiresAt: &later},
+			"SOON":     {ExpiresAt: &soon},
+			"TOO_LATE": {ExpiresAt: &tooLate},
+		}, nil)
+
+	ret, err := s.sut.ExpiringVariables(s.ctx, expiringVariablesArgs{Within: duration{Duration: 24 * time.Hour}})
+
+	s.NoError(err)
+	s.Len(ret, 2)
+	s.EqualValues("SOON", ret[0].ID())
+	s.EqualValues("LATER", ret[1].ID())
+}
+
+func (s *scopeResolverTestSuite) TestExpiringVariables_BackendFailure() {
+	s.backend.
+		On("ListVariables", s.ctx, "workspace/scopeName").
+		Return([]*ssmvars.Variable(nil), errors.New("bacon"))
+
+	ret, err := s.sut.ExpiringVariables(s.ctx, expiringVariablesArgs{Within: duration{Duration: time.Hour}})
+
+	s.Nil(ret)
+	s.EqualError(err, "could not get workspace: bacon")
+}
+
 func (s *scopeResolverTestSuite) TestKMSKeyID() {
 	s.Equal("kmsKeyID", s.sut.KMSKeyID())
 }
 
+func (s *scopeResolverTestSuite) TestRequiredApprovals() {
+	s.Zero(s.sut.RequiredApprovals())
+
+	s.sut.wraps.RequiredApprovals = 2
+	s.EqualValues(2, s.sut.RequiredApprovals())
+}
+
 func (s *scopeResolverTestSuite) TestRelease() {
 	release := s.sut.Release(releaseArgs{ID: "releaseID"})
 
@@ -127,18 +171,64 @@ func (s *scopeResolverTestSuite) TestReleases_BackendFailure() {
 	s.EqualError(err, "could not list release IDs: bacon")
 }
 
+func (s *scopeResolverTestSuite) TestRevision_OK() {
+	variables := []*ssmvars.Variable{{Name: "B"}, {Name: "A"}}
+
+	s.backend.
+		On("ListVariables", s.ctx, "workspace/scopeName").
+		Return(variables, nil)
+
+	ret, err := s.sut.Revision(s.ctx)
+
+	s.NoError(err)
+	s.Equal(secretservice.ContentHash(nil, []*ssmvars.Variable{variables[1], variables[0]}), ret)
+}
+
+func (s *scopeResolverTestSuite) TestRevision_BackendFailure() {
+	s.backend.
+		On("ListVariables", s.ctx, "workspace/scopeName").
+		Return([]*ssmvars.Variable(nil), errors.New("bacon"))
+
+	ret, err := s.sut.Revision(s.ctx)
+
+	s.Empty(ret)
+	s.EqualError(err, "could not get workspace: bacon")
+}
+
 func (s *scopeResolverTestSuite) TestVariables_OK() {
 	variable := &ssmvars.Variable{Name: "NEW"}
 
+	metadata := &secretservice.Metadata{UpdatedAt: time.Now()}
+
 	s.backend.
 		On("ListVariables", s.ctx, "workspace/scopeName").
 		Return([]*ssmvars.Variable{variable}, nil)
 
+	s.backend.
+		On("ListMetadata", s.ctx, "scopeName").
+		Return(map[string]*secretservice.Metadata{"NEW": metadata}, nil)
+
 	ret, err := s.sut.Variables(s.ctx)
 
 	s.NoError(err)
 	s.Len(ret, 1)
 	s.Equal(variable, ret[0].wraps)
+	s.Equal(metadata, ret[0].metadata)
+}
+
+func (s *scopeResolverTestSuite) TestVariables_MetadataFailure() {
+	s.backend.
+		On("ListVariables", s.ctx, "workspace/scopeName").
+		Return([]*ssmvars.Variable{{Name: "NEW"}}, nil)
+
+	s.backend.
+		On("ListMetadata", s.ctx, "scopeName").
+		Return(map[string]*secretservice.Metadata(nil), errors.New("bacon"))
+
+	ret, err := s.sut.Variables(s.ctx)
+
+	s.Nil(ret)
+	s.EqualError(err, "could not get variable metadata: bacon")
 }
 
 func (s *scopeResolverTestSuite) TestVariables_BackendFailure() {
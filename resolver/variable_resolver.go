@@ -3,11 +3,13 @@ package resolver
 import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/graph-gophers/graphql-go"
+	"github.com/marcinwyszynski/secretservice"
 	"github.com/marcinwyszynski/ssmvars"
 )
 
 type variableResolver struct {
-	wraps *ssmvars.Variable
+	metadata *secretservice.Metadata
+	wraps    *ssmvars.Variable
 }
 
 // id: ID!
@@ -15,6 +17,30 @@ func (v *variableResolver) ID() graphql.ID {
 	return graphql.ID(v.wraps.Name)
 }
 
+// expiresAt: Time
+func (v *variableResolver) ExpiresAt() *graphql.Time {
+	if v.metadata == nil || v.metadata.ExpiresAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *v.metadata.ExpiresAt}
+}
+
+// rotateEvery: Duration
+func (v *variableResolver) RotateEvery() *duration {
+	if v.metadata == nil || v.metadata.RotateEvery == nil {
+		return nil
+	}
+	return &duration{Duration: *v.metadata.RotateEvery}
+}
+
+// updatedAt: Time
+func (v *variableResolver) UpdatedAt() *graphql.Time {
+	if v.metadata == nil {
+		return nil
+	}
+	return &graphql.Time{Time: v.metadata.UpdatedAt}
+}
+
 // value: String
 func (v *variableResolver) Value() *string {
 	if v.wraps.WriteOnly {
@@ -2,7 +2,9 @@ package resolver
 
 import (
 	"testing"
+	"time"
 
+	"github.com/marcinwyszynski/secretservice"
 	"github.com/marcinwyszynski/ssmvars"
 	"github.com/stretchr/testify/suite"
 )
@@ -23,6 +25,27 @@ func (v *variableResolverTestSuite) TestID() {
 	v.EqualValues("NAME", v.sut.ID())
 }
 
+func (v *variableResolverTestSuite) TestMetadata_Missing() {
+	v.Nil(v.sut.ExpiresAt())
+	v.Nil(v.sut.RotateEvery())
+	v.Nil(v.sut.UpdatedAt())
+}
+
+func (v *variableResolverTestSuite) TestMetadata_Present() {
+	expiresAt := time.Date(2018, 11, 10, 23, 0, 0, 0, time.UTC)
+	rotateEvery := time.Hour
+
+	v.sut.metadata = &secretservice.Metadata{
+		ExpiresAt:   &expiresAt,
+		RotateEvery: &rotateEvery,
+		UpdatedAt:   expiresAt.Add(-rotateEvery),
+	}
+
+	v.Equal(expiresAt, v.sut.ExpiresAt().Time)
+	v.Equal(rotateEvery, v.sut.RotateEvery().Duration)
+	v.Equal(expiresAt.Add(-rotateEvery), v.sut.UpdatedAt().Time)
+}
+
 func (v *variableResolverTestSuite) TestValue_Public() {
 	v.variable.WriteOnly = false
 	v.Equal("value", *v.sut.Value())
@@ -0,0 +1,196 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/pkg/errors"
+)
+
+// maxParallelWrites is the maximum number of concurrent backend calls made
+// while applying changes to a workspace.
+const maxParallelWrites = 8
+
+// applyDiff brings the workspace of a given Scope in line with the diff,
+// writing new and changed Variables along with their metadata, and removing
+// deleted ones along with theirs. Metadata of Variables which the diff does
+// not change is written on its own. Backend calls are made concurrently, and
+// the first error encountered is returned.
+func applyDiff(ctx context.Context, backend secretservice.Backend, scopeName string, diff *diffResolver, metadata map[string]*secretservice.Metadata) error {
+	namespace := fmt.Sprintf("workspace/%s", scopeName)
+
+	var tasks []func() error
+
+	written := make(map[string]bool)
+	for _, variable := range diff.written() {
+		variable := variable
+		written[variable.Name] = true
+		tasks = append(tasks, func() error {
+			if _, err := backend.CreateVariable(ctx, namespace, variable); err != nil {
+				return errors.Wrapf(err, "could not write variable %q", variable.Name)
+			}
+
+			meta, exists := metadata[variable.Name]
+			if !exists {
+				return nil
+			}
+
+			if err := backend.SetMetadata(ctx, scopeName, variable.Name, meta); err != nil {
+				return errors.Wrapf(err, "could not set metadata for variable %q", variable.Name)
+			}
+
+			return nil
+		})
+	}
+
+	for name, meta := range metadata {
+		if written[name] {
+			continue
+		}
+		name, meta := name, meta
+		tasks = append(tasks, func() error {
+			if err := backend.SetMetadata(ctx, scopeName, name, meta); err != nil {
+				return errors.Wrapf(err, "could not set metadata for variable %q", name)
+			}
+			return nil
+		})
+	}
+
+	for _, variable := range diff.Deleted() {
+		name := variable.wraps.Name
+		tasks = append(tasks, func() error {
+			if _, err := backend.DeleteVariable(ctx, namespace, name); err != nil {
+				return errors.Wrapf(err, "could not remove variable %q", name)
+			}
+
+			if err := backend.DeleteMetadata(ctx, scopeName, name); err != nil {
+				return errors.Wrapf(err, "could not remove metadata for variable %q", name)
+			}
+
+			return nil
+		})
+	}
+
+	return runParallel(tasks, maxParallelWrites)
+}
+
+// preservedMetadata returns metadata for Variables written as part of the
+// diff, keeping their existing expiry and rotation settings and setting the
+// update time to now.
+func preservedMetadata(existing map[string]*secretservice.Metadata, diff *diffResolver, now time.Time) map[string]*secretservice.Metadata {
+	ret := make(map[string]*secretservice.Metadata)
+
+	for _, variable := range diff.written() {
+		meta := new(secretservice.Metadata)
+		if old, exists := existing[variable.Name]; exists {
+			*meta = *old
+		}
+		meta.UpdatedAt = now
+		ret[variable.Name] = meta
+	}
+
+	return ret
+}
+
+// changedMetadata returns metadata for Variables set in a batch of changes.
+// Variables whose value changes get a new update time. Others keep their
+// existing update time, and are skipped unless their expiry or rotation
+// settings change.
+func changedMetadata(existing map[string]*secretservice.Metadata, set []variableInput, diff *diffResolver, now time.Time) map[string]*secretservice.Metadata {
+	written := make(map[string]bool)
+	for _, variable := range diff.written() {
+		written[variable.Name] = true
+	}
+
+	ret := make(map[string]*secretservice.Metadata, len(set))
+	for _, input := range set {
+		if written[input.Name] {
+			ret[input.Name] = input.metadata(now)
+			continue
+		}
+
+		old, exists := existing[input.Name]
+		if !exists {
+			old = &secretservice.Metadata{UpdatedAt: now}
+		}
+
+		meta := input.metadata(old.UpdatedAt)
+		if !sameSettings(old, meta) {
+			ret[input.Name] = meta
+		}
+	}
+
+	return ret
+}
+
+// sameSettings returns true if two sets of metadata have the same expiry and
+// rotation settings.
+func sameSettings(a, b *secretservice.Metadata) bool {
+	sameExpiry := a.ExpiresAt == nil && b.ExpiresAt == nil ||
+		a.ExpiresAt != nil && b.ExpiresAt != nil && a.ExpiresAt.Equal(*b.ExpiresAt)
+
+	sameRotation := a.RotateEvery == nil && b.RotateEvery == nil ||
+		a.RotateEvery != nil && b.RotateEvery != nil && *a.RotateEvery == *b.RotateEvery
+
+	return sameExpiry && sameRotation
+}
+
+// mergeVariables returns the workspace content after setting and removing
+// Variables.
+func mergeVariables(current, set []*ssmvars.Variable, remove map[string]bool) []*ssmvars.Variable {
+	replaced := make(map[string]bool, len(set))
+	for _, variable := range set {
+		replaced[variable.Name] = true
+	}
+
+	ret := append([]*ssmvars.Variable{}, set...)
+	for _, variable := range current {
+		if !replaced[variable.Name] && !remove[variable.Name] {
+			ret = append(ret, variable)
+		}
+	}
+
+	return ret
+}
+
+// changeDiff returns the difference a CHANGE Proposal makes to the current
+// workspace.
+func changeDiff(current []*ssmvars.Variable, proposal *secretservice.Proposal) *diffResolver {
+	removed := make(map[string]bool, len(proposal.Remove))
+	for _, name := range proposal.Remove {
+		removed[name] = true
+	}
+	return newDiffResolver(current, mergeVariables(current, proposal.Variables, removed))
+}
+
+// runParallel runs tasks with at most "parallelism" of them running at any
+// given time, and returns the first error encountered.
+func runParallel(tasks []func() error, parallelism int) error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var ret error
+
+	semaphore := make(chan struct{}, parallelism)
+
+	for _, task := range tasks {
+		task := task
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if err := task(); err != nil {
+				once.Do(func() { ret = err })
+			}
+		}()
+	}
+
+	wg.Wait()
+	return ret
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockBackend struct {
+	mock.Mock
+	secretservice.Backend
+}
+
+func (m *mockBackend) ArchiveRelease(ctx context.Context, scopeName, releaseID string) error {
+	return m.Called(ctx, scopeName, releaseID).Error(0)
+}
+
+// ContentHash is deterministic, so it is not mocked. Hashes are unkeyed.
+func (m *mockBackend) ContentHash(variables []*ssmvars.Variable) string {
+	return secretservice.ContentHash(nil, variables)
+}
+
+func (m *mockBackend) CreateRelease(ctx context.Context, scopeName string, variables []*ssmvars.Variable, baseReleaseID string) (*secretservice.Release, error) {
+	args := m.Called(ctx, scopeName, variables, baseReleaseID)
+	return args.Get(0).(*secretservice.Release), args.Error(1)
+}
+
+func (m *mockBackend) GetRelease(ctx context.Context, scopeName, releaseID string) (*secretservice.Release, error) {
+	args := m.Called(ctx, scopeName, releaseID)
+	return args.Get(0).(*secretservice.Release), args.Error(1)
+}
+
+func (m *mockBackend) ListReleases(ctx context.Context, scopeName string, before *string) ([]string, error) {
+	args := m.Called(ctx, scopeName, before)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockBackend) ListPendingOperations(ctx context.Context, scopeName string) ([]*secretservice.ScheduledOperation, error) {
+	args := m.Called(ctx, scopeName)
+	return args.Get(0).([]*secretservice.ScheduledOperation), args.Error(1)
+}
+
+func (m *mockBackend) ListVariables(ctx context.Context, namespace string) ([]*ssmvars.Variable, error) {
+	args := m.Called(ctx, namespace)
+	return args.Get(0).([]*ssmvars.Variable), args.Error(1)
+}
+
+func (m *mockBackend) Scope(ctx context.Context, scopeName string) (*secretservice.Scope, error) {
+	args := m.Called(ctx, scopeName)
+	return args.Get(0).(*secretservice.Scope), args.Error(1)
+}
+
+func (m *mockBackend) UpdateScheduledOperation(ctx context.Context, operation *secretservice.ScheduledOperation) error {
+	return m.Called(ctx, operation).Error(0)
+}
@@ -0,0 +1,144 @@
+// Package scheduler executes Release operations scheduled for a later time.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+)
+
+// Runner executes scheduled operations which are due.
+type Runner struct {
+	backend secretservice.Backend
+}
+
+// New returns an instance of a Runner.
+func New(backend secretservice.Backend) *Runner {
+	return &Runner{backend: backend}
+}
+
+// Handle serves as a Lambda handler for scheduled CloudWatch events.
+func (r *Runner) Handle(ctx context.Context, event events.CloudWatchEvent) error {
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return r.Run(ctx, now)
+}
+
+// Run executes all pending operations in all Scopes which are due at the given
+// time. Every operation is only executed once - it is marked as RUNNING before
+// it is executed, which fails if it has been cancelled or picked up by another
+// run in the meantime, and its outcome is recorded afterwards. An operation
+// which fails is marked as such and not retried. One whose outcome could not
+// be recorded remains RUNNING. Scopes whose operations could not be run do not
+// stop others from running, their errors are returned together at the end.
+func (r *Runner) Run(ctx context.Context, now time.Time) error {
+	scopes, err := r.backend.ListVariables(ctx, "scopes")
+	if err != nil {
+		return errors.Wrap(err, "could not list scopes")
+	}
+
+	var failures []string
+	for _, scope := range scopes {
+		if err := r.runScope(ctx, scope.Name, now); err != nil {
+			failures = append(failures, errors.Wrapf(err, "could not run operations for scope %q", scope.Name).Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+func (r *Runner) runScope(ctx context.Context, scopeName string, now time.Time) error {
+	operations, err := r.backend.ListPendingOperations(ctx, scopeName)
+	if err != nil {
+		return errors.Wrap(err, "could not list pending operations")
+	}
+
+	for _, operation := range operations {
+		if !operation.Due(now) {
+			continue
+		}
+
+		operation.Status = secretservice.OperationRunning
+		if err := r.backend.UpdateScheduledOperation(ctx, operation); secretservice.ErrorCode(err) == secretservice.CodeConflict {
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "could not start operation %s", operation.ID)
+		}
+
+		operation.Status = secretservice.OperationDone
+		if err := r.execute(ctx, operation); err != nil {
+			operation.Status = secretservice.OperationFailed
+			operation.Error = err.Error()
+		}
+
+		if err := r.backend.UpdateScheduledOperation(ctx, operation); err != nil {
+			return errors.Wrapf(err, "could not record outcome of operation %s", operation.ID)
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) execute(ctx context.Context, operation *secretservice.ScheduledOperation) error {
+	switch operation.Kind {
+	case secretservice.OperationArchiveRelease:
+		return r.backend.ArchiveRelease(ctx, operation.ScopeName, operation.ReleaseID)
+	case secretservice.OperationCreateRelease:
+		release, err := r.createRelease(ctx, operation.ScopeName)
+		if err != nil {
+			return err
+		}
+		operation.ReleaseID = release.ID
+		return nil
+	default:
+		return errors.Errorf("unsupported operation kind %q", operation.Kind)
+	}
+}
+
+// createRelease creates a Release from the current workspace, unless the
+// workspace has not changed since the newest live Release. This makes it safe
+// to repeat the operation if recording its outcome has failed.
+func (r *Runner) createRelease(ctx context.Context, scopeName string) (*secretservice.Release, error) {
+	scope, err := r.backend.Scope(ctx, scopeName)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve scope")
+	}
+
+	if scope.RequiredApprovals > 0 {
+		return nil, errors.Errorf("releases in scope %q require approval", scopeName)
+	}
+
+	variables, err := r.backend.ListVariables(ctx, fmt.Sprintf("workspace/%s", scopeName))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list variables")
+	}
+
+	latest, err := secretservice.NewestLiveRelease(ctx, r.backend, scopeName)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find the newest live release")
+	}
+
+	// The stored hash of the Release may have been computed with a previous
+	// key, so it is computed again.
+	if latest != nil && r.backend.ContentHash(latest.Variables) == r.backend.ContentHash(variables) {
+		return latest, nil
+	}
+
+	release, err := r.backend.CreateRelease(ctx, scopeName, variables, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create a release")
+	}
+
+	return release, nil
+}
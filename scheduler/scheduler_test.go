@@ -0,0 +1,280 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/marcinwyszynski/ssmvars"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var now = time.Date(2018, 11, 10, 2, 0, 0, 0, time.UTC)
+
+type runnerTestSuite struct {
+	suite.Suite
+
+	backend *mockBackend
+	ctx     context.Context
+
+	sut *Runner
+}
+
+func (r *runnerTestSuite) SetupTest() {
+	r.backend = new(mockBackend)
+	r.ctx = context.Background()
+	r.sut = New(r.backend)
+}
+
+func (r *runnerTestSuite) TestRun_Archive() {
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationArchiveRelease, "releaseID", now))
+	r.withStart("due", nil)
+	r.backend.On("ArchiveRelease", r.ctx, "scopeName", "releaseID").Return(nil)
+	r.withUpdate("due", secretservice.OperationDone, "releaseID", "")
+
+	r.NoError(r.sut.Run(r.ctx, now))
+	r.backend.AssertExpectations(r.T())
+}
+
+func (r *runnerTestSuite) TestRun_SkipsNotDue() {
+	future := r.operation("future", secretservice.OperationArchiveRelease, "releaseID", now.Add(time.Minute))
+	done := r.operation("done", secretservice.OperationArchiveRelease, "releaseID", now)
+	done.Status = secretservice.OperationDone
+	cancelled := r.operation("cancelled", secretservice.OperationCreateRelease, "", now)
+	cancelled.Status = secretservice.OperationCancelled
+
+	r.withScopes(nil)
+	r.withOperations(nil, future, done, cancelled)
+
+	r.NoError(r.sut.Run(r.ctx, now))
+	r.backend.AssertNotCalled(r.T(), "ArchiveRelease", r.ctx, "scopeName", "releaseID")
+	r.backend.AssertNotCalled(r.T(), "UpdateScheduledOperation", r.ctx, mock.Anything)
+}
+
+func (r *runnerTestSuite) TestRun_CreateRelease() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "new"}
+
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationCreateRelease, "", now))
+	r.withStart("due", nil)
+	r.withScope(0)
+	r.backend.On("ListVariables", r.ctx, "workspace/scopeName").Return([]*ssmvars.Variable{variable}, nil)
+	r.backend.On("ListReleases", r.ctx, "scopeName", (*string)(nil)).Return([]string{"oldID"}, nil)
+	r.backend.
+		On("GetRelease", r.ctx, "scopeName", "oldID").
+		Return(&secretservice.Release{ID: "oldID", Live: true, ContentHash: "stale"}, nil)
+	r.backend.
+		On("CreateRelease", r.ctx, "scopeName", []*ssmvars.Variable{variable}, "").
+		Return(&secretservice.Release{ID: "newID"}, nil)
+	r.withUpdate("due", secretservice.OperationDone, "newID", "")
+
+	r.NoError(r.sut.Run(r.ctx, now))
+	r.backend.AssertExpectations(r.T())
+}
+
+func (r *runnerTestSuite) TestRun_CreateReleaseUnchanged() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationCreateRelease, "", now))
+	r.withStart("due", nil)
+	r.withScope(0)
+	r.backend.On("ListVariables", r.ctx, "workspace/scopeName").Return([]*ssmvars.Variable{variable}, nil)
+	r.backend.On("ListReleases", r.ctx, "scopeName", (*string)(nil)).Return([]string{"oldID"}, nil)
+	r.backend.On("GetRelease", r.ctx, "scopeName", "oldID").Return(&secretservice.Release{
+		ID:          "oldID",
+		Live:        true,
+		ContentHash: "computed with a previous key",
+		Variables:   []*ssmvars.Variable{variable},
+	}, nil)
+	r.withUpdate("due", secretservice.OperationDone, "oldID", "")
+
+	r.NoError(r.sut.Run(r.ctx, now))
+	r.backend.AssertNotCalled(r.T(), "CreateRelease", r.ctx, "scopeName", []*ssmvars.Variable{variable}, "")
+}
+
+func (r *runnerTestSuite) TestRun_CreateReleaseArchived() {
+	variable := &ssmvars.Variable{Name: "VARIABLE", Value: "value"}
+
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationCreateRelease, "", now))
+	r.withStart("due", nil)
+	r.withScope(0)
+	r.backend.On("ListVariables", r.ctx, "workspace/scopeName").Return([]*ssmvars.Variable{variable}, nil)
+	r.backend.On("ListReleases", r.ctx, "scopeName", (*string)(nil)).Return([]string{"oldID"}, nil)
+	r.backend.On("ListReleases", r.ctx, "scopeName", aws.String("oldID")).Return([]string(nil), nil)
+	r.backend.On("GetRelease", r.ctx, "scopeName", "oldID").Return(&secretservice.Release{
+		ID:          "oldID",
+		ContentHash: secretservice.ContentHash(nil, []*ssmvars.Variable{variable}),
+	}, nil)
+	r.backend.
+		On("CreateRelease", r.ctx, "scopeName", []*ssmvars.Variable{variable}, "").
+		Return(&secretservice.Release{ID: "newID"}, nil)
+	r.withUpdate("due", secretservice.OperationDone, "newID", "")
+
+	r.NoError(r.sut.Run(r.ctx, now))
+	r.backend.AssertExpectations(r.T())
+}
+
+func (r *runnerTestSuite) TestRun_CreateReleaseRequiresApproval() {
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationCreateRelease, "", now))
+	r.withStart("due", nil)
+	r.withScope(2)
+	r.withUpdate("due", secretservice.OperationFailed, "", `releases in scope "scopeName" require approval`)
+
+	r.NoError(r.sut.Run(r.ctx, now))
+	r.backend.AssertExpectations(r.T())
+}
+
+func (r *runnerTestSuite) TestRun_OperationFailure() {
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationArchiveRelease, "releaseID", now))
+	r.withStart("due", nil)
+	r.backend.On("ArchiveRelease", r.ctx, "scopeName", "releaseID").Return(errors.New("bacon"))
+	r.withUpdate("due", secretservice.OperationFailed, "releaseID", "bacon")
+
+	r.NoError(r.sut.Run(r.ctx, now))
+	r.backend.AssertExpectations(r.T())
+}
+
+func (r *runnerTestSuite) TestRun_UpdateFailure() {
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationArchiveRelease, "releaseID", now))
+	r.withStart("due", nil)
+	r.backend.On("ArchiveRelease", r.ctx, "scopeName", "releaseID").Return(nil)
+	r.backend.On("UpdateScheduledOperation", r.ctx, r.withStatus("due", secretservice.OperationDone)).Return(errors.New("bacon"))
+
+	r.EqualError(
+		r.sut.Run(r.ctx, now),
+		`could not run operations for scope "scopeName": could not record outcome of operation due: bacon`,
+	)
+}
+
+func (r *runnerTestSuite) TestRun_AlreadyStarted() {
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationArchiveRelease, "releaseID", now))
+	r.withStart("due", secretservice.Conflict("bacon"))
+
+	r.NoError(r.sut.Run(r.ctx, now))
+	r.backend.AssertNotCalled(r.T(), "ArchiveRelease", r.ctx, "scopeName", "releaseID")
+	r.backend.AssertNumberOfCalls(r.T(), "UpdateScheduledOperation", 1)
+}
+
+func (r *runnerTestSuite) TestRun_StartFailure() {
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationArchiveRelease, "releaseID", now))
+	r.withStart("due", errors.New("bacon"))
+
+	r.EqualError(
+		r.sut.Run(r.ctx, now),
+		`could not run operations for scope "scopeName": could not start operation due: bacon`,
+	)
+	r.backend.AssertNotCalled(r.T(), "ArchiveRelease", r.ctx, "scopeName", "releaseID")
+}
+
+func (r *runnerTestSuite) TestRun_ListScopesFailure() {
+	r.withScopes(errors.New("bacon"))
+
+	r.EqualError(r.sut.Run(r.ctx, now), "could not list scopes: bacon")
+}
+
+func (r *runnerTestSuite) TestRun_ListOperationsFailure() {
+	r.withScopes(nil)
+	r.withOperations(errors.New("bacon"))
+
+	r.EqualError(
+		r.sut.Run(r.ctx, now),
+		`could not run operations for scope "scopeName": could not list pending operations: bacon`,
+	)
+}
+
+func (r *runnerTestSuite) TestRun_ContinuesAfterScopeFailure() {
+	r.backend.
+		On("ListVariables", r.ctx, "scopes").
+		Return([]*ssmvars.Variable{{Name: "broken"}, {Name: "scopeName"}, {Name: "unreadable"}}, nil)
+	r.backend.On("ListPendingOperations", r.ctx, "broken").Return([]*secretservice.ScheduledOperation(nil), errors.New("bacon"))
+	r.withOperations(nil, r.operation("due", secretservice.OperationArchiveRelease, "releaseID", now))
+	r.backend.On("ListPendingOperations", r.ctx, "unreadable").Return([]*secretservice.ScheduledOperation(nil), errors.New("ham"))
+	r.withStart("due", nil)
+	r.backend.On("ArchiveRelease", r.ctx, "scopeName", "releaseID").Return(nil)
+	r.withUpdate("due", secretservice.OperationDone, "releaseID", "")
+
+	r.EqualError(
+		r.sut.Run(r.ctx, now),
+		`could not run operations for scope "broken": could not list pending operations: bacon; `+
+			`could not run operations for scope "unreadable": could not list pending operations: ham`,
+	)
+	r.backend.AssertExpectations(r.T())
+}
+
+func (r *runnerTestSuite) TestHandle() {
+	r.withScopes(nil)
+	r.withOperations(nil, r.operation("due", secretservice.OperationArchiveRelease, "releaseID", now))
+	r.withStart("due", nil)
+	r.backend.On("ArchiveRelease", r.ctx, "scopeName", "releaseID").Return(nil)
+	r.withUpdate("due", secretservice.OperationDone, "releaseID", "")
+
+	r.NoError(r.sut.Handle(r.ctx, events.CloudWatchEvent{Time: now}))
+	r.backend.AssertExpectations(r.T())
+}
+
+func (r *runnerTestSuite) operation(id, kind, releaseID string, runAt time.Time) *secretservice.ScheduledOperation {
+	return &secretservice.ScheduledOperation{
+		ID:        id,
+		ScopeName: "scopeName",
+		Kind:      kind,
+		ReleaseID: releaseID,
+		RunAt:     runAt,
+		Status:    secretservice.OperationPending,
+	}
+}
+
+func (r *runnerTestSuite) withScopes(err error) {
+	r.backend.
+		On("ListVariables", r.ctx, "scopes").
+		Return([]*ssmvars.Variable{{Name: "scopeName"}}, err)
+}
+
+func (r *runnerTestSuite) withScope(requiredApprovals int) {
+	r.backend.
+		On("Scope", r.ctx, "scopeName").
+		Return(&secretservice.Scope{Name: "scopeName", RequiredApprovals: requiredApprovals}, nil)
+}
+
+func (r *runnerTestSuite) withOperations(err error, operations ...*secretservice.ScheduledOperation) {
+	r.backend.On("ListPendingOperations", r.ctx, "scopeName").Return(operations, err)
+}
+
+func (r *runnerTestSuite) withStart(id string, err error) {
+	r.backend.On("UpdateScheduledOperation", r.ctx, r.withStatus(id, secretservice.OperationRunning)).Return(err).Once()
+}
+
+func (r *runnerTestSuite) withUpdate(id, status, releaseID, message string) {
+	r.backend.On(
+		"UpdateScheduledOperation",
+		r.ctx,
+		mock.MatchedBy(func(operation *secretservice.ScheduledOperation) bool {
+			return operation.ID == id &&
+				operation.Status == status &&
+				operation.ReleaseID == releaseID &&
+				operation.Error == message
+		}),
+	).Return(nil)
+}
+
+func (r *runnerTestSuite) withStatus(id, status string) interface{} {
+	return mock.MatchedBy(func(operation *secretservice.ScheduledOperation) bool {
+		return operation.ID == id && operation.Status == status
+	})
+}
+
+func TestRunner(t *testing.T) {
+	suite.Run(t, new(runnerTestSuite))
+}
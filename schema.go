@@ -1,30 +1,150 @@
 package secretservice
 
-// Schema is the GraphQL schema.
+// Schema is the GraphQL schema. Errors carry one of the Code constants as their
+// "code" extension, and responses report the estimated depth and cost of the
+// operation as the "cost" extension.
 const Schema = `
 schema {
   query: Query
   mutation: Mutation
 }
 
+# Duration is a span of time written using Go duration syntax, e.g. "720h".
+scalar Duration
+
+# Time is an RFC 3339 timestamp.
+scalar Time
+
 type Query {
   # workspace returns the current workspace for a particular Scope.
   scope(scopeId: ID!): Scope!
+
+  # compare returns the difference between two sets of Variables, each being
+  # either a Release or the current workspace of any Scope. Changes are
+  # reported from "left" to "right".
+  compare(left: DiffTarget!, right: DiffTarget!): Diff!
+
+  # previewRelease shows what "createRelease" would produce from the current
+  # workspace, without creating a Release.
+  previewRelease(scopeId: ID!): ReleasePreview!
+
+  # scheduledOperations lists all operations scheduled for a Scope, including
+  # the ones which have already been executed or cancelled.
+  scheduledOperations(scopeId: ID!): [ScheduledOperation!]!
+
+  # proposals lists all Proposals for a Scope, both RELEASE and CHANGE ones,
+  # including the ones which have already been approved or rejected.
+  proposals(scopeId: ID!): [Proposal!]!
 }
 
+# Mutations creating resources accept a "clientMutationId", which works like
+# the "Idempotency-Key" header: a retried mutation with the same value gets
+# the response of the original one instead of being executed again.
 type Mutation {
   # createScope creates a new configuration scope with a given name, using the
-  # provided KMS key for encryption.
-  createScope(name: String!, kmsKeyId: String!): Scope!
+  # provided KMS key for encryption. If "requiredApprovals" is positive,
+  # releases in the Scope can only be created through approved Proposals.
+  createScope(
+    name: String!,
+    kmsKeyId: String!,
+    requiredApprovals: Int,
+    clientMutationId: String
+  ): Scope!
+
+  # configureScope changes settings of an existing Scope. Settings which are
+  # not provided are left intact. Settings can only be changed directly by
+  # callers with the admin role, everyone else has to use "requestChange".
+  configureScope(scopeId: ID!, settings: ScopeSettings!): Scope!
 
   # addVariable adds or changes a Variable in the current workspace.
   addVariable(scopeId: ID!, variable: VariableInput!): Variable!
 
+  # generateVariable adds or changes a write-only Variable in the current
+  # workspace, with the value generated server-side according to the spec. The
+  # generated value is never returned. Regenerating a Variable keeps its expiry
+  # and rotation settings, and restarts its rotation period.
+  generateVariable(scopeId: ID!, name: String!, spec: SecretSpec!): Variable!
+
+  # importVariables parses a flat document and imports its content into the
+  # current workspace. In MERGE mode Variables missing from the document are
+  # kept, in REPLACE mode they are removed. Variables listed in "writeOnly"
+  # are imported as write-only, and existing write-only Variables stay
+  # write-only. With "dryRun" set the workspace is not changed. Returns the
+  # difference between the workspace before and after the import.
+  importVariables(
+    scopeId: ID!,
+    format: ImportFormat!,
+    document: String!,
+    mode: ImportMode!,
+    writeOnly: [String!],
+    dryRun: Boolean
+  ): Diff!
+
+  # applyChanges sets and removes multiple Variables in the current workspace.
+  # All changes are validated before any of them is applied. If
+  # "expectedRevision" is provided, it must match the current revision of the
+  # workspace. Returns the difference between the workspace before and after
+  # the change.
+  applyChanges(
+    scopeId: ID!,
+    set: [VariableInput!],
+    remove: [ID!],
+    expectedRevision: String
+  ): Diff!
+
   # removeVariable removes a Variable from the current workspace.
   removeVariable(scopeId: ID!, id: ID!): Variable!
 
   # createRelease takes a snapshot of the current workspace to create a Release.
-  createRelease(scopeId: ID!): Release!
+  # If "ifChanged" is set and the workspace is identical to the most recent
+  # Release, that Release is returned instead of creating a new one.
+  #
+  # If "include" is set, only the listed workspace Variables are applied on top
+  # of "baseRelease", or the newest live Release if it is not provided. The
+  # rest of the workspace is left out of the Release.
+  createRelease(
+    scopeId: ID!,
+    ifChanged: Boolean,
+    include: [ID!],
+    baseRelease: ID,
+    clientMutationId: String
+  ): Release!
+
+  # requestRelease proposes a Release with the current content of the
+  # workspace. The Release is created once the Proposal gets the number of
+  # approvals required by the Scope, right away if none are required.
+  requestRelease(
+    scopeId: ID!,
+    message: String!,
+    clientMutationId: String
+  ): Proposal!
+
+  # approveRelease approves a pending Proposal. Proposals can not be approved
+  # by their requester.
+  approveRelease(scopeId: ID!, id: ID!): Proposal!
+
+  # rejectRelease rejects a pending Proposal.
+  rejectRelease(scopeId: ID!, id: ID!): Proposal!
+
+  # requestChange proposes changes to the workspace and the settings of the
+  # Scope, which is the only way of making them without the admin role. The
+  # changes are applied once the Proposal gets the number of approvals
+  # required by the Scope before the changes, but at least one.
+  requestChange(
+    scopeId: ID!,
+    message: String!,
+    set: [VariableInput!],
+    remove: [ID!],
+    settings: ScopeSettings,
+    clientMutationId: String
+  ): Proposal!
+
+  # approveChange approves a pending CHANGE Proposal. Proposals can not be
+  # approved by their requester.
+  approveChange(scopeId: ID!, id: ID!): Proposal!
+
+  # rejectChange rejects a pending CHANGE Proposal.
+  rejectChange(scopeId: ID!, id: ID!): Proposal!
 
   # archiveRelease archives a Release. Archived releases should no longer be
   # available for anything other than historical purposes. This is an
@@ -32,11 +152,45 @@ type Mutation {
   # of an archived Release back in the workspace.
   archiveRelease(scopeId: ID!, releaseId: ID!): Release!
 
+  # scheduleOperation schedules creating or archiving a Release at a given
+  # time in the future. The "releaseId" is required to archive a Release. A
+  # scheduled Release is not created if the workspace has not changed since
+  # the most recent Release.
+  scheduleOperation(
+    scopeId: ID!,
+    kind: OperationKind!,
+    runAt: Time!,
+    releaseId: ID,
+    clientMutationId: String
+  ): ScheduledOperation!
+
+  # cancelScheduledOperation cancels an operation which has not been executed
+  # yet.
+  cancelScheduledOperation(scopeId: ID!, id: ID!): ScheduledOperation!
+
+  # merge performs a three-way merge, applying changes made between the "base"
+  # and "incoming" Releases to the current workspace. Variables changed
+  # differently in the workspace and in the incoming Release are reported as
+  # conflicts and resolved according to the strategy. With FAIL_ON_CONFLICT
+  # the workspace is not changed if there are any conflicts.
+  merge(
+    scopeId: ID!,
+    base: ID!,
+    incoming: ID!,
+    strategy: MergeStrategy!
+  ): MergeResult!
+
   # reset replaces the content of the current workspace with the content of
   # the Release.
   reset(scopeId: ID!, releaseId: ID!): Scope!
 }
 
+# Approval is a single approval of a Proposal.
+type Approval {
+  approvedAt: Time!
+  principal: String!
+}
+
 # Change represents a difference between two versions of the same single
 # variable.
 type Change {
@@ -44,21 +198,213 @@ type Change {
   after: Variable!
 }
 
+# Conflict represents a Variable changed differently in the workspace and in
+# the incoming Release during a merge. Missing Variables are null.
+type Conflict {
+  id: ID!
+  base: Variable
+  ours: Variable
+  theirs: Variable
+}
+
 # Diff represents a difference between two Releases, or between the current
-# workspace and a Release.
+# workspace and a Release. All lists are sorted by Variable name.
 type Diff {
   added: [Variable!]!
   changed: [Change!]!
   deleted: [Variable!]!
+
+  # patch is a JSON Patch (RFC 6902) document transforming an object keyed by
+  # Variable name, with {"value", "writeOnly"} objects as values. Values of
+  # write-only Variables are omitted.
+  patch: String!
+
+  summary: DiffSummary!
+}
+
+# DiffSummary holds the number of Variables in each section of a Diff.
+type DiffSummary {
+  added: Int!
+  changed: Int!
+  deleted: Int!
+}
+
+# DiffTarget points at a Release of a Scope or, if "releaseId" is not set, at
+# its current workspace.
+input DiffTarget {
+  scopeId: ID!
+  releaseId: ID
+}
+
+# ImportFormat is the format of a document passed to "importVariables".
+enum ImportFormat {
+  DOTENV
+  JSON
+  YAML
+}
+
+# ImportMode decides what happens to Variables not present in an imported
+# document.
+enum ImportMode {
+  MERGE
+  REPLACE
+}
+
+# MergeResult is the outcome of a three-way merge.
+type MergeResult {
+  # applied is the difference between the workspace before and after the
+  # merge.
+  applied: Diff!
+  conflicts: [Conflict!]!
+  scope: Scope!
+}
+
+# MergeStrategy decides how conflicts are resolved during a merge.
+enum MergeStrategy {
+  OURS
+  THEIRS
+  FAIL_ON_CONFLICT
+}
+
+# OperationKind is the kind of a scheduled operation.
+enum OperationKind {
+  ARCHIVE_RELEASE
+  CREATE_RELEASE
+}
+
+# OperationStatus is the status of a scheduled operation. Operations are
+# RUNNING while they are being executed.
+enum OperationStatus {
+  CANCELLED
+  DONE
+  FAILED
+  PENDING
+  RUNNING
+}
+
+# Proposal is a Release or a workspace change waiting for approval. Variables
+# of RELEASE Proposals are frozen at the time of the request.
+type Proposal {
+  id: ID!
+  approvals: [Approval!]!
+
+  # baseRelease is the newest live Release at the time of the request.
+  baseRelease: Release
+
+  # diff is the difference between the base Release and the Proposal. For
+  # CHANGE Proposals it is the difference the changes make to the current
+  # workspace.
+  diff: Diff!
+
+  kind: ProposalKind!
+  message: String!
+  rejectedBy: String
+
+  # release is the Release created once the Proposal is APPROVED.
+  release: Release
+
+  # remove lists the Variables a CHANGE Proposal removes.
+  remove: [ID!]!
+
+  requestedAt: Time!
+  requestedBy: String!
+
+  # settings are the changes a CHANGE Proposal makes to the settings of the
+  # Scope, if any.
+  settings: ProposedSettings
+
+  status: ProposalStatus!
+
+  # variables are the content of a RELEASE Proposal, or the Variables set by
+  # a CHANGE Proposal.
+  variables: [Variable!]!
+}
+
+# ProposedSettings are changes to the settings of a Scope. Settings which are
+# left intact are null.
+type ProposedSettings {
+  protected: Boolean
+  requiredApprovals: Int
+}
+
+# ProposalKind is the kind of a Proposal.
+enum ProposalKind {
+  CHANGE
+  RELEASE
+}
+
+# ProposalStatus is the status of a Proposal.
+enum ProposalStatus {
+  APPROVED
+  PENDING
+  REJECTED
 }
 
 # Release is the snapshot of the configuration associated with a given Scope.
 type Release {
   id: ID!
+
+  # baseRelease is the Release this one was derived from, if it was created
+  # from a subset of workspace Variables.
+  baseRelease: Release
+
+  # contentHash is a hex-encoded HMAC-SHA256 of the Release variables, keyed
+  # with a server-side secret if the service has one. It does not depend on
+  # their order. It is computed when the Release is created, and does not
+  # change when the secret is rotated.
+  contentHash: String!
+
   diff(since: ID!): Diff!
   live: Boolean!
+
+  # render serializes the Release in a given format. Write-only Variables are
+  # skipped unless "privileged" is set, which requires the admin role.
+  render(format: RenderFormat!, privileged: Boolean): String!
+
   timestamp: Int!
   variables: [Variable!]!
+
+  # verified is true if the Release has a valid signature. If the service signs
+  # Releases, ones with a missing or invalid signature can not be retrieved.
+  verified: Boolean!
+
+  # warnings lists problems detected while creating the Release, such as
+  # expired values. It is only populated by "createRelease".
+  warnings: [String!]!
+}
+
+# ReleasePreview is what a Release created from the current workspace would
+# look like.
+type ReleasePreview {
+  # base is the newest live Release, if any.
+  base: Release
+
+  contentHash: String!
+
+  # diff is the difference between the base Release and the workspace.
+  diff: Diff!
+
+  # errors lists problems which make the Variables unsafe to release, such as
+  # invalid names.
+  errors: [String!]!
+
+  variables: [Variable!]!
+
+  # warnings lists suspicious things, like empty or expired values and
+  # Variables which would be removed.
+  warnings: [String!]!
+}
+
+# RenderFormat is the output format of "Release.render".
+enum RenderFormat {
+  DOTENV
+  JSON
+  YAML
+  TOML
+  SHELL_EXPORT
+  JAVA_PROPERTIES
+  K8S_SECRET
+  SYSTEMD_ENV
 }
 
 # Scope is a particular configuration scope. Configuration is available on
@@ -66,8 +412,17 @@ type Release {
 type Scope {
   id: ID!
   diff(since: ID!): Diff!
+
+  # expiringVariables returns workspace Variables which have expired or are
+  # due for rotation within the given Duration, sorted by deadline.
+  expiringVariables(within: Duration!): [Variable!]!
+
   kmsKeyId: String!
 
+  # protected Scopes only allow direct workspace changes to callers with the
+  # admin role. Everyone else has to use "requestChange".
+  protected: Boolean!
+
   # release returns a single release from a particular Scope.
   release(id: ID!): Release!
 
@@ -76,19 +431,78 @@ type Scope {
   # can be used for pagination.
   releases(before: ID): [Release!]!
 
+  # requiredApprovals is the number of approvals a Proposal needs to become a
+  # Release.
+  requiredApprovals: Int!
+
+  # revision is the content hash of the current workspace. It changes every
+  # time a Variable in the workspace is changed.
+  revision: String!
+
   variables: [Variable!]!
 }
 
+# ScheduledOperation is a Release operation executed at a later time.
+type ScheduledOperation {
+  id: ID!
+
+  # error explains why a FAILED operation could not be executed.
+  error: String
+
+  kind: OperationKind!
+
+  # release is the Release to archive, or the Release created by the
+  # operation once it is DONE.
+  release: Release
+
+  runAt: Time!
+  status: OperationStatus!
+}
+
+# ScopeSettings are the settings of a Scope.
+input ScopeSettings {
+  protected: Boolean
+  requiredApprovals: Int
+}
+
+# SecretKind is the kind of value generated by "generateVariable".
+enum SecretKind {
+  PASSWORD
+  HEX
+  BASE64
+  UUID
+  RSA_KEY
+  ED25519_KEY
+}
+
+# SecretSpec describes a value to be generated server-side. "length" is the
+# number of characters for PASSWORD, the number of random bytes for HEX and
+# BASE64, and the key size in bits for RSA_KEY. "charset" only applies to
+# PASSWORD.
+input SecretSpec {
+  kind: SecretKind!
+  length: Int
+  charset: String
+}
+
 # Variable is a single element of the configuration.
 type Variable {
   id: ID!
   value: String
   writeOnly: Boolean!
+
+  # expiresAt, rotateEvery and updatedAt are only available for Variables in
+  # the current workspace.
+  expiresAt: Time
+  rotateEvery: Duration
+  updatedAt: Time
 }
 
 input VariableInput {
   name: String!
   value: String!
   writeOnly: Boolean!
+  expiresAt: Time
+  rotateEvery: Duration
 }
 `
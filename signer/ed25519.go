@@ -0,0 +1,86 @@
+// Package signer provides implementations of secretservice.Signer used to sign
+// and verify Releases.
+package signer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidSignature is returned when a signature does not match the data.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Ed25519 signs data using a local ED25519 key.
+type Ed25519 struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// NewEd25519 returns an instance of an Ed25519 signer. If only the public
+// key is provided, the signer can only verify signatures.
+func NewEd25519(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) *Ed25519 {
+	if privateKey != nil {
+		publicKey = privateKey.Public().(ed25519.PublicKey)
+	}
+	return &Ed25519{privateKey: privateKey, publicKey: publicKey}
+}
+
+// LoadEd25519 reads a PEM-encoded PKCS #8 private key or PKIX public key from
+// a file, and returns an Ed25519 signer using it.
+func LoadEd25519(path string) (*Ed25519, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read key file")
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("could not find PEM data in key file")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse private key")
+		}
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.Errorf("expected an ED25519 private key, got %T", key)
+		}
+		return NewEd25519(privateKey, nil), nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse public key")
+		}
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.Errorf("expected an ED25519 public key, got %T", key)
+		}
+		return NewEd25519(nil, publicKey), nil
+	default:
+		return nil, errors.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+// Sign signs the data with the private key.
+func (e *Ed25519) Sign(_ context.Context, data []byte) ([]byte, error) {
+	if e.privateKey == nil {
+		return nil, errors.New("no private key available for signing")
+	}
+	return ed25519.Sign(e.privateKey, data), nil
+}
+
+// Verify checks the signature with the public key.
+func (e *Ed25519) Verify(_ context.Context, data, signature []byte) error {
+	if !ed25519.Verify(e.publicKey, data, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package signer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ed25519TestSuite struct {
+	suite.Suite
+
+	ctx        context.Context
+	dir        string
+	privateKey ed25519.PrivateKey
+}
+
+func (e *ed25519TestSuite) SetupTest() {
+	var err error
+
+	e.ctx = context.Background()
+
+	_, e.privateKey, err = ed25519.GenerateKey(nil)
+	e.Require().NoError(err)
+
+	e.dir, err = ioutil.TempDir("", "signer")
+	e.Require().NoError(err)
+}
+
+func (e *ed25519TestSuite) TearDownTest() {
+	os.RemoveAll(e.dir)
+}
+
+func (e *ed25519TestSuite) TestSignVerify() {
+	sut := NewEd25519(e.privateKey, nil)
+
+	signature, err := sut.Sign(e.ctx, []byte("bacon"))
+	e.NoError(err)
+
+	e.NoError(sut.Verify(e.ctx, []byte("bacon"), signature))
+	e.Equal(ErrInvalidSignature, sut.Verify(e.ctx, []byte("ham"), signature))
+}
+
+func (e *ed25519TestSuite) TestLoad_PrivateKey() {
+	der, err := x509.MarshalPKCS8PrivateKey(e.privateKey)
+	e.Require().NoError(err)
+
+	sut, err := LoadEd25519(e.writeKey("PRIVATE KEY", der))
+	e.Require().NoError(err)
+
+	signature, err := sut.Sign(e.ctx, []byte("bacon"))
+	e.NoError(err)
+	e.True(ed25519.Verify(e.privateKey.Public().(ed25519.PublicKey), []byte("bacon"), signature))
+}
+
+func (e *ed25519TestSuite) TestLoad_PublicKey() {
+	der, err := x509.MarshalPKIXPublicKey(e.privateKey.Public())
+	e.Require().NoError(err)
+
+	sut, err := LoadEd25519(e.writeKey("PUBLIC KEY", der))
+	e.Require().NoError(err)
+
+	e.NoError(sut.Verify(e.ctx, []byte("bacon"), ed25519.Sign(e.privateKey, []byte("bacon"))))
+
+	signature, err := sut.Sign(e.ctx, []byte("bacon"))
+	e.Nil(signature)
+	e.EqualError(err, "no private key available for signing")
+}
+
+func (e *ed25519TestSuite) TestLoad_NotPEM() {
+	path := filepath.Join(e.dir, "key.pem")
+	e.Require().NoError(ioutil.WriteFile(path, []byte("bacon"), 0600))
+
+	sut, err := LoadEd25519(path)
+
+	e.Nil(sut)
+	e.EqualError(err, "could not find PEM data in key file")
+}
+
+func (e *ed25519TestSuite) TestLoad_UnsupportedType() {
+	sut, err := LoadEd25519(e.writeKey("CERTIFICATE", []byte("bacon")))
+
+	e.Nil(sut)
+	e.EqualError(err, `unsupported PEM block type "CERTIFICATE"`)
+}
+
+func (e *ed25519TestSuite) writeKey(blockType string, der []byte) string {
+	path := filepath.Join(e.dir, "key.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	e.Require().NoError(ioutil.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestEd25519(t *testing.T) {
+	suite.Run(t, new(ed25519TestSuite))
+}
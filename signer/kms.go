@@ -0,0 +1,72 @@
+package signer
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/pkg/errors"
+)
+
+// KMS signs data using an asymmetric AWS KMS key. Data is hashed locally with
+// SHA-256, so the signing algorithm must use SHA-256 as well.
+type KMS struct {
+	kms       kmsiface.KMSAPI
+	keyID     *string
+	algorithm *string
+}
+
+// NewKMS returns an instance of a KMS signer using a given key and signing
+// algorithm (e.g. ECDSA_SHA_256 or RSASSA_PSS_SHA_256).
+func NewKMS(kms kmsiface.KMSAPI, keyID, algorithm string) *KMS {
+	return &KMS{
+		kms:       kms,
+		keyID:     aws.String(keyID),
+		algorithm: aws.String(algorithm),
+	}
+}
+
+// Sign signs the SHA-256 digest of the data.
+func (k *KMS) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+
+	output, err := k.kms.SignWithContext(ctx, &kms.SignInput{
+		KeyId:            k.keyID,
+		Message:          digest[:],
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: k.algorithm,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not sign with KMS")
+	}
+
+	return output.Signature, nil
+}
+
+// Verify checks the signature of the SHA-256 digest of the data.
+func (k *KMS) Verify(ctx context.Context, data, signature []byte) error {
+	digest := sha256.Sum256(data)
+
+	output, err := k.kms.VerifyWithContext(ctx, &kms.VerifyInput{
+		KeyId:            k.keyID,
+		Message:          digest[:],
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		Signature:        signature,
+		SigningAlgorithm: k.algorithm,
+	})
+
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == kms.ErrCodeKMSInvalidSignatureException {
+		return ErrInvalidSignature
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not verify with KMS")
+	}
+	if !aws.BoolValue(output.SignatureValid) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type mockKMS struct {
+	mock.Mock
+	kmsiface.KMSAPI
+}
+
+func (m *mockKMS) SignWithContext(ctx aws.Context, input *kms.SignInput, opts ...request.Option) (*kms.SignOutput, error) {
+	args := m.Called(ctx, input, opts)
+	return args.Get(0).(*kms.SignOutput), args.Error(1)
+}
+
+func (m *mockKMS) VerifyWithContext(ctx aws.Context, input *kms.VerifyInput, opts ...request.Option) (*kms.VerifyOutput, error) {
+	args := m.Called(ctx, input, opts)
+	return args.Get(0).(*kms.VerifyOutput), args.Error(1)
+}
+
+type kmsTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	kms *mockKMS
+
+	sut *KMS
+}
+
+func (k *kmsTestSuite) SetupTest() {
+	k.ctx = context.Background()
+	k.kms = new(mockKMS)
+	k.sut = NewKMS(k.kms, "keyID", "ECDSA_SHA_256")
+}
+
+func (k *kmsTestSuite) TestSign_OK() {
+	k.withSign(&kms.SignOutput{Signature: []byte("signature")}, nil)
+
+	ret, err := k.sut.Sign(k.ctx, []byte("bacon"))
+
+	k.NoError(err)
+	k.Equal([]byte("signature"), ret)
+}
+
+func (k *kmsTestSuite) TestSign_Failure() {
+	k.withSign((*kms.SignOutput)(nil), errors.New("bacon"))
+
+	ret, err := k.sut.Sign(k.ctx, []byte("bacon"))
+
+	k.Nil(ret)
+	k.EqualError(err, "could not sign with KMS: bacon")
+}
+
+func (k *kmsTestSuite) TestVerify_OK() {
+	k.withVerify(&kms.VerifyOutput{SignatureValid: aws.Bool(true)}, nil)
+
+	k.NoError(k.sut.Verify(k.ctx, []byte("bacon"), []byte("signature")))
+}
+
+func (k *kmsTestSuite) TestVerify_Invalid() {
+	k.withVerify(
+		(*kms.VerifyOutput)(nil),
+		awserr.New(kms.ErrCodeKMSInvalidSignatureException, "invalid", nil),
+	)
+
+	k.Equal(ErrInvalidSignature, k.sut.Verify(k.ctx, []byte("bacon"), []byte("signature")))
+}
+
+func (k *kmsTestSuite) TestVerify_Failure() {
+	k.withVerify((*kms.VerifyOutput)(nil), errors.New("bacon"))
+
+	k.EqualError(
+		k.sut.Verify(k.ctx, []byte("bacon"), []byte("signature")),
+		"could not verify with KMS: bacon",
+	)
+}
+
+func (k *kmsTestSuite) withSign(output *kms.SignOutput, err error) {
+	k.kms.On(
+		"SignWithContext",
+		k.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			input, ok := arg.(*kms.SignInput)
+			k.True(ok)
+
+			k.Equal("keyID", *input.KeyId)
+			k.Equal("DIGEST", *input.MessageType)
+			k.Equal("ECDSA_SHA_256", *input.SigningAlgorithm)
+			k.Len(input.Message, 32)
+
+			return true
+		}),
+		[]request.Option(nil),
+	).Return(output, err)
+}
+
+func (k *kmsTestSuite) withVerify(output *kms.VerifyOutput, err error) {
+	k.kms.On(
+		"VerifyWithContext",
+		k.ctx,
+		mock.MatchedBy(func(arg interface{}) bool {
+			input, ok := arg.(*kms.VerifyInput)
+			k.True(ok)
+
+			k.Equal("keyID", *input.KeyId)
+			k.Equal([]byte("signature"), input.Signature)
+			k.Len(input.Message, 32)
+
+			return true
+		}),
+		[]request.Option(nil),
+	).Return(output, err)
+}
+
+func TestKMS(t *testing.T) {
+	suite.Run(t, new(kmsTestSuite))
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AWS records a span for each call made by an AWS API client, covering all
+// of its retries.
+func AWS(c *client.Client) {
+	c.Handlers.Validate.PushFrontNamed(request.NamedHandler{Name: "tracing.StartAWS", Fn: startAWS})
+	c.Handlers.Complete.PushBackNamed(request.NamedHandler{Name: "tracing.EndAWS", Fn: endAWS})
+}
+
+func startAWS(r *request.Request) {
+	ctx, _ := Start(
+		r.Context(),
+		r.ClientInfo.ServiceName+"."+r.Operation.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "aws-api"),
+			attribute.String("rpc.service", r.ClientInfo.ServiceName),
+			attribute.String("rpc.method", r.Operation.Name),
+		),
+	)
+	r.SetContext(ctx)
+}
+
+func endAWS(r *request.Request) {
+	span := trace.SpanFromContext(r.Context())
+
+	if r.RequestID != "" {
+		span.SetAttributes(attribute.String("aws.request_id", r.RequestID))
+	}
+	if r.HTTPResponse != nil {
+		span.SetAttributes(attribute.Int("http.status_code", r.HTTPResponse.StatusCode))
+	}
+
+	End(span, r.Error)
+}
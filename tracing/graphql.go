@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/introspection"
+	gqltrace "github.com/graph-gophers/graphql-go/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GraphQLTracer records a span for each resolver which takes a context or
+// returns an error, as only those can call the Backend. Arguments are not
+// recorded, as they may contain secret values.
+type GraphQLTracer struct{}
+
+// TraceQuery does not record anything, as the Handler records a span for the
+// whole operation already.
+func (GraphQLTracer) TraceQuery(ctx context.Context, _ string, _ string, _ map[string]interface{}, _ map[string]*introspection.Type) (context.Context, gqltrace.TraceQueryFinishFunc) {
+	return ctx, func([]*errors.QueryError) {}
+}
+
+// TraceField records a span for a resolver, unless it is trivial.
+func (GraphQLTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, _ map[string]interface{}) (context.Context, gqltrace.TraceFieldFinishFunc) {
+	if trivial {
+		return ctx, func(*errors.QueryError) {}
+	}
+
+	ctx, span := Start(ctx, label, trace.WithAttributes(
+		attribute.String("graphql.type", typeName),
+		attribute.String("graphql.field", fieldName),
+	))
+
+	return ctx, func(queryErr *errors.QueryError) {
+		var err error
+		if queryErr != nil {
+			err = queryErr
+			if queryErr.ResolverError != nil {
+				err = queryErr.ResolverError
+			}
+		}
+		End(span, err)
+	}
+}
@@ -0,0 +1,107 @@
+// Package tracing records OpenTelemetry spans for GraphQL operations, the
+// resolvers they run and the AWS API calls those make, and propagates trace
+// context from incoming requests.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/marcinwyszynski/secretservice"
+
+// Exporters supported by Setup. Without an exporter, spans are not recorded,
+// but trace context is still propagated.
+const (
+	ExporterNone   = ""
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterXRay   = "xray"
+)
+
+// Setup installs the global tracer provider and propagator. The OTLP
+// exporter is configured with the standard OTEL_EXPORTER_OTLP_* variables.
+// X-Ray uses the OTLP exporter as well, expecting an AWS Distro for
+// OpenTelemetry collector to forward the spans, but generates X-Ray trace
+// IDs and propagates the "X-Amzn-Trace-Id" header.
+func Setup(ctx context.Context, exporter, serviceName string) error {
+	propagators := []propagation.TextMapPropagator{propagation.TraceContext{}, propagation.Baggage{}}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	}
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+
+	switch exporter {
+	case ExporterNone:
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagators...))
+		return nil
+	case ExporterOTLP:
+		spanExporter, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New()
+	case ExporterXRay:
+		spanExporter, err = otlptracegrpc.New(ctx)
+		propagators = append(propagators, xray.Propagator{})
+		opts = append(opts, sdktrace.WithIDGenerator(xray.NewIDGenerator()))
+	default:
+		return errors.Errorf("unsupported tracing exporter %q", exporter)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "could not create %s exporter", exporter)
+	}
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(append(opts, sdktrace.WithBatcher(spanExporter))...))
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagators...))
+	return nil
+}
+
+// Flush exports spans which have ended, so that they are not lost when
+// Lambda freezes the process after an invocation.
+func Flush(ctx context.Context) {
+	provider, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	if !ok {
+		return
+	}
+
+	if err := provider.ForceFlush(ctx); err != nil {
+		log.Errorf("Could not flush spans: %v", err)
+	}
+}
+
+// Start starts a span as a child of the span in the context, if any.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End ends a span, marking it as failed with the error code if the error is
+// not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, secretservice.ErrorCode(err))
+	}
+	span.End()
+}
+
+// Extract returns a context carrying the trace context from the headers of
+// an incoming request.
+func Extract(ctx context.Context, headers http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(headers))
+}
@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/marcinwyszynski/secretservice"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracingTestSuite struct {
+	suite.Suite
+
+	ctx      context.Context
+	recorder *tracetest.SpanRecorder
+
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+}
+
+func (t *tracingTestSuite) SetupTest() {
+	t.ctx = context.Background()
+	t.recorder = tracetest.NewSpanRecorder()
+
+	t.tracerProvider = otel.GetTracerProvider()
+	t.propagator = otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(t.recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+func (t *tracingTestSuite) TearDownTest() {
+	otel.SetTracerProvider(t.tracerProvider)
+	otel.SetTextMapPropagator(t.propagator)
+}
+
+func (t *tracingTestSuite) TestSetup_UnsupportedExporter() {
+	t.EqualError(Setup(t.ctx, "bacon", "secretservice"), `unsupported tracing exporter "bacon"`)
+}
+
+func (t *tracingTestSuite) TestStartEnd() {
+	ctx, parent := Start(t.ctx, "parent")
+	_, child := Start(ctx, "child")
+
+	End(child, secretservice.NotFound("bacon"))
+	End(parent, nil)
+
+	spans := t.recorder.Ended()
+	t.Require().Len(spans, 2)
+
+	t.Equal("child", spans[0].Name())
+	t.Equal(parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	t.Equal(sdktrace.Status{Code: codes.Error, Description: secretservice.CodeNotFound}, spans[0].Status())
+
+	t.Equal("parent", spans[1].Name())
+	t.Equal(codes.Unset, spans[1].Status().Code)
+}
+
+func (t *tracingTestSuite) TestExtract() {
+	headers := http.Header{}
+	headers.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx := Extract(t.ctx, headers)
+	_, span := Start(ctx, "span")
+	End(span, nil)
+
+	t.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	t.Equal("00f067aa0ba902b7", t.recorder.Ended()[0].Parent().SpanID().String())
+}
+
+func (t *tracingTestSuite) TestGraphQLTracer_TrivialField() {
+	ctx, finish := GraphQLTracer{}.TraceField(t.ctx, "Release.id", "Release", "id", true, nil)
+	finish(nil)
+
+	t.Equal(t.ctx, ctx)
+	t.Empty(t.recorder.Ended())
+}
+
+func (t *tracingTestSuite) TestGraphQLTracer_Field() {
+	_, finish := GraphQLTracer{}.TraceField(t.ctx, "Query.scope", "Query", "scope", false, map[string]interface{}{"scopeId": "bacon"})
+	finish(&gqlerrors.QueryError{Message: "bacon", ResolverError: secretservice.Forbidden("bacon")})
+
+	spans := t.recorder.Ended()
+	t.Require().Len(spans, 1)
+	t.Equal("Query.scope", spans[0].Name())
+	t.Equal(secretservice.CodeForbidden, spans[0].Status().Description)
+	t.Equal([]attribute.KeyValue{
+		attribute.String("graphql.type", "Query"),
+		attribute.String("graphql.field", "scope"),
+	}, spans[0].Attributes())
+}
+
+func (t *tracingTestSuite) TestAWS() {
+	c := new(client.Client)
+	AWS(c)
+
+	r := &request.Request{
+		ClientInfo:   metadata.ClientInfo{ServiceName: "s3"},
+		Operation:    &request.Operation{Name: "GetObject"},
+		HTTPRequest:  httptest.NewRequest(http.MethodGet, "https://s3.amazonaws.com/bucket/key", nil),
+		HTTPResponse: httptest.NewRecorder().Result(),
+		Error:        awserr.New("NoSuchKey", "bacon", nil),
+		RequestID:    "request",
+	}
+	r.SetContext(t.ctx)
+
+	c.Handlers.Validate.Run(r)
+	t.True(trace.SpanFromContext(r.Context()).IsRecording())
+	c.Handlers.Complete.Run(r)
+
+	spans := t.recorder.Ended()
+	t.Require().Len(spans, 1)
+	t.Equal("s3.GetObject", spans[0].Name())
+	t.Equal(trace.SpanKindClient, spans[0].SpanKind())
+	t.Equal(codes.Error, spans[0].Status().Code)
+	t.Contains(spans[0].Attributes(), attribute.String("aws.request_id", "request"))
+	t.Contains(spans[0].Attributes(), attribute.Int("http.status_code", http.StatusOK))
+}
+
+func TestTracing(t *testing.T) {
+	suite.Run(t, new(tracingTestSuite))
+}
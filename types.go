@@ -1,16 +1,24 @@
 package secretservice
 
 import (
+	"encoding/json"
+	"strings"
+	"time"
+
 	"github.com/marcinwyszynski/ssmvars"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 )
 
 type Release struct {
-	ID        string              `json:"-"`
-	ScopeName string              `json:"-"`
-	Live      bool                `json:"-"`
-	Variables []*ssmvars.Variable `json:"variables"`
+	ID               string              `json:"-"`
+	ScopeName        string              `json:"-"`
+	Live             bool                `json:"-"`
+	Verified         bool                `json:"-"`
+	BaseReleaseID    string              `json:"baseRelease,omitempty"`
+	ContentHash      string              `json:"contentHash,omitempty"`
+	ContentHashKeyID string              `json:"contentHashKeyId,omitempty"`
+	Variables        []*ssmvars.Variable `json:"variables"`
 }
 
 func (r *Release) Timestamp() (int64, error) {
@@ -25,6 +33,186 @@ func (r *Release) Timestamp() (int64, error) {
 }
 
 type Scope struct {
-	Name     string `json:"-"`
-	KMSKeyID string `json:"-"`
+	Name              string `json:"-"`
+	KMSKeyID          string `json:"kmsKeyId"`
+	RequiredApprovals int    `json:"requiredApprovals,omitempty"`
+	Protected         bool   `json:"protected,omitempty"`
+}
+
+// ParseScope decodes a Scope from the value it is stored as. Scopes without
+// any settings other than the KMS key are stored as the bare key ID.
+func ParseScope(name, value string) (*Scope, error) {
+	ret := &Scope{Name: name}
+
+	if !strings.HasPrefix(value, "{") {
+		ret.KMSKeyID = value
+		return ret, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), ret); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal scope settings")
+	}
+
+	return ret, nil
+}
+
+// Encode returns the value the Scope is stored as.
+func (s *Scope) Encode() string {
+	if s.RequiredApprovals == 0 && !s.Protected {
+		return s.KMSKeyID
+	}
+
+	// Marshaling a struct with string, int and bool fields can not fail.
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// ScopeSettings are changes to the settings of a Scope. Settings which are
+// nil are left intact.
+type ScopeSettings struct {
+	RequiredApprovals *int  `json:"requiredApprovals,omitempty"`
+	Protected         *bool `json:"protected,omitempty"`
+}
+
+// Metadata holds optional lifecycle information about a workspace Variable.
+type Metadata struct {
+	ExpiresAt   *time.Time     `json:"expiresAt,omitempty"`
+	RotateEvery *time.Duration `json:"rotateEvery,omitempty"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+}
+
+// Deadline returns the earliest point in time at which the Variable either
+// expires or is due for rotation. The second return value is false if the
+// Variable has neither an expiry date nor a rotation period.
+func (m *Metadata) Deadline() (time.Time, bool) {
+	var ret time.Time
+	var ok bool
+
+	if m.RotateEvery != nil {
+		ret, ok = m.UpdatedAt.Add(*m.RotateEvery), true
+	}
+
+	if m.ExpiresAt != nil && (!ok || m.ExpiresAt.Before(ret)) {
+		ret, ok = *m.ExpiresAt, true
+	}
+
+	return ret, ok
+}
+
+// Expired returns true if the Variable has an expiry date earlier than now.
+func (m *Metadata) Expired(now time.Time) bool {
+	return m.ExpiresAt != nil && !m.ExpiresAt.After(now)
+}
+
+// Notification is sent about a Variable which has either expired or is due
+// for rotation.
+type Notification struct {
+	ScopeName    string    `json:"scope"`
+	VariableName string    `json:"variable"`
+	Deadline     time.Time `json:"deadline"`
+	Expired      bool      `json:"expired"`
+}
+
+// Kinds of ScheduledOperations.
+const (
+	OperationArchiveRelease = "ARCHIVE_RELEASE"
+	OperationCreateRelease  = "CREATE_RELEASE"
+)
+
+// Statuses of ScheduledOperations.
+const (
+	OperationCancelled = "CANCELLED"
+	OperationDone      = "DONE"
+	OperationFailed    = "FAILED"
+	OperationPending   = "PENDING"
+	OperationRunning   = "RUNNING"
+)
+
+// ScheduledOperation is a Release operation to be executed at a later time.
+// For ARCHIVE_RELEASE operations ReleaseID is the Release to archive, for
+// CREATE_RELEASE ones it is the Release created once the operation is done.
+type ScheduledOperation struct {
+	ID        string    `json:"-"`
+	ScopeName string    `json:"-"`
+	Kind      string    `json:"kind"`
+	ReleaseID string    `json:"releaseId,omitempty"`
+	RunAt     time.Time `json:"runAt"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+
+	// ETag identifies the stored version the operation was read from.
+	// Updates fail with a CONFLICT error if the stored operation has changed
+	// since.
+	ETag string `json:"-"`
+}
+
+// Due returns true if the operation is pending and should already be
+// executed.
+func (o *ScheduledOperation) Due(now time.Time) bool {
+	return o.Status == OperationPending && !o.RunAt.After(now)
+}
+
+// Kinds of Proposals.
+const (
+	ProposalChange  = "CHANGE"
+	ProposalRelease = "RELEASE"
+)
+
+// Statuses of Proposals.
+const (
+	ProposalApproved = "APPROVED"
+	ProposalPending  = "PENDING"
+	ProposalRejected = "REJECTED"
+)
+
+// Proposal is a Release or a workspace change waiting for approval. For
+// RELEASE Proposals the Variables are the workspace frozen at the time of the
+// request. For CHANGE Proposals they are the Variables to set, while Remove
+// lists the names of the Variables to remove, and Settings are the changes to
+// the settings of the Scope, if any.
+type Proposal struct {
+	ID            string               `json:"-"`
+	ScopeName     string               `json:"-"`
+	Kind          string               `json:"kind,omitempty"`
+	Message       string               `json:"message"`
+	RequestedBy   string               `json:"requestedBy"`
+	RequestedAt   time.Time            `json:"requestedAt"`
+	BaseReleaseID string               `json:"baseRelease,omitempty"`
+	Variables     []*ssmvars.Variable  `json:"variables"`
+	Remove        []string             `json:"remove,omitempty"`
+	Metadata      map[string]*Metadata `json:"metadata,omitempty"`
+	Approvals     []*Approval          `json:"approvals"`
+	Status        string               `json:"status"`
+	RejectedBy    string               `json:"rejectedBy,omitempty"`
+	ReleaseID     string               `json:"releaseId,omitempty"`
+	Settings      *ScopeSettings       `json:"settings,omitempty"`
+
+	// ETag identifies the stored version the Proposal was read from. Updates
+	// fail with a CONFLICT error if the stored Proposal has changed since.
+	ETag string `json:"-"`
+}
+
+// ProposalKind returns the kind of the Proposal. Proposals stored before
+// change requests were introduced are all RELEASE Proposals.
+func (p *Proposal) ProposalKind() string {
+	if p.Kind == "" {
+		return ProposalRelease
+	}
+	return p.Kind
+}
+
+// ApprovedBy returns true if the Principal has already approved the Proposal.
+func (p *Proposal) ApprovedBy(principalID string) bool {
+	for _, approval := range p.Approvals {
+		if approval.Principal == principalID {
+			return true
+		}
+	}
+	return false
+}
+
+// Approval is a single approval of a Proposal.
+type Approval struct {
+	Principal  string    `json:"principal"`
+	ApprovedAt time.Time `json:"approvedAt"`
 }
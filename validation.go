@@ -0,0 +1,19 @@
+package secretservice
+
+import "regexp"
+
+const maxVariableNameLength = 128
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateVariableName checks that the name can be safely used as the name of
+// an environment variable.
+func ValidateVariableName(name string) error {
+	if len(name) > maxVariableNameLength {
+		return Validation("variable name %q is longer than %d characters", name, maxVariableNameLength)
+	}
+	if !variableNamePattern.MatchString(name) {
+		return Validation("variable name %q is not a valid environment variable name", name)
+	}
+	return nil
+}